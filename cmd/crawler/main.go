@@ -2,546 +2,394 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"io/fs"
 	"log/slog"
-	"math"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"regexp"
-	"strconv"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
-	"github.com/paulmach/orb"
 	"github.com/xuri/excelize/v2"
 
 	"github.com/Xapsiel/bpla_dashboard/internal/config"
 	"github.com/Xapsiel/bpla_dashboard/internal/model"
 	"github.com/Xapsiel/bpla_dashboard/internal/repository"
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
+	"github.com/Xapsiel/bpla_dashboard/internal/watcher"
 )
 
 func InitLogger() *slog.Logger {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
 	return logger
 }
 
-var (
-	coordRe = regexp.MustCompile(`(\d{4,6})([NS])(\d{5,7})([EW])`) // ddmm(ss)Ndddmm(ss)E
-	timeRe  = regexp.MustCompile(`^\d{4}$`)                        // ччмм
-	dateRe  = regexp.MustCompile(`^\d{6}$`)                        // ггммдд
-)
+type options struct {
+	dataDir     string
+	pattern     string
+	recursive   bool
+	concurrency int
+	dryRun      bool
+	policy      string
+	jsonOutput  bool
+	authorID    string
+	metrics     bool
+	preview     int
+	watch       bool
+	poll        bool
+	interval    time.Duration
+	stableFor   int
+}
 
-type Repository interface {
-	SaveMessage(ctx context.Context, mes *model.ParsedMessage) error
+// fileResult — итог обработки одного файла
+type fileResult struct {
+	Path           string                  `json:"path"`
+	FileID         int                     `json:"file_id,omitempty"`
+	Status         string                  `json:"status"`
+	ValidCount     int                     `json:"valid_count"`
+	ErrorCount     int                     `json:"error_count"`
+	DuplicateCount int                     `json:"duplicate_count"`
+	ConflictCount  int                     `json:"conflict_count"`
+	Error          string                  `json:"error,omitempty"`
+	Validation     *model.ValidationReport `json:"validation,omitempty"`
 }
 
-func main() {
-	dataDir := flag.String("d", ".data", "data directory")
-	flag.Parse()
+type summary struct {
+	DryRun         bool         `json:"dry_run"`
+	Policy         string       `json:"policy"`
+	Files          []fileResult `json:"files"`
+	TotalFiles     int          `json:"total_files"`
+	FailedFiles    int          `json:"failed_files"`
+	ValidCount     int          `json:"valid_count"`
+	ErrorCount     int          `json:"error_count"`
+	DuplicateCount int          `json:"duplicate_count"`
+	ConflictCount  int          `json:"conflict_count"`
+}
 
+func main() {
 	logger := InitLogger()
 	slog.SetDefault(logger)
+
+	var opts options
 	configPath := flag.String("c", "config/config.yaml", "The path to the configuration file")
+	flag.StringVar(&opts.dataDir, "d", ".data", "data directory")
+	flag.StringVar(&opts.pattern, "glob", "*.xlsx", "file name pattern")
+	flag.BoolVar(&opts.recursive, "r", false, "scan subdirectories")
+	flag.IntVar(&opts.concurrency, "j", 4, "number of files processed in parallel")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "validate files without saving")
+	flag.StringVar(&opts.policy, "policy", model.ConflictSkip, "conflict policy: skip, overwrite, keep-newest")
+	flag.BoolVar(&opts.jsonOutput, "json", false, "print summary as JSON")
+	flag.StringVar(&opts.authorID, "author", "cli", "author id recorded in files")
+	flag.BoolVar(&opts.metrics, "metrics", true, "refresh metrics after loading")
+	flag.IntVar(&opts.preview, "preview", 0, "number of flights in dry-run preview")
+	flag.BoolVar(&opts.watch, "watch", false, "watch data directory and load new files")
+	flag.BoolVar(&opts.poll, "poll", false, "watch by polling only (network mounts)")
+	flag.DurationVar(&opts.interval, "interval", 5*time.Second, "watch polling interval")
+	flag.IntVar(&opts.stableFor, "stable", 2, "polls a file must stay unchanged before loading")
 	flag.Parse()
 
+	policy, err := service.ParseConflictPolicy(opts.policy)
+	if err != nil {
+		slog.Error("invalid conflict policy", "error", err)
+		os.Exit(2)
+	}
+	opts.policy = policy
+	if opts.concurrency < 1 {
+		opts.concurrency = 1
+	}
+
 	cfg, err := config.New(*configPath)
 	if err != nil {
-		slog.Error("unable to read config:", err.Error())
+		slog.Error("unable to read config", "error", err)
 		os.Exit(1)
 	}
 	db, err := repository.NewPostgresDB(cfg.DatabaseConfig)
 	if err != nil {
-		slog.Error("unable to connect to database:", err.Error())
+		slog.Error("unable to connect to database", "error", err)
 		os.Exit(1)
 	}
 	defer db.Close()
 	repo := repository.NewRepository(db)
-	fmt.Printf(*dataDir)
-	xlsxFiles, err := findXLSXFiles(*dataDir)
-	if err != nil {
-		log.Fatal("Error finding XLSX files:", err)
-	}
-
-	if len(xlsxFiles) == 0 {
-		log.Fatal("No XLSX files found in current directory")
+	operators := service.NewOperatorResolver(repo)
+	parser := service.NewParserService(repo, operators)
+	metrics := service.NewMetricsService(repo, cfg.MetricsConfig, operators)
+
+	if opts.watch {
+		if err := watch(parser, metrics, opts); err != nil {
+			slog.Error("watch error", "error", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	fmt.Printf("Found %d XLSX files: %v\n", len(xlsxFiles), xlsxFiles)
-
-	totalMessages := 0
-	totalValid := 0
-	totalErrors := 0
-
-	for _, xlsxFile := range xlsxFiles {
-		fmt.Printf("\nProcessing file: %s\n", xlsxFile)
-
-		messages, validCount, errorCount := processXLSXFile(xlsxFile, repo)
-
-		totalMessages += len(messages)
-		totalValid += validCount
-		totalErrors += errorCount
-
-		fmt.Printf("File %s: %d messages processed (%d valid, %d with errors)\n",
-			xlsxFile, len(messages), validCount, errorCount)
+	// Позиционные аргументы — файлы или glob-шаблоны, иначе сканируется каталог -d
+	var files []string
+	if flag.NArg() > 0 {
+		files, err = expandArgs(flag.Args())
+	} else {
+		files, err = findFiles(opts.dataDir, opts.pattern, opts.recursive)
 	}
-
-	// Общая статистика
-	fmt.Printf("\n=== SUMMARY ===\n")
-	fmt.Printf("Total files processed: %d\n", len(xlsxFiles))
-	fmt.Printf("Total messages: %d\n", totalMessages)
-	fmt.Printf("Valid messages: %d\n", totalValid)
-	fmt.Printf("Messages with errors: %d\n", totalErrors)
-}
-
-// поиск всех XLSX файлов в указанной папке
-func findXLSXFiles(dir string) ([]string, error) {
-	var xlsxFiles []string
-
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if !info.IsDir() && filepath.Dir(path) == dir && strings.ToLower(filepath.Ext(path)) == ".xlsx" {
-			xlsxFiles = append(xlsxFiles, path)
-		}
-
-		return nil
-	})
-
-	return xlsxFiles, err
-}
-
-func cleanString(s string) string {
-	s = strings.ReplaceAll(s, "\n", "")
-	s = strings.ReplaceAll(s, "\r", "")
-	s = strings.ReplaceAll(s, "\t", " ")
-	s = strings.Join(strings.Fields(s), " ")
-	return strings.TrimSpace(s)
-}
-
-func ProcessXLSX(ctx context.Context, f *excelize.File, authorID, filename string) (int, int, error) {
-
-	sheet := f.GetSheetName(0)
-	rows, err := f.GetRows(sheet)
 	if err != nil {
-		log.Printf("Error reading rows from %s: %v", filename, err)
-		return  0, 0,err
+		slog.Error("error finding files", "error", err)
+		os.Exit(1)
+	}
+	if len(files) == 0 {
+		slog.Error("no files found", "dir", opts.dataDir, "glob", opts.pattern)
+		os.Exit(1)
 	}
 
-	seen := make(map[string]struct{})
-	reportLines := []string{}
-	validCount := 0
-	errorCount := 0
-	for i, row := range rows {
-		if len(row) < 2 {
-			errorCount++
-			continue
-		}
-
-		region := cleanString(row[0])
-		shrRaw := cleanString(row[1])
-		idepRaw := ""
-		iarrRaw := ""
-		if len(row) > 2 {
-			idepRaw = cleanString(row[2])
-		}
-		if len(row) > 3 {
-			iarrRaw = cleanString(row[3])
-		}
-
-		if region == "" || shrRaw == "" {
-			errorCount++
-			continue
-		}
-
-		if i < 5 {
-			reportLines = append(reportLines, fmt.Sprintf("Row %d debug - Region: '%s', SHR: '%s'", i+1, region, shrRaw))
-		}
-
-		msg, _, _ := parseSHR(shrRaw, region)
-
-		if idepRaw != "" {
-		}
-		if iarrRaw != "" {
-			ata := parseATAFromIARR(iarrRaw)
-			if ata != "" {
-				msg.ATA = ata
-			}
-		}
+	ctx := context.Background()
+	results := processFiles(ctx, parser, files, opts)
 
-		key := msg.SID + msg.DOF + msg.ATD
-		if _, exists := seen[key]; exists {
-			reportLines = append(reportLines, fmt.Sprintf("Row %d duplicate: %s", i+1, key))
-			errorCount++
-			continue
+	sum := summary{DryRun: opts.dryRun, Policy: opts.policy, Files: results, TotalFiles: len(results)}
+	for _, res := range results {
+		if res.Status == "error" {
+			sum.FailedFiles++
 		}
-		seen[key] = struct{}{}
-		if msg.SID == "" || msg.DepCoords == "" || msg.ATA == "" {
-			errorCount++
-			continue
-		}
-		err = .SaveMessage(context.Background(), &msg)
-		if err != nil {
-			slog.Error("error saving message:", err.Error())
-			errorCount++
-
-			continue
-		}
-		validCount++
+		sum.ValidCount += res.ValidCount
+		sum.ErrorCount += res.ErrorCount
+		sum.DuplicateCount += res.DuplicateCount
+		sum.ConflictCount += res.ConflictCount
 	}
 
-	return nil, validCount, errorCount
-}
-
-func parseSHR(raw string, region string) (model.ParsedMessage, []string, []string) {
-	msg := model.ParsedMessage{Region: region}
-	changes := []string{}
-	errs := []string{}
-
-	raw = strings.TrimSpace(raw)
-	raw = strings.Trim(raw, `()"`)
-	raw = strings.ReplaceAll(raw, " ", "")
-
-	changes = append(changes, fmt.Sprintf("Raw SHR input: '%s'", raw))
-
-	parts := strings.Split(raw, "-")
-	changes = append(changes, fmt.Sprintf("SHR parts count: %d", len(parts)))
-
-	if len(parts) < 3 {
-		errs = append(errs, "Invalid SHR format - too few parts")
-		return msg, changes, errs
+	if !opts.dryRun && opts.metrics && sum.ValidCount > 0 {
+		if _, err := metrics.Refresh(ctx); err != nil {
+			slog.Error("error update metrics", "error", err)
+		}
 	}
 
-	if !strings.Contains(strings.ToUpper(parts[0]), "SHR") {
-		errs = append(errs, "Invalid SHR format - missing SHR prefix")
-		return msg, changes, errs
+	printSummary(os.Stdout, sum, opts.jsonOutput)
+	if sum.FailedFiles > 0 {
+		os.Exit(1)
 	}
+}
 
-	msg.REG = parts[1]
-	changes = append(changes, fmt.Sprintf("Set REG from index: %s", msg.REG))
-
-	if len(parts) > 2 {
-		atdPart := parts[2]
-		changes = append(changes, fmt.Sprintf("ATD part: '%s'", atdPart))
-
-		if strings.HasPrefix(atdPart, "ZZZZ") {
-			atd := atdPart[4:]
-			changes = append(changes, fmt.Sprintf("ATD time: '%s'", atd))
-
-			if valid, ch := validateTime(atd); valid {
-				msg.ATD = ch
-				changes = append(changes, fmt.Sprintf("Valid ATD: %s", ch))
-			} else {
-				errs = append(errs, fmt.Sprintf("Invalid ATD: '%s'", atd))
-			}
-		} else {
-			if len(atdPart) >= 4 {
-				if valid, ch := validateTime(atdPart); valid {
-					msg.ATD = ch
-					changes = append(changes, fmt.Sprintf("Found ATD without ZZZZ prefix: %s", ch))
-				} else {
-					errs = append(errs, fmt.Sprintf("ATD part doesn't start with ZZZZ and not valid time: '%s'", atdPart))
-				}
-			} else {
-				errs = append(errs, fmt.Sprintf("ATD part too short: '%s'", atdPart))
-			}
+// watch загружает файлы, появляющиеся в каталоге -d, и переносит их в processed/failed
+func watch(parser *service.ParserService, metrics *service.MetricsService, opts options) error {
+	if opts.dryRun {
+		return fmt.Errorf("dry-run is not supported in watch mode")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w := watcher.New(watcher.Config{
+		Dir:       opts.dataDir,
+		Pattern:   opts.pattern,
+		Interval:  opts.interval,
+		StableFor: opts.stableFor,
+		Poll:      opts.poll,
+	}, func(ctx context.Context, path string) error {
+		res := processFile(ctx, parser, path, opts)
+		if res.Status == "error" {
+			return errors.New(res.Error)
 		}
-	} else {
-		errs = append(errs, "No ATD part found")
-	}
-
-	minAlt := 0
-	maxAlt := 0
-	foundHeight := false
-	heightRe := regexp.MustCompile(`(K\d{4})?M(\d{4})(/M(\d{4}))?`)
-	for i := 3; i < len(parts) && !foundHeight; i++ {
-		matches := heightRe.FindStringSubmatch(parts[i])
-		if len(matches) > 0 {
-			foundHeight = true
-			maxStr := matches[2]
-			max, err := strconv.Atoi(maxStr)
-			if err == nil {
-				maxAlt = max * 10
-			} else {
-				errs = append(errs, fmt.Sprintf("Invalid max height: %s", maxStr))
+		if opts.metrics && res.ValidCount > 0 {
+			if _, err := metrics.Refresh(ctx); err != nil {
+				slog.Error("error update metrics", "error", err)
 			}
+		}
+		return nil
+	})
+	return w.Run(ctx)
+}
 
-			if matches[3] != "" && matches[4] != "" {
-				minStr := matches[2]
-				min, err := strconv.Atoi(minStr)
-				if err == nil {
-					minAlt = min * 10
-				} else {
-					errs = append(errs, fmt.Sprintf("Invalid min height: %s", minStr))
-				}
-				maxStr = matches[4]
-				max, err = strconv.Atoi(maxStr)
-				if err == nil {
-					maxAlt = max * 10
-				} else {
-					errs = append(errs, fmt.Sprintf("Invalid max height: %s", maxStr))
-				}
-			} else {
-				minAlt = 0
+func processFiles(ctx context.Context, parser *service.ParserService, files []string, opts options) []fileResult {
+	results := make([]fileResult, len(files))
+	jobs := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < opts.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = processFile(ctx, parser, files[i], opts)
 			}
-			changes = append(changes, fmt.Sprintf("Parsed heights: min=%d m, max=%d m", minAlt, maxAlt))
-		}
+		}()
 	}
-	if !foundHeight {
-		changes = append(changes, "No height information found")
+	for i := range files {
+		jobs <- i
 	}
-	msg.MinAlt = minAlt
-	msg.MaxAlt = maxAlt
-
-	// Поле 15: высоты /ZONA ... - парсим зону полета
-	zoneCoords, zoneLatLon := parseZone(parts, changes)
-	msg.ZoneCoords = zoneCoords
-	msg.ZoneLatLon = zoneLatLon
+	close(jobs)
+	wg.Wait()
+	return results
+}
 
-	// Поле 16: ZZZZччмм (EET)
-	if len(parts) > 4 {
-		eetPart := parts[4]
-		if strings.HasPrefix(eetPart, "ZZZZ") {
-			eet := eetPart[4:]
-			if valid, ch := validateTime(eet); valid {
-				msg.ATA = ch
-				changes = append(changes, fmt.Sprintf("Added ATA from EET: %s", ch))
-			}
-		}
+func processFile(ctx context.Context, parser *service.ParserService, path string, opts options) fileResult {
+	res := fileResult{Path: path}
+	fail := func(err error) fileResult {
+		slog.Error("error processing file", "path", path, "error", err)
+		res.Status = "error"
+		res.Error = err.Error()
+		return res
 	}
 
-	var field18 string
-	if len(parts) > 5 {
-		field18 = strings.Join(parts[5:], "-")
-	} else if len(parts) > 4 {
-		field18 = parts[4]
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return fail(err)
 	}
+	defer f.Close()
 
-	changes = append(changes, fmt.Sprintf("Field 18: '%s'", field18))
-	kv := parseField18(field18)
-	changes = append(changes, fmt.Sprintf("Parsed fields: %v", kv))
-
-	if dof, ok := kv["DOF/"]; ok {
-		if valid, ch := validateDate(dof); valid {
-			msg.DOF = ch
-			changes = append(changes, fmt.Sprintf("Normalized DOF: %s -> %s", dof, ch))
-		} else {
-			errs = append(errs, "Invalid DOF")
-		}
-	}
-	if dep, ok := kv["DEP/"]; ok {
-		changes = append(changes, fmt.Sprintf("Found DEP: '%s'", dep))
-		if valid, norm, latlon := validateCoords(dep); valid {
-			msg.DepCoords = norm
-			msg.DepLatLon = latlon
-			changes = append(changes, fmt.Sprintf("Normalized DEP: %s -> %s", dep, norm))
-		} else {
-			errs = append(errs, fmt.Sprintf("Invalid DEP coords: '%s'", dep))
+	if opts.dryRun {
+		report, err := parser.ValidateXLSX(ctx, f, filepath.Base(path), opts.preview)
+		if err != nil {
+			return fail(err)
 		}
-	} else {
-		changes = append(changes, "No DEP field found")
+		res.Status = "validated"
+		res.ValidCount = report.ValidCount
+		res.ErrorCount = report.ErrorCount
+		res.DuplicateCount = len(report.ExistingInDB)
+		res.Validation = &report
+		return res
 	}
 
-	if dest, ok := kv["DEST/"]; ok {
-		changes = append(changes, fmt.Sprintf("Found DEST: '%s'", dest))
-		if valid, norm, latlon := validateCoords(dest); valid {
-			msg.ArrCoords = norm
-			msg.ArrLatLon = latlon
-			changes = append(changes, fmt.Sprintf("Normalized DEST: %s -> %s", dest, norm))
-		} else {
-			errs = append(errs, fmt.Sprintf("Invalid DEST coords: '%s'", dest))
-		}
-	} else {
-		changes = append(changes, "No DEST field found")
-	}
-	if sid, ok := kv["SID/"]; ok {
-		msg.SID = sid
-		changes = append(changes, fmt.Sprintf("Found SID: %s", sid))
-	} else {
-		changes = append(changes, "No SID field found")
+	mf, err := fileInfo(path, opts)
+	if err != nil {
+		return fail(err)
 	}
-	msg.OPR = kv["OPR/"]
-	if reg, ok := kv["REG/"]; ok {
-		msg.REG = reg
+	fileID, err := parser.RegisterFile(ctx, mf)
+	if errors.Is(err, model.ErrFileExists) {
+		slog.Info("file already uploaded", "path", path, "file_id", fileID)
+		res.FileID = fileID
+		res.Status = "duplicate"
+		return res
 	}
-	msg.TYP = kv["TYP/"]
-	msg.RMK = kv["RMK/"]
-
-	for k := range kv {
-		if !strings.HasPrefix(k, "DEP/") && !strings.HasPrefix(k, "DEST/") && !strings.HasPrefix(k, "DOF/") && !strings.HasPrefix(k, "SID/") && !strings.HasPrefix(k, "OPR/") && !strings.HasPrefix(k, "REG/") && !strings.HasPrefix(k, "TYP/") && !strings.HasPrefix(k, "RMK/") && !strings.HasPrefix(k, "STS/") && !strings.HasPrefix(k, "EET/") {
-			changes = append(changes, fmt.Sprintf("Ignored extra field: %s", k))
-		}
+	if err != nil {
+		return fail(err)
 	}
-
-	return msg, changes, errs
+	res.FileID = fileID
+	report, err := parser.IngestFile(ctx, f, mf, fileID)
+	if err != nil {
+		return fail(err)
+	}
+	res.Status = "parsed"
+	res.ValidCount = report.ValidCount
+	res.ErrorCount = report.ErrorCount
+	res.DuplicateCount = report.DuplicateCount
+	res.ConflictCount = report.ConflictCount
+	slog.Info("file processed", "path", path, "file_id", fileID, "valid", report.ValidCount, "errors", report.ErrorCount)
+	return res
 }
 
-func parseField18(raw string) map[string]string {
-	kv := make(map[string]string)
-
-	if raw == "" {
-		return kv
+// fileInfo описывает файл для таблицы files. По хешу содержимого повторная загрузка
+// того же файла распознается и не выполняется.
+func fileInfo(path string, opts options) (model.File, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return model.File{}, err
 	}
-
-	patterns := map[string]string{
-		"DOF/":  `DOF/(\d{6})`,
-		"DEP/":  `DEP/([0-9]+[NS][0-9]+[EW])`,
-		"DEST/": `DEST/([0-9]+[NS][0-9]+[EW])`,
-		"SID/":  `SID/(\d+)`,
-		"OPR/":  `OPR/([^A-Z/]+)`,
-		"REG/":  `REG/([^A-Z/]+)`,
-		"TYP/":  `TYP/([A-Z]+)`,
-		"RMK/":  `RMK/([^A-Z/]+)`,
+	fh, err := os.Open(path)
+	if err != nil {
+		return model.File{}, err
 	}
-
-	for key, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		if match := re.FindStringSubmatch(raw); len(match) > 1 {
-			kv[key] = strings.TrimSpace(match[1])
-		}
+	defer fh.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, fh); err != nil {
+		return model.File{}, err
 	}
-
-	return kv
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	metadata, err := json.Marshal(map[string]interface{}{
+		"source":   "cli",
+		"path":     abs,
+		"size":     st.Size(),
+		"mod_time": st.ModTime().UTC(),
+		"sha256":   sum,
+	})
+	if err != nil {
+		return model.File{}, err
+	}
+	modTime := st.ModTime().UTC()
+	return model.File{
+		AuthorID:       opts.authorID,
+		Filename:       filepath.Base(path),
+		Size:           st.Size(),
+		Metadata:       metadata,
+		SHA256:         sum,
+		ConflictPolicy: opts.policy,
+		ModifiedAt:     &modTime,
+	}, nil
 }
 
-// парсинг зоны полета из поля 15
-func parseZone(parts []string, changes []string) ([]string, []orb.Point) {
-	var zoneCoords []string
-	var zoneLatLon []orb.Point
-
-	// ищем поле с /ZONA
-	for _, part := range parts {
-		if strings.Contains(part, "/ZONA") {
-			changes = append(changes, fmt.Sprintf("Found ZONA field: %s", part))
-
-			// извлекаем координаты из зоны
-			zonePattern := `([0-9]+[NS][0-9]+[EW])`
-			re := regexp.MustCompile(zonePattern)
-			matches := re.FindAllString(part, -1)
-
-			for _, coord := range matches {
-				zoneCoords = append(zoneCoords, coord)
-
-				// конвертируем в decimal
-				if valid, norm, latlon := validateCoords(coord); valid {
-					zoneLatLon = append(zoneLatLon, latlon)
-					changes = append(changes, fmt.Sprintf("Zone coord: %s -> %s", coord, norm))
-				} else {
-					changes = append(changes, fmt.Sprintf("Invalid zone coord: %s", coord))
-				}
+// поиск файлов по шаблону в указанной папке
+func findFiles(dir, pattern string, recursive bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
 			}
-
-			changes = append(changes, fmt.Sprintf("Found %d zone coordinates", len(zoneCoords)))
-			break
+			return nil
 		}
-	}
-
-	return zoneCoords, zoneLatLon
-}
-
-// валидация и нормализация времени ччмм -> чч:мм
-func validateTime(t string) (bool, string) {
-	t = strings.TrimSpace(t)
-	if !timeRe.MatchString(t) {
-		return false, ""
-	}
-	if len(t) != 4 {
-		return false, ""
-	}
-
-	h, err1 := strconv.Atoi(t[:2])
-	m, err2 := strconv.Atoi(t[2:])
-
-	if err1 != nil || err2 != nil {
-		return false, ""
-	}
-
-	if h < 0 || h > 23 || m < 0 || m > 59 {
-		return false, ""
-	}
-
-	return true, fmt.Sprintf("%02d:%02d", h, m)
-}
-
-// валидация и нормализация даты
-func validateDate(d string) (bool, string) {
-	if !dateRe.MatchString(d) {
-		return false, ""
-	}
-	y := "20" + d[:2]
-	m := d[2:4]
-	dd := d[4:]
-	_, err := time.Parse("2006-01-02", y+"-"+m+"-"+dd)
-	if err != nil {
-		return false, ""
-	}
-	return true, y + "-" + m + "-" + dd
+		ok, err := filepath.Match(pattern, d.Name())
+		if err != nil {
+			return err
+		}
+		if ok && strings.EqualFold(filepath.Ext(path), ".xlsx") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
 }
 
-// валидация и нормализация координат
-func validateCoords(c string) (bool, string, orb.Point) {
-	m := coordRe.FindStringSubmatch(c)
-	if len(m) != 5 {
-		return false, "", orb.Point{}
-	}
-	latStr, ns, lonStr, ew := m[1], m[2], m[3], m[4]
-
-	// Нормализация к ddmmss / dddmmss
-	if len(latStr) == 4 {
-		latStr += "00"
-	} else if len(latStr) == 5 {
-		latStr = latStr[:4] + "0" + latStr[4:]
-	}
-	if len(lonStr) == 5 {
-		lonStr += "00"
-	} else if len(lonStr) == 6 {
-		lonStr = lonStr[:5] + "0" + lonStr[5:]
-	}
-
-	// decimal
-	latD := float64(strToInt(latStr[:2]))
-	latM := float64(strToInt(latStr[2:4]))
-	latS := float64(strToInt(latStr[4:6]))
-	lat := latD + latM/60 + latS/3600
-	if ns == "S" {
-		lat = -lat
-	}
-	if lat < -90 || lat > 90 {
-		return false, "", [2]float64{}
-	}
-
-	lonD := float64(strToInt(lonStr[:3]))
-	lonM := float64(strToInt(lonStr[3:5]))
-	lonS := float64(strToInt(lonStr[5:7]))
-	lon := lonD + lonM/60 + lonS/3600
-	if ew == "W" {
-		lon = -lon
-	}
-	if math.Abs(lon) > 180 {
-		return false, "", [2]float64{}
+func expandArgs(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
 	}
-
-	norm := latStr + ns + lonStr + ew
-	return true, norm, orb.Point{lon, lat}
-}
-
-func strToInt(s string) int {
-	i, _ := strconv.Atoi(s)
-	return i
+	sort.Strings(files)
+	return files, nil
 }
 
-func parseATAFromIARR(raw string) string {
-	parts := strings.Split(raw, "-")
-	for _, p := range parts {
-		if strings.HasPrefix(p, "ATA ") {
-			t := strings.TrimSpace(p[4:])
-			if valid, norm := validateTime(t); valid {
-				return norm
-			}
+func printSummary(w io.Writer, sum summary, asJSON bool) {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(sum); err != nil {
+			slog.Error("error encoding summary", "error", err)
 		}
+		return
 	}
-	return ""
+	for _, res := range sum.Files {
+		if res.Error != "" {
+			fmt.Fprintf(w, "%s: error: %s\n", res.Path, res.Error)
+			continue
+		}
+		if res.Status == "duplicate" {
+			fmt.Fprintf(w, "%s: already uploaded as file %d\n", res.Path, res.FileID)
+			continue
+		}
+		fmt.Fprintf(w, "%s: %d valid, %d errors, %d duplicates, %d conflicts\n",
+			res.Path, res.ValidCount, res.ErrorCount, res.DuplicateCount, res.ConflictCount)
+	}
+	fmt.Fprintf(w, "\n=== SUMMARY ===\n")
+	if sum.DryRun {
+		fmt.Fprintf(w, "Dry run: nothing was saved\n")
+	}
+	fmt.Fprintf(w, "Total files processed: %d (%d failed)\n", sum.TotalFiles, sum.FailedFiles)
+	fmt.Fprintf(w, "Valid messages: %d\n", sum.ValidCount)
+	fmt.Fprintf(w, "Messages with errors: %d\n", sum.ErrorCount)
+	fmt.Fprintf(w, "Duplicates: %d\n", sum.DuplicateCount)
+	fmt.Fprintf(w, "Conflicts (%s): %d\n", sum.Policy, sum.ConflictCount)
 }
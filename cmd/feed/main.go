@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"time"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
+)
+
+// Заглушка AFTN-канала: читает телеграммы из файла и по одной отправляет их в TCP-приемник сервера
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	file := flag.String("f", "telegrams.txt", "file with SHR/DEP/ARR telegrams")
+	addr := flag.String("addr", "127.0.0.1:9999", "telegram feed address")
+	delay := flag.Duration("delay", 500*time.Millisecond, "delay between telegrams")
+	flag.Parse()
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		slog.Error("unable to read telegrams file", "error", err)
+		os.Exit(1)
+	}
+	raws := service.SplitTelegrams(string(data))
+
+	conn, err := net.Dial("tcp", *addr)
+	if err != nil {
+		slog.Error("unable to connect to feed", "addr", *addr, "error", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	for i, raw := range raws {
+		if _, err := fmt.Fprintf(conn, "%s\nNNNN\n", raw); err != nil {
+			slog.Error("unable to send telegram", "index", i, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("telegram sent", "index", i)
+		time.Sleep(*delay)
+	}
+	slog.Info("feed finished", "telegrams", len(raws))
+}
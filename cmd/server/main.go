@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"log/slog"
@@ -12,6 +13,7 @@ import (
 	_ "github.com/Xapsiel/bpla_dashboard/docs"
 	"github.com/Xapsiel/bpla_dashboard/internal/config"
 	httpv1 "github.com/Xapsiel/bpla_dashboard/internal/entrypoint"
+	"github.com/Xapsiel/bpla_dashboard/internal/feed"
 	"github.com/Xapsiel/bpla_dashboard/internal/repository"
 	"github.com/Xapsiel/bpla_dashboard/internal/service"
 )
@@ -35,12 +37,12 @@ func main() {
 
 	cfg, err := config.New(*configPath)
 	if err != nil {
-		slog.Error("unable to read config:", err.Error())
+		slog.Error("unable to read config", "error", err)
 		os.Exit(1)
 	}
 	db, err := repository.NewPostgresDB(cfg.DatabaseConfig)
 	if err != nil {
-		slog.Error("unable to connect to database:", err.Error())
+		slog.Error("unable to connect to database", "error", err)
 		os.Exit(1)
 	}
 	defer db.Close()
@@ -52,15 +54,41 @@ func main() {
 
 	// Swagger UI endpoint
 	app.Get("/swagger/*", fiberSwagger.New())
-	service := service.New(repo, cfg.OidcConfig)
+	srv := service.New(repo, cfg.OidcConfig, cfg.MetricsConfig, cfg.ConflictConfig, cfg.SiteConfig)
 	router := httpv1.New(httpv1.Config{
 		Repo:             repo,
 		Domain:           cfg.Domain,
-		Service:          &service,
+		Service:          &srv,
 		IsProduction:     cfg.IsProduction,
 		Origins:          cfg.Origins,
 		RedirectFrontURI: cfg.RedirectFrontURI,
 	})
 	router.Routes(app)
+
+	feedPolicy, err := service.ParseConflictPolicy(cfg.IngestConfig.ConflictPolicy)
+	if err != nil {
+		slog.Error("invalid ingest conflict policy", "error", err)
+		os.Exit(1)
+	}
+	telegramFeed := feed.New(service.SplitTelegrams, func(ctx context.Context, raws []string) {
+		report := srv.TelegramService.Ingest(ctx, raws, feedPolicy)
+		slog.Info("telegrams ingested from feed", "received", report.Received, "saved", report.Saved,
+			"duplicates", report.Duplicates, "conflicts", report.Conflicts,
+			"matched", report.Matched, "pending", report.Pending, "failed", report.Failed)
+	})
+	if cfg.IngestConfig.FeedFile != "" {
+		go func() {
+			if err := telegramFeed.ReadFile(context.Background(), cfg.IngestConfig.FeedFile); err != nil {
+				slog.Error("telegram feed file error", "error", err)
+			}
+		}()
+	}
+	if cfg.IngestConfig.TCPAddr != "" {
+		go func() {
+			if err := telegramFeed.ListenTCP(context.Background(), cfg.IngestConfig.TCPAddr); err != nil {
+				slog.Error("telegram feed tcp error", "error", err)
+			}
+		}()
+	}
 	log.Fatal(app.Listen(":" + cfg.HostConfig.Port))
 }
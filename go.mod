@@ -4,6 +4,7 @@ go 1.24.1
 
 require (
 	github.com/coreos/go-oidc/v3 v3.15.0
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/gofiber/fiber/v2 v2.52.9
 	github.com/gofiber/swagger v1.1.1
 	github.com/golang-jwt/jwt v3.2.2+incompatible
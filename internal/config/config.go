@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
@@ -8,6 +10,10 @@ type Config struct {
 	DatabaseConfig `yaml:"database"`
 	HostConfig     `yaml:"host"`
 	OidcConfig     `yaml:"oidc"`
+	IngestConfig   `yaml:"ingest"`
+	MetricsConfig  `yaml:"metrics"`
+	ConflictConfig `yaml:"conflicts"`
+	SiteConfig     `yaml:"launchSites"`
 }
 
 type HostConfig struct {
@@ -39,6 +45,30 @@ type OidcConfig struct {
 	RedirectFrontURI string   `yaml:"redirect_front_uri"`
 }
 
+type IngestConfig struct {
+	TCPAddr  string `yaml:"tcpAddr"`  // Адрес TCP-приемника телеграмм, например 127.0.0.1:9999
+	FeedFile string `yaml:"feedFile"` // Файл с телеграммами, загружаемый при старте
+	// Политика конфликтов SHR из потока: skip, overwrite или keep-newest
+	ConflictPolicy string `yaml:"conflictPolicy" env-default:"skip"`
+}
+
+type MetricsConfig struct {
+	Workers     int           `yaml:"workers" env-default:"4"`      // Параллельных пересчетов регион/год
+	Timeout     time.Duration `yaml:"timeout" env-default:"30m"`    // Ограничение на весь пересчет
+	TaskTimeout time.Duration `yaml:"taskTimeout" env-default:"2m"` // Ограничение на один регион/год
+}
+
+type ConflictConfig struct {
+	Separation float64       `yaml:"separation" env-default:"500"` // Минимальное расстояние между зонами полетов, м
+	DayTimeout time.Duration `yaml:"dayTimeout" env-default:"5m"`  // Ограничение на проверку одних суток
+}
+
+type SiteConfig struct {
+	Radius     float64       `yaml:"radius" env-default:"300"`   // Радиус соседства точек вылета, м
+	MinFlights int           `yaml:"minFlights" env-default:"5"` // Наименьшее число вылетов места
+	Timeout    time.Duration `yaml:"timeout" env-default:"10m"`  // Ограничение на кластеризацию
+}
+
 func New(path string) (*Config, error) {
 	var cfg Config
 
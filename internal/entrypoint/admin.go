@@ -0,0 +1,90 @@
+package httpv1
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
+)
+
+// RebuildMetricsHandler
+// @Summary Полный пересчет метрик
+// @Description Запускает в фоне пересчет метрик по всем регионам и годам. Обычно метрики пересчитываются только по затронутым регионам/месяцам
+// @Tags admin
+// @Produce json
+// @Success 202 {object} httpv1.APIResponse
+// @Failure 409 {object} httpv1.APIResponse
+// @Router /admin/metrics/rebuild [post]
+func (r *Router) RebuildMetricsHandler(ctx *fiber.Ctx) error {
+	return r.startRefresh(ctx, model.RefreshFull)
+}
+
+// RefreshMetricsHandler
+// @Summary Инкрементальный пересчет метрик
+// @Description Запускает в фоне пересчет метрик по партициям, ожидающим пересчета
+// @Tags admin
+// @Produce json
+// @Success 202 {object} httpv1.APIResponse
+// @Failure 409 {object} httpv1.APIResponse
+// @Router /admin/metrics/refresh [post]
+func (r *Router) RefreshMetricsHandler(ctx *fiber.Ctx) error {
+	return r.startRefresh(ctx, model.RefreshIncremental)
+}
+
+func (r *Router) startRefresh(ctx *fiber.Ctx, kind string) error {
+	err := r.service.MetricsService.Start(kind)
+	if errors.Is(err, service.ErrRefreshRunning) {
+		return ctx.Status(fiber.StatusConflict).JSON(r.NewErrorResponse(fiber.StatusConflict, "Пересчет метрик уже выполняется"))
+	}
+	if err != nil {
+		slog.Error("failed to start metrics refresh", "kind", kind, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка запуска пересчета"))
+	}
+	return ctx.Status(fiber.StatusAccepted).JSON(r.NewSuccessResponse(fiber.Map{
+		"message": "Пересчет метрик запущен",
+		"kind":    kind,
+	}, ""))
+}
+
+// GetRefreshRunsHandler
+// @Summary Запуски пересчета метрик
+// @Description Возвращает последние запуски пересчета: статус, длительность и регионы/годы с ошибками. Первым идет самый новый
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Количество запусков (по умолчанию 1)"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /admin/metrics/refresh [get]
+func (r *Router) GetRefreshRunsHandler(ctx *fiber.Ctx) error {
+	limit := ctx.QueryInt("limit", 1)
+	if limit < 1 || limit > 100 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "limit должен быть от 1 до 100"))
+	}
+	runs, err := r.service.MetricsService.RefreshRuns(context.Background(), limit)
+	if err != nil {
+		slog.Error("failed to get refresh runs", "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка получения запусков пересчета"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(runs, ""))
+}
+
+// GetDirtyPartitionsHandler
+// @Summary Партиции, ожидающие пересчета
+// @Description Возвращает регионы/годы/месяцы, в которых менялись полеты после последнего пересчета метрик
+// @Tags admin
+// @Produce json
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /admin/metrics/dirty [get]
+func (r *Router) GetDirtyPartitionsHandler(ctx *fiber.Ctx) error {
+	parts, err := r.service.MetricsService.DirtyPartitions(context.Background())
+	if err != nil {
+		slog.Error("failed to get dirty partitions", "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка получения партиций"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(parts, ""))
+}
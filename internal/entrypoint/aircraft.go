@@ -0,0 +1,141 @@
+package httpv1
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
+)
+
+// GetAircraftListHandler
+// @Summary Реестр БВС
+// @Description Борта, собранные по регистрационным номерам REG/, с типами TYP/, числом полетов, налетом, числом операторов и датами первого и последнего полета за период
+// @Tags aircraft
+// @Produce json
+// @Param period query string false "Период по дате вылета; по умолчанию текущий год"
+// @Param reg_id query int false "Код региона (0 — все)"
+// @Param category query string false "Категория (multirotor, fixed_wing, vtol, helicopter, airship, other или unknown — неразмеченные)"
+// @Param q query string false "Подстрока регистрационного номера"
+// @Param limit query int false "Не более (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /aircraft [get]
+func (r *Router) GetAircraftListHandler(ctx *fiber.Ctx) error {
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	f := model.AircraftFilter{
+		Period:   period,
+		RegionID: ctx.QueryInt("reg_id", 0),
+		Category: ctx.Query("category"),
+		Query:    ctx.Query("q"),
+		Limit:    ctx.QueryInt("limit", 100),
+		Offset:   ctx.QueryInt("offset", 0),
+	}
+	res, err := r.service.AircraftService.List(context.Background(), f)
+	if err != nil {
+		slog.Error("failed to get aircraft", "period", period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении реестра БВС"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetAircraftHandler
+// @Summary Карточка БВС
+// @Description Разметка борта, показатели за период и операторы, выполнявшие на нем полеты
+// @Tags aircraft
+// @Produce json
+// @Param id path int true "ID борта"
+// @Param period query string false "Период по дате вылета; по умолчанию текущий год"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Router /aircraft/{id} [get]
+func (r *Router) GetAircraftHandler(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный ID борта"))
+	}
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	res, err := r.service.AircraftService.Profile(context.Background(), id, period)
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Борт не найден"))
+	}
+	if err != nil {
+		slog.Error("failed to get aircraft", "id", id, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении борта"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// AnnotateAircraftHandler
+// @Summary Разметить БВС
+// @Description Задает модель, класс массы (micro, light, medium, heavy), категорию (multirotor, fixed_wing, vtol, helicopter, airship, other) и примечание. Пустые значения снимают разметку
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "ID борта"
+// @Param request body model.AircraftAnnotation true "Разметка"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Router /admin/aircraft/{id} [post]
+func (r *Router) AnnotateAircraftHandler(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный ID борта"))
+	}
+	var req model.AircraftAnnotation
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректное тело запроса"))
+	}
+	res, err := r.service.AircraftService.Annotate(context.Background(), id, req)
+	if errors.Is(err, service.ErrInvalidAircraft) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, err.Error()))
+	}
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Борт не найден"))
+	}
+	if err != nil {
+		slog.Error("failed to annotate aircraft", "id", id, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при сохранении разметки борта"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetCategoryMetricsHandler
+// @Summary Полеты по категориям БВС
+// @Description Число бортов, полетов, налет и средняя длительность по категориям из реестра БВС вместо исходного поля TYP/. unknown — борт не размечен, unregistered — в сообщении нет регистрационного номера
+// @Tags metrics
+// @Produce json
+// @Param period query string false "Период по дате вылета; по умолчанию текущий год"
+// @Param reg_id query int false "Код региона (0 — вся РФ)"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /metrics/categories [get]
+func (r *Router) GetCategoryMetricsHandler(ctx *fiber.Ctx) error {
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	regionID := ctx.QueryInt("reg_id", 0)
+	res, err := r.service.MetricsService.CategoryBreakdown(context.Background(), period, regionID)
+	if err != nil {
+		slog.Error("failed to get category metrics", "period", period.Label, "region", regionID, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении метрик по категориям"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
@@ -0,0 +1,142 @@
+package httpv1
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
+)
+
+type SaveCeilingRequest struct {
+	Name     string  `json:"name"`
+	RegionID *int    `json:"region_id"` // Пусто — для всех регионов
+	ZoneKind *string `json:"zone_kind"` // no_fly или restricted; пусто — для любой точки вылета
+	MaxAlt   int     `json:"max_alt"`   // Потолок, м
+}
+
+// GetCeilingsHandler
+// @Summary Потолки высоты
+// @Description Настроенные потолки высоты: общий, по регионам и по типу зоны вылета
+// @Tags admin
+// @Produce json
+// @Success 200 {object} httpv1.APIResponse
+// @Router /admin/ceilings [get]
+func (r *Router) GetCeilingsHandler(ctx *fiber.Ctx) error {
+	res, err := r.service.AltitudeService.Ceilings(context.Background())
+	if err != nil {
+		slog.Error("failed to get altitude ceilings", "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении потолков"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// SaveCeilingHandler
+// @Summary Задать потолок высоты
+// @Description Создает потолок или обновляет существующий для того же региона и типа зоны, затем переназначает потолки всем полетам (применяется самый низкий из подходящих)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body httpv1.SaveCeilingRequest true "Потолок"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Router /admin/ceilings [post]
+func (r *Router) SaveCeilingHandler(ctx *fiber.Ctx) error {
+	var req SaveCeilingRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректное тело запроса"))
+	}
+	res, err := r.service.AltitudeService.SaveCeiling(context.Background(), model.AltitudeCeiling{
+		Name:     req.Name,
+		RegionID: req.RegionID,
+		ZoneKind: req.ZoneKind,
+		MaxAlt:   req.MaxAlt,
+	})
+	if errors.Is(err, service.ErrInvalidCeiling) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, err.Error()))
+	}
+	if err != nil {
+		slog.Error("failed to save altitude ceiling", "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при сохранении потолка"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// DeleteCeilingHandler
+// @Summary Удалить потолок высоты
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID потолка"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Router /admin/ceilings/{id} [delete]
+func (r *Router) DeleteCeilingHandler(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный id потолка"))
+	}
+	err = r.service.AltitudeService.DeleteCeiling(context.Background(), id)
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Потолок не найден"))
+	}
+	if err != nil {
+		slog.Error("failed to delete altitude ceiling", "id", id, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при удалении потолка"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(nil, "Потолок удален"))
+}
+
+// GetAltitudeComplianceHandler
+// @Summary Соблюдение потолков высоты
+// @Description Число и доля полетов с заявленной максимальной высотой выше назначенного потолка, всего и по каждому потолку
+// @Tags metrics
+// @Produce json
+// @Param reg_id query int false "Код региона (0 — вся РФ)"
+// @Param period query string false "Период по дате вылета; по умолчанию текущий год"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Router /metrics/altitude [get]
+func (r *Router) GetAltitudeComplianceHandler(ctx *fiber.Ctx) error {
+	regID := ctx.QueryInt("reg_id", 0)
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	res, err := r.service.AltitudeService.Compliance(context.Background(), regID, period)
+	if err != nil {
+		slog.Error("failed to get altitude compliance", "reg_id", regID, "period", period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при расчете соблюдения потолков"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetAltitudeProfileHandler
+// @Summary Профиль высоты полета
+// @Description Заявленный диапазон высот полета от вылета до посадки (UTC) и примененный потолок для линейного графика
+// @Tags metrics
+// @Produce json
+// @Param sid query string true "SID полета"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Router /metrics/altitude/profile [get]
+func (r *Router) GetAltitudeProfileHandler(ctx *fiber.Ctx) error {
+	sid := ctx.Query("sid")
+	if sid == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Отсутствует sid"))
+	}
+	res, err := r.service.AltitudeService.Profile(context.Background(), sid)
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Полет не найден"))
+	}
+	if err != nil {
+		slog.Error("failed to get altitude profile", "sid", sid, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении профиля высоты"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
@@ -0,0 +1,153 @@
+package httpv1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
+)
+
+type SaveAreaRequest struct {
+	Name     string          `json:"name"`
+	Geometry json.RawMessage `json:"geometry"` // GeoJSON: Polygon, MultiPolygon, Feature или FeatureCollection из одного объекта
+}
+
+type AreaMetricsRequest struct {
+	Geometry json.RawMessage `json:"geometry"` // GeoJSON: Polygon, MultiPolygon, Feature или FeatureCollection из одного объекта
+}
+
+// GetAreasHandler
+// @Summary Области интереса
+// @Description Сохраненные области с площадью и геометрией в GeoJSON
+// @Tags areas
+// @Produce json
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /areas [get]
+func (r *Router) GetAreasHandler(ctx *fiber.Ctx) error {
+	res, err := r.service.AreaService.Areas(context.Background())
+	if err != nil {
+		slog.Error("failed to get areas", "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении областей"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// SaveAreaHandler
+// @Summary Сохранить область интереса
+// @Description Сохраняет именованный полигон для повторного расчета метрик. Площадь — не более 2 000 000 км²
+// @Tags areas
+// @Accept json
+// @Produce json
+// @Param request body httpv1.SaveAreaRequest true "Область"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Router /areas [post]
+func (r *Router) SaveAreaHandler(ctx *fiber.Ctx) error {
+	var req SaveAreaRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректное тело запроса"))
+	}
+	res, err := r.service.AreaService.SaveArea(context.Background(), req.Name, req.Geometry)
+	if errors.Is(err, service.ErrInvalidArea) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, err.Error()))
+	}
+	if err != nil {
+		slog.Error("failed to save area", "name", req.Name, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при сохранении области"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// DeleteAreaHandler
+// @Summary Удалить область интереса
+// @Tags areas
+// @Produce json
+// @Param id path int true "ID области"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Router /areas/{id} [delete]
+func (r *Router) DeleteAreaHandler(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный ID области"))
+	}
+	err = r.service.AreaService.DeleteArea(context.Background(), id)
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Область не найдена"))
+	}
+	if err != nil {
+		slog.Error("failed to delete area", "id", id, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при удалении области"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(nil, "Область удалена"))
+}
+
+// GetAreaMetricsHandler
+// @Summary Метрики сохраненной области
+// @Description Полный набор метрик за период по полетам с вылетом из области (departure) или с вылетом из области либо пролетом через нее зоной ZONA или прямой вылет–посадка (crossing). Часы и дни — в зоне региона, в котором лежит область
+// @Tags areas
+// @Produce json
+// @Param id path int true "ID области"
+// @Param period query string false "Период по дате вылета; по умолчанию текущий год"
+// @Param mode query string false "departure (по умолчанию) или crossing"
+// @Param include_implausible query bool false "Учитывать кинематически неправдоподобные полеты"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Router /areas/{id}/metrics [get]
+func (r *Router) GetAreaMetricsHandler(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный ID области"))
+	}
+	return r.areaMetrics(ctx, id, nil)
+}
+
+// CalculateAreaMetricsHandler
+// @Summary Метрики произвольной области
+// @Description Как /areas/{id}/metrics, но для полигона из тела запроса без сохранения
+// @Tags areas
+// @Accept json
+// @Produce json
+// @Param request body httpv1.AreaMetricsRequest true "Область"
+// @Param period query string false "Период по дате вылета; по умолчанию текущий год"
+// @Param mode query string false "departure (по умолчанию) или crossing"
+// @Param include_implausible query bool false "Учитывать кинематически неправдоподобные полеты"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Router /areas/metrics [post]
+func (r *Router) CalculateAreaMetricsHandler(ctx *fiber.Ctx) error {
+	var req AreaMetricsRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректное тело запроса"))
+	}
+	return r.areaMetrics(ctx, 0, req.Geometry)
+}
+
+func (r *Router) areaMetrics(ctx *fiber.Ctx, id int, geometry []byte) error {
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	res, err := r.service.AreaService.AreaMetrics(context.Background(), id, geometry,
+		ctx.Query("mode"), period, ctx.QueryBool("include_implausible"))
+	if errors.Is(err, service.ErrInvalidArea) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, err.Error()))
+	}
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Область не найдена"))
+	}
+	if err != nil {
+		slog.Error("failed to get area metrics", "id", id, "period", period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при расчете метрик области"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
@@ -0,0 +1,129 @@
+package httpv1
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
+)
+
+// DetectConflictsHandler
+// @Summary Поиск конфликтов между полетами
+// @Description Запускает в фоне поиск пар полетов, чьи объемы воздушного пространства сближались одновременно, по каждым суткам периода (UTC). Прежние результаты за эти сутки заменяются
+// @Tags admin
+// @Produce json
+// @Param period query string false "Период: год, квартал (2024-Q1), месяц (2024-03) или диапазон (2024-01-01..2024-03-31); по умолчанию вчерашние сутки"
+// @Success 202 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 409 {object} httpv1.APIResponse
+// @Router /admin/conflicts/detect [post]
+func (r *Router) DetectConflictsHandler(ctx *fiber.Ctx) error {
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	period, err := service.ParsePeriod(ctx.Query("period", yesterday+".."+yesterday))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	err = r.service.ConflictService.Start(period)
+	if errors.Is(err, service.ErrConflictJobRunning) {
+		return ctx.Status(fiber.StatusConflict).JSON(r.NewErrorResponse(fiber.StatusConflict, "Поиск конфликтов уже выполняется"))
+	}
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка запуска поиска конфликтов"))
+	}
+	return ctx.Status(fiber.StatusAccepted).JSON(r.NewSuccessResponse(nil, "Поиск конфликтов запущен"))
+}
+
+// GetConflictJobHandler
+// @Summary Состояние поиска конфликтов
+// @Description Ход и итог последнего пакетного поиска конфликтов
+// @Tags admin
+// @Produce json
+// @Success 200 {object} httpv1.APIResponse
+// @Router /admin/conflicts/job [get]
+func (r *Router) GetConflictJobHandler(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(r.service.ConflictService.Job(), ""))
+}
+
+// GetFlightConflictsHandler
+// @Summary Список конфликтов между полетами
+// @Description Пары полетов с пересечением по времени и высоте и сближением зон полета ближе разделения, с длительностью пересечения и расстоянием
+// @Tags conflicts
+// @Produce json
+// @Param period query string false "Период по суткам начала пересечения; по умолчанию текущий год"
+// @Param reg_id query int false "Регион хотя бы одного из полетов (0 — все)"
+// @Param operator query string false "Подстрока оператора (OPR) хотя бы одного из полетов"
+// @Param limit query int false "Не более (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Router /conflicts [get]
+func (r *Router) GetFlightConflictsHandler(ctx *fiber.Ctx) error {
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	f := model.FlightConflictFilter{
+		Period:   period,
+		RegionID: ctx.QueryInt("reg_id", 0),
+		Operator: ctx.Query("operator"),
+		Limit:    ctx.QueryInt("limit", 100),
+		Offset:   ctx.QueryInt("offset", 0),
+	}
+	res, err := r.service.ConflictService.Conflicts(context.Background(), f)
+	if err != nil {
+		slog.Error("failed to get flight conflicts", "period", period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении конфликтов"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetConflictDaysHandler
+// @Summary Проверенные сутки
+// @Description Сутки периода, по которым выполнялся поиск конфликтов: разделение, число полетов и конфликтов
+// @Tags conflicts
+// @Produce json
+// @Param period query string false "Период; по умолчанию текущий год"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Router /conflicts/days [get]
+func (r *Router) GetConflictDaysHandler(ctx *fiber.Ctx) error {
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	res, err := r.service.ConflictService.ConflictDays(context.Background(), period)
+	if err != nil {
+		slog.Error("failed to get conflict days", "period", period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении проверенных суток"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetConflictStatsHandler
+// @Summary Конфликты по регионам или операторам
+// @Description Число конфликтов, участвовавших полетов, суммарная длительность пересечений и минимальное расстояние за период
+// @Tags metrics
+// @Produce json
+// @Param period query string false "Период; по умолчанию текущий год"
+// @Param group query string false "region (по умолчанию) или operator"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Router /metrics/conflicts [get]
+func (r *Router) GetConflictStatsHandler(ctx *fiber.Ctx) error {
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	res, err := r.service.ConflictService.ConflictStats(context.Background(), period, ctx.Query("group", "region"))
+	if err != nil {
+		slog.Error("failed to get conflict stats", "period", period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при подсчете конфликтов"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
@@ -1,28 +1,36 @@
 package httpv1
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
-	"mime/multipart"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/xuri/excelize/v2"
 
 	"github.com/Xapsiel/bpla_dashboard/internal/model"
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
 )
 
 // UploadFileHandler
 // @Summary Загрузить XLSX с данными
-// @Description Принимает файл .xlsx, запускает парсинг и обновление метрик
+// @Description Принимает файл .xlsx, запускает парсинг и обновление метрик. Файл с уже загруженным содержимым не обрабатывается повторно: status duplicate и file_id первой загрузки
 // @Tags crawler
 // @Accept mpfd
 // @Produce json
 // @Param file formData file true "Файл XLSX"
 // @Param authorID formData string true "Идентификатор автора"
+// @Param policy formData string false "Политика конфликтов: skip, overwrite, keep-newest (по умолчанию skip)"
+// @Param modified_at formData string false "Время изменения файла в RFC 3339; без него keep-newest оставляет сохраненные полеты"
 // @Success 200 {object} httpv1.APIResponse
 // @Failure 400 {object} httpv1.APIResponse
 // @Failure 500 {object} httpv1.APIResponse
@@ -41,60 +49,84 @@ func (r *Router) UploadFileHandler(ctx *fiber.Ctx) error {
 	if authorID == "" {
 		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Отсутствует authorID"))
 	}
-	fileChan := make(chan int)
-	go func(file *multipart.FileHeader, ch chan int) {
-
-		jsonData, err := json.Marshal(file.Header)
-		if err != nil {
-			slog.Error(fmt.Sprintf("error with marshaling: %v", err))
-		}
-		mf := model.File{
-			AuthorID: authorID,
-			Filename: filename,
-			Size:     file.Size,
-			Metadata: jsonData,
-			Status:   "processing",
-		}
-		fi, err := file.Open()
-		if err != nil {
-			slog.Error(fmt.Sprintf("error opening file: %v", err))
-			return
-		}
-		defer fi.Close()
-
-		f, err := excelize.OpenReader(fi)
-		if err != nil {
-			slog.Error(fmt.Sprintf("error opening file: %v", err))
-			return
-		}
-		file_id, err := r.repo.SaveFileInfo(context.Background(), mf, 0, 0)
-		ch <- file_id
+	policy, err := service.ParseConflictPolicy(ctx.FormValue("policy"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Неизвестная политика конфликтов"))
+	}
+	var modifiedAt *time.Time
+	if v := ctx.FormValue("modified_at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
 		if err != nil {
-			slog.Error(fmt.Sprintf("error saving file: %v", err))
+			return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректное время изменения файла"))
 		}
+		modifiedAt = &t
+	}
+	fi, err := file.Open()
+	if err != nil {
+		slog.Error(fmt.Sprintf("error opening file: %v", err))
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Ошибка открытия файла"))
+	}
+	data, err := io.ReadAll(fi)
+	fi.Close()
+	if err != nil {
+		slog.Error(fmt.Sprintf("error reading file: %v", err))
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Ошибка открытия файла"))
+	}
+	sum := sha256.Sum256(data)
+	jsonData, err := json.Marshal(file.Header)
+	if err != nil {
+		slog.Error(fmt.Sprintf("error with marshaling: %v", err))
+	}
+	mf := model.File{
+		AuthorID:       authorID,
+		Filename:       filename,
+		Size:           file.Size,
+		Metadata:       jsonData,
+		SHA256:         hex.EncodeToString(sum[:]),
+		ConflictPolicy: policy,
+		ModifiedAt:     modifiedAt,
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		slog.Error(fmt.Sprintf("error opening file: %v", err))
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Ошибка чтения XLSX"))
+	}
+	fileID, err := r.service.ParserService.RegisterFile(context.Background(), mf)
+	if errors.Is(err, model.ErrFileExists) {
+		f.Close()
+		return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(fiber.Map{
+			"message":  "Файл с таким содержимым уже загружен",
+			"status":   "duplicate",
+			"authorID": authorID,
+			"filename": file.Filename,
+			"size":     file.Size,
+			"file_id":  fileID,
+		}, ""))
+	}
+	if err != nil {
+		f.Close()
+		slog.Error(fmt.Sprintf("error saving file: %v", err))
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка сохранения файла"))
+	}
 
-		validCount, errorCount, parseErr := r.service.ParserService.ProcessXLSX(context.Background(), f, authorID, file.Filename, file_id)
-		if parseErr != nil {
-			slog.Error("failed to parse xlsx", "filename", filename, "error", parseErr)
-			return
-		}
-		mf.Status = "parsed"
-		_, err = r.repo.SaveFileInfo(context.Background(), mf, validCount, errorCount)
+	go func(f *excelize.File, mf model.File, fileID int) {
+		defer f.Close()
+		_, err := r.service.ParserService.IngestFile(context.Background(), f, mf, fileID)
 		if err != nil {
-			slog.Error(fmt.Sprintf("error saving file: %v", err))
+			slog.Error("failed to parse xlsx", "filename", mf.Filename, "error", err)
 			return
 		}
-		err = r.service.MetricsService.Update(context.Background())
+		_, err = r.service.MetricsService.Refresh(context.Background())
 		if err != nil {
 			slog.Error(fmt.Sprintf("error update metrics: %v", err))
 			return
 		}
 		slog.Info(fmt.Sprintf("successfully processed file: %v", mf.Filename))
+	}(f, mf, fileID)
 
-	}(file, fileChan)
-	fileID := <-fileChan
 	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(fiber.Map{
 		"message":  "Файл успешно обработан",
+		"status":   "processing",
 		"authorID": authorID,
 		"filename": file.Filename,
 		"size":     file.Size,
@@ -102,6 +134,53 @@ func (r *Router) UploadFileHandler(ctx *fiber.Ctx) error {
 	}, ""))
 }
 
+// ValidateFileHandler
+// @Summary Проверить XLSX без загрузки
+// @Description Выполняет полный разбор файла без сохранения: количество валидных строк, ошибки по строкам, дубликаты в файле и в БД, предпросмотр первых N полетов
+// @Tags crawler
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "Файл XLSX"
+// @Param preview formData int false "Количество полетов в предпросмотре (по умолчанию 10)"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /crawler/validate [post]
+func (r *Router) ValidateFileHandler(ctx *fiber.Ctx) error {
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		slog.Error("failed to read uploaded file", "error", err)
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Ошибка загрузки файла"))
+	}
+	if !strings.HasSuffix(file.Filename, ".xlsx") {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "invalid file extension"))
+	}
+	preview, err := strconv.Atoi(ctx.FormValue("preview"))
+	if err != nil || preview < 0 {
+		preview = 10
+	}
+
+	fi, err := file.Open()
+	if err != nil {
+		slog.Error("error opening file", "error", err)
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Ошибка открытия файла"))
+	}
+	defer fi.Close()
+	f, err := excelize.OpenReader(fi)
+	if err != nil {
+		slog.Error("error opening xlsx", "error", err)
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Ошибка чтения XLSX"))
+	}
+	defer f.Close()
+
+	report, err := r.service.ParserService.ValidateXLSX(context.Background(), f, file.Filename, preview)
+	if err != nil {
+		slog.Error("failed to validate xlsx", "filename", file.Filename, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка проверки файла"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(report, ""))
+}
+
 func (r *Router) CheckFileStatus(ctx *fiber.Ctx) error {
 	fileID, err := strconv.Atoi(ctx.Query("id"))
 	if err != nil {
@@ -116,3 +195,27 @@ func (r *Router) CheckFileStatus(ctx *fiber.Ctx) error {
 		f, ""))
 
 }
+
+// GetFileConflicts
+// @Summary Отчет о конфликтах файла
+// @Description Возвращает полеты файла, SID которых уже был сохранен с другими значениями полей, и способ разрешения
+// @Tags crawler
+// @Produce json
+// @Param id query int true "Идентификатор файла"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /crawler/conflicts [get]
+func (r *Router) GetFileConflicts(ctx *fiber.Ctx) error {
+	fileID, err := strconv.Atoi(ctx.Query("id"))
+	if err != nil {
+		slog.Error("failed to parse file id", "id", ctx.Query("id"))
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Отсутствует fileID"))
+	}
+	conflicts, err := r.repo.GetConflicts(context.Background(), fileID)
+	if err != nil {
+		slog.Error("failed to get conflicts", "file_id", fileID, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении конфликтов"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(conflicts, ""))
+}
@@ -0,0 +1,73 @@
+package httpv1
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
+)
+
+func (r *Router) parseFlowFilter(ctx *fiber.Ctx) (model.FlowFilter, error) {
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return model.FlowFilter{}, err
+	}
+	return model.FlowFilter{
+		Period:   period,
+		RegionID: ctx.QueryInt("reg_id", 0),
+		Internal: ctx.QueryBool("internal", false),
+	}, nil
+}
+
+// GetFlows
+// @Summary Матрица отправления–назначения
+// @Description Число полетов и расстояние по прямой между регионом вылета и регионом посадки за период; регион посадки определяется по точке прибытия
+// @Tags metrics
+// @Produce json
+// @Param period query string false "Период: год, квартал (2024-Q1), месяц (2024-03) или диапазон (2024-01-01..2024-03-31); по умолчанию текущий год"
+// @Param reg_id query int false "Только потоки из региона или в регион (0 — все)"
+// @Param internal query bool false "Включать полеты с посадкой в регионе вылета"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Router /metrics/flows [get]
+func (r *Router) GetFlows(ctx *fiber.Ctx) error {
+	f, err := r.parseFlowFilter(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	res, err := r.service.MetricsService.Flows(context.Background(), f)
+	if err != nil {
+		slog.Error("failed to get flows", "reg_id", f.RegionID, "period", f.Period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при расчете потоков"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetFlowLines
+// @Summary Линии межрегиональных потоков
+// @Description GeoJSON FeatureCollection линий между регионами вылета и посадки с числом полетов и суммарным расстоянием
+// @Tags metrics
+// @Produce json
+// @Param period query string false "Период: год, квартал (2024-Q1), месяц (2024-03) или диапазон (2024-01-01..2024-03-31); по умолчанию текущий год"
+// @Param reg_id query int false "Только потоки из региона или в регион (0 — все)"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Router /metrics/flows/geojson [get]
+func (r *Router) GetFlowLines(ctx *fiber.Ctx) error {
+	f, err := r.parseFlowFilter(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	raw, err := r.service.MetricsService.FlowLines(context.Background(), f)
+	if err != nil {
+		slog.Error("failed to get flow lines", "reg_id", f.RegionID, "period", f.Period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при построении линий потоков"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(json.RawMessage(raw), ""))
+}
@@ -0,0 +1,52 @@
+package httpv1
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
+)
+
+type IngestTelegramsRequest struct {
+	Telegrams []string `json:"telegrams"` // Тексты телеграмм SHR/DEP/ARR
+}
+
+// IngestTelegramsHandler
+// @Summary Принять телеграммы SHR/DEP/ARR
+// @Description Принимает одну или несколько телеграмм (JSON-массив или текст), сопоставляет DEP/ARR с SHR по SID/DOF/REG и обновляет метрики затронутых регионов
+// @Tags ingest
+// @Accept json,plain
+// @Produce json
+// @Param request body httpv1.IngestTelegramsRequest false "Телеграммы"
+// @Param policy query string false "Политика конфликтов SHR: skip, overwrite, keep-newest (по умолчанию skip)"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Router /ingest/telegrams [post]
+func (r *Router) IngestTelegramsHandler(ctx *fiber.Ctx) error {
+	policy, err := service.ParseConflictPolicy(ctx.Query("policy"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Неизвестная политика конфликтов"))
+	}
+	var raws []string
+	if strings.HasPrefix(ctx.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON) {
+		var req IngestTelegramsRequest
+		if err := ctx.BodyParser(&req); err != nil {
+			slog.Error("failed to parse telegrams body", "error", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректное тело запроса"))
+		}
+		for _, t := range req.Telegrams {
+			raws = append(raws, service.SplitTelegrams(t)...)
+		}
+	} else {
+		raws = service.SplitTelegrams(string(ctx.Body()))
+	}
+	if len(raws) == 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Отсутствуют телеграммы"))
+	}
+
+	report := r.service.TelegramService.Ingest(context.Background(), raws, policy)
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(report, ""))
+}
@@ -2,17 +2,23 @@ package httpv1
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"math"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/Xapsiel/bpla_dashboard/internal/model"
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
 )
 
 // GetMetrics
 // @Summary Получить метрики по региону
-// @Description Возвращает метрики для указанного региона и года
+// @Description Возвращает метрики для указанного региона и года. Часы, дни и месяцы считаются в местном времени региона (поле TimeZone), для всей РФ — по московскому времени
 // @Tags metrics
 // @Accept json
 // @Produce json
@@ -67,3 +73,228 @@ func (r *Router) GetAllMetrics(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(metrics, ""))
 
 }
+
+// GetMetricsHistory
+// @Summary История метрик региона
+// @Description Возвращает все версии метрик региона за год, сохраненные пересчетами, начиная с самой новой. Каждая версия связана с пересчетом и файлами, изменения из которых он учел
+// @Tags metrics
+// @Produce json
+// @Param reg_id query int false "Код региона (0 — вся РФ)"
+// @Param year query int false "Год"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /metrics/history [get]
+func (r *Router) GetMetricsHistory(ctx *fiber.Ctx) error {
+	regID := ctx.QueryInt("reg_id", 0)
+	year := ctx.QueryInt("year", 2025)
+	history, err := r.service.MetricsService.History(context.Background(), regID, year)
+	if err != nil {
+		slog.Error("failed to get metrics history", "reg_id", regID, "year", year, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении истории метрик"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(history, ""))
+}
+
+// GetMetricsAsOf
+// @Summary Метрики на момент пересчета
+// @Description Возвращает метрики региона за год в том виде, в каком они были после указанного пересчета
+// @Tags metrics
+// @Produce json
+// @Param reg_id query int false "Код региона (0 — вся РФ)"
+// @Param year query int false "Год"
+// @Param run query int true "ID пересчета"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Router /metrics/asof [get]
+func (r *Router) GetMetricsAsOf(ctx *fiber.Ctx) error {
+	regID := ctx.QueryInt("reg_id", 0)
+	year := ctx.QueryInt("year", 2025)
+	runID := ctx.QueryInt("run", 0)
+	if runID <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Не указан пересчет"))
+	}
+	snap, err := r.service.MetricsService.AsOf(context.Background(), regID, year, runID)
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Метрики на момент пересчета не найдены"))
+	}
+	if err != nil {
+		slog.Error("failed to get metrics snapshot", "reg_id", regID, "year", year, "run", runID, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении метрик"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(snap, ""))
+}
+
+// GetMetricsDiff
+// @Summary Изменения метрик между пересчетами
+// @Description Сравнивает метрики региона за год на момент двух пересчетов и возвращает изменившиеся показатели
+// @Tags metrics
+// @Produce json
+// @Param reg_id query int false "Код региона (0 — вся РФ)"
+// @Param year query int false "Год"
+// @Param from query int true "ID первого пересчета"
+// @Param to query int false "ID второго пересчета (по умолчанию — последний)"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Router /metrics/diff [get]
+func (r *Router) GetMetricsDiff(ctx *fiber.Ctx) error {
+	regID := ctx.QueryInt("reg_id", 0)
+	year := ctx.QueryInt("year", 2025)
+	from := ctx.QueryInt("from", 0)
+	to := ctx.QueryInt("to", math.MaxInt32)
+	if from <= 0 || to <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Не указаны пересчеты для сравнения"))
+	}
+	diff, err := r.service.MetricsService.Diff(context.Background(), regID, year, from, to)
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Метрики на момент пересчета не найдены"))
+	}
+	if err != nil {
+		slog.Error("failed to diff metrics", "reg_id", regID, "year", year, "from", from, "to", to, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при сравнении метрик"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(diff, ""))
+}
+
+// CompareMetrics
+// @Summary Сравнение метрик за два периода
+// @Description Считает метрики регионов за периоды A и B и возвращает каждый показатель рядом с абсолютным и процентным изменением. Период — год (2024), квартал (2024-Q1), месяц (2024-03) или диапазон дат (2024-01-01..2024-03-31)
+// @Tags metrics
+// @Produce json
+// @Param a query string true "Период A"
+// @Param b query string true "Период B"
+// @Param reg_ids query string false "Коды регионов через запятую (0 — вся РФ, по умолчанию)"
+// @Param include_implausible query bool false "Учитывать кинематически неправдоподобные полеты"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /metrics/compare [get]
+func (r *Router) CompareMetrics(ctx *fiber.Ctx) error {
+	a, err := service.ParsePeriod(ctx.Query("a"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период A: "+err.Error()))
+	}
+	b, err := service.ParsePeriod(ctx.Query("b"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период B: "+err.Error()))
+	}
+	regIDs, err := parseIDs(ctx.Query("reg_ids", "0"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный список регионов"))
+	}
+	res, err := r.service.MetricsService.Compare(context.Background(), regIDs, a, b, ctx.QueryBool("include_implausible"))
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Регион не найден"))
+	}
+	if err != nil {
+		slog.Error("failed to compare metrics", "a", a.Label, "b", b.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при сравнении метрик"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// parseIDs разбирает список целых через запятую
+func parseIDs(s string) ([]int, error) {
+	var ids []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("invalid id %q", part)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("empty id list")
+	}
+	return ids, nil
+}
+
+// RankMetrics
+// @Summary Рейтинг регионов
+// @Description Для каждого региона возвращает ранг, перцентиль и z-оценку по показателям (total_flight, flight_density, avg_duration_minutes, peak_load, total_distance_km, avg_daily_flights) среди всех регионов за период, а также ранг внутри федерального округа
+// @Tags metrics
+// @Produce json
+// @Param period query string false "Период: год, квартал (2024-Q1), месяц (2024-03) или диапазон (2024-01-01..2024-03-31); по умолчанию текущий год"
+// @Param sort query string false "Показатель для сортировки (по умолчанию total_flight)"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /metrics/rank [get]
+func (r *Router) RankMetrics(ctx *fiber.Ctx) error {
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	sortBy := ctx.Query("sort", "total_flight")
+	if !service.IsRankField(sortBy) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Неизвестный показатель сортировки"))
+	}
+	res, err := r.service.MetricsService.Rank(context.Background(), period, sortBy)
+	if err != nil {
+		slog.Error("failed to rank regions", "period", period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при расчете рейтинга"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetActivityMatrix
+// @Summary Активность по часам и дням недели
+// @Description Матрица 7×24 (понедельник..воскресенье × час) вылетов и одновременно выполняемых полетов в местном времени региона за период
+// @Tags metrics
+// @Produce json
+// @Param reg_id query int false "Код региона (0 — вся РФ, каждый полет в зоне своего региона)"
+// @Param period query string false "Период: год, квартал (2024-Q1), месяц (2024-03) или диапазон (2024-01-01..2024-03-31); по умолчанию текущий год"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Router /metrics/activity [get]
+func (r *Router) GetActivityMatrix(ctx *fiber.Ctx) error {
+	regID := ctx.QueryInt("reg_id", 0)
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	res, err := r.service.MetricsService.Activity(context.Background(), regID, period)
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Регион не найден"))
+	}
+	if err != nil {
+		slog.Error("failed to get activity matrix", "reg_id", regID, "period", period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при расчете активности"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetOccupancy
+// @Summary Одновременная загрузка воздушного пространства
+// @Description По интервалам ATD–ATA (с переходом через полночь) для каждых местных суток периода: максимум одновременно выполняемых полетов, момент максимума и кривая загрузки
+// @Tags metrics
+// @Produce json
+// @Param reg_id query int false "Код региона (0 — вся РФ в опорной зоне)"
+// @Param period query string false "Период: год, квартал (2024-Q1), месяц (2024-03) или диапазон (2024-01-01..2024-03-31); по умолчанию текущий месяц"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Router /metrics/occupancy [get]
+func (r *Router) GetOccupancy(ctx *fiber.Ctx) error {
+	regID := ctx.QueryInt("reg_id", 0)
+	period, err := service.ParsePeriod(ctx.Query("period", time.Now().Format("2006-01")))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	res, err := r.service.MetricsService.Occupancy(context.Background(), regID, period)
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Регион не найден"))
+	}
+	if err != nil {
+		slog.Error("failed to get occupancy", "reg_id", regID, "period", period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при расчете загрузки"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
@@ -0,0 +1,191 @@
+package httpv1
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
+)
+
+func (r *Router) parseOperatorFilter(ctx *fiber.Ctx) (model.OperatorFilter, error) {
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return model.OperatorFilter{}, err
+	}
+	return model.OperatorFilter{
+		Period:   period,
+		RegionID: ctx.QueryInt("reg_id", 0),
+		Query:    ctx.Query("q"),
+		Order:    ctx.Query("order", "flights"),
+		Limit:    ctx.QueryInt("limit", 100),
+		Offset:   ctx.QueryInt("offset", 0),
+	}, nil
+}
+
+// GetOperatorsHandler
+// @Summary Операторы БВС
+// @Description Операторы, сведенные из написаний поля OPR/, с числом полетов, регионов, расстоянием по прямой, типичными высотами и профилем вылетов по часам за период
+// @Tags operators
+// @Produce json
+// @Param period query string false "Период по дате вылета; по умолчанию текущий год"
+// @Param reg_id query int false "Код региона (0 — все)"
+// @Param q query string false "Подстрока имени или цифры телефона"
+// @Param order query string false "flights (по умолчанию), distance или regions"
+// @Param limit query int false "Не более (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /operators [get]
+func (r *Router) GetOperatorsHandler(ctx *fiber.Ctx) error {
+	f, err := r.parseOperatorFilter(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	res, err := r.service.OperatorService.Operators(context.Background(), f)
+	if err != nil {
+		slog.Error("failed to get operators", "period", f.Period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении операторов"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetOperatorHandler
+// @Summary Карточка оператора
+// @Description Показатели оператора за период, полеты по регионам и типам БВС, известные регистрационные номера, телефоны и написания OPR/
+// @Tags operators
+// @Produce json
+// @Param id path int true "ID оператора"
+// @Param period query string false "Период по дате вылета; по умолчанию текущий год"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Router /operators/{id} [get]
+func (r *Router) GetOperatorHandler(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный ID оператора"))
+	}
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	res, err := r.service.OperatorService.Profile(context.Background(), id, period)
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Оператор не найден"))
+	}
+	if err != nil {
+		slog.Error("failed to get operator", "id", id, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении оператора"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetOperatorFlightsHandler
+// @Summary Полеты оператора
+// @Description Полеты оператора за период, новые первыми
+// @Tags operators
+// @Produce json
+// @Param id path int true "ID оператора"
+// @Param period query string false "Период по дате вылета; по умолчанию текущий год"
+// @Param reg_id query int false "Код региона (0 — все)"
+// @Param limit query int false "Не более (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Router /operators/{id}/flights [get]
+func (r *Router) GetOperatorFlightsHandler(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный ID оператора"))
+	}
+	f, err := r.parseOperatorFilter(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	res, err := r.service.OperatorService.Flights(context.Background(), id, f)
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Оператор не найден"))
+	}
+	if err != nil {
+		slog.Error("failed to get operator flights", "id", id, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении полетов оператора"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// MergeOperatorsHandler
+// @Summary Объединить операторов
+// @Description Вливает операторов source_ids в оператора target_id: написания OPR/, телефоны и полеты переходят к целевому оператору. Будущие написания с ключами влитых операторов относятся к целевому
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body model.OperatorMerge true "Объединение"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Router /admin/operators/merge [post]
+func (r *Router) MergeOperatorsHandler(ctx *fiber.Ctx) error {
+	var req model.OperatorMerge
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректное тело запроса"))
+	}
+	err := r.service.OperatorService.Merge(context.Background(), req)
+	if errors.Is(err, service.ErrInvalidOperatorEdit) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Укажите целевого оператора и отличных от него операторов-источников"))
+	}
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Оператор не найден или уже объединен"))
+	}
+	if err != nil {
+		slog.Error("failed to merge operators", "target", req.Target, "sources", req.Sources, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при объединении операторов"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(nil, "Операторы объединены"))
+}
+
+// SplitOperatorHandler
+// @Summary Разделить оператора
+// @Description Заводит оператора с именем name и переносит к нему написания OPR/ aliases вместе с полетами. У исходного оператора должно остаться хотя бы одно написание
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "ID оператора"
+// @Param request body model.OperatorSplit true "Разделение"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Failure 409 {object} httpv1.APIResponse
+// @Router /admin/operators/{id}/split [post]
+func (r *Router) SplitOperatorHandler(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный ID оператора"))
+	}
+	var req model.OperatorSplit
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректное тело запроса"))
+	}
+	newID, err := r.service.OperatorService.Split(context.Background(), id, req)
+	if errors.Is(err, service.ErrInvalidOperatorEdit) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Укажите имя нового оператора и часть написаний исходного"))
+	}
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Оператор или написание не найдены"))
+	}
+	if errors.Is(err, model.ErrOperatorExists) {
+		return ctx.Status(fiber.StatusConflict).JSON(r.NewErrorResponse(fiber.StatusConflict, "Оператор с таким именем уже существует"))
+	}
+	if err != nil {
+		slog.Error("failed to split operator", "id", id, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при разделении оператора"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(fiber.Map{"id": newID}, "Оператор разделен"))
+}
@@ -0,0 +1,109 @@
+package httpv1
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
+)
+
+// GetImplausibleFlightsHandler
+// @Summary Кинематически неправдоподобные полеты
+// @Description Полеты, исключенные из метрик: посадка раньше вылета без правдоподобного перехода через полночь (ata_before_atd), нулевая длительность (zero_duration), скорость по прямой выше 200 км/ч (impossible_speed). Сводка по причинам и страница полетов
+// @Tags quality
+// @Produce json
+// @Param period query string false "Период по дате вылета; по умолчанию текущий год"
+// @Param reg_id query int false "Код региона (0 — все)"
+// @Param reason query string false "Код причины"
+// @Param limit query int false "Не более (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /quality/implausible [get]
+func (r *Router) GetImplausibleFlightsHandler(ctx *fiber.Ctx) error {
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	f := model.QualityFilter{
+		Period:   period,
+		RegionID: ctx.QueryInt("reg_id", 0),
+		Reason:   ctx.Query("reason"),
+		Limit:    ctx.QueryInt("limit", 100),
+		Offset:   ctx.QueryInt("offset", 0),
+	}
+	res, err := r.service.MetricsService.QualityReport(context.Background(), f)
+	if errors.Is(err, service.ErrUnknownReason) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Неизвестная причина: "+f.Reason))
+	}
+	if err != nil {
+		slog.Error("failed to get quality report", "period", period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении отчета о качестве данных"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetRegionAttributionHandler
+// @Summary Сверка регионов полетов
+// @Description Сравнивает регион из таблицы с регионом по точке вылета: число полетов внутри полигона, отнесенных к ближайшему региону (у границы или в море), без региона и с несовпадающим регионом; сочетания регионов с числом полетов
+// @Tags quality
+// @Produce json
+// @Param period query string false "Период по дате вылета; по умолчанию текущий год"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /quality/regions [get]
+func (r *Router) GetRegionAttributionHandler(ctx *fiber.Ctx) error {
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	res, err := r.service.MetricsService.RegionAttribution(context.Background(), period)
+	if err != nil {
+		slog.Error("failed to get region attribution", "period", period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при сверке регионов"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetAttributedFlightsHandler
+// @Summary Полеты с расхождением регионов
+// @Description Полеты, у которых регион из таблицы не совпадает с регионом по геометрии (mismatch), регион определен по ближайшему полигону (nearest) или не определен (unattributed)
+// @Tags quality
+// @Produce json
+// @Param period query string false "Период по дате вылета; по умолчанию текущий год"
+// @Param kind query string false "mismatch (по умолчанию), nearest или unattributed"
+// @Param limit query int false "Не более (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /quality/regions/flights [get]
+func (r *Router) GetAttributedFlightsHandler(ctx *fiber.Ctx) error {
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	f := model.AttributionFilter{
+		Period: period,
+		Kind:   ctx.Query("kind", "mismatch"),
+		Limit:  ctx.QueryInt("limit", 100),
+		Offset: ctx.QueryInt("offset", 0),
+	}
+	res, err := r.service.MetricsService.AttributedFlights(context.Background(), f)
+	if errors.Is(err, service.ErrUnknownAttributionKind) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Неизвестный вид расхождения: "+f.Kind))
+	}
+	if err != nil {
+		slog.Error("failed to get attributed flights", "period", period.Label, "kind", f.Kind, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении полетов"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
@@ -23,6 +23,7 @@ type Repository interface {
 	GetMetrics(ctx context.Context, id int, year int) (model.Metrics, error)
 	GetRegions(ctx context.Context) []model.District
 	GetFile(ctx context.Context, id int) (model.File, error)
+	GetConflicts(ctx context.Context, fileID int) ([]model.Conflict, error)
 }
 type Router struct {
 	repo         Repository
@@ -86,12 +87,92 @@ func (r *Router) Routes(app fiber.Router) {
 	crawler := app.Group("/crawler")
 	crawler.Use(r.RoleMiddleware("admin"))
 	crawler.Post("/upload", r.UploadFileHandler)
+	crawler.Post("/validate", r.ValidateFileHandler)
 	crawler.Get("/status", r.CheckFileStatus)
+	crawler.Get("/conflicts", r.GetFileConflicts)
+
+	ingest := app.Group("/ingest")
+	ingest.Use(r.RoleMiddleware("admin"))
+	ingest.Post("/telegrams", r.IngestTelegramsHandler)
 
 	metrics := app.Group("/metrics")
 	metrics.Use(r.RoleMiddleware("admin", "analytic"))
 	metrics.Get("/", r.GetMetrics)
 	metrics.Get("/all", r.GetAllMetrics)
+	metrics.Get("/history", r.GetMetricsHistory)
+	metrics.Get("/asof", r.GetMetricsAsOf)
+	metrics.Get("/diff", r.GetMetricsDiff)
+	metrics.Get("/compare", r.CompareMetrics)
+	metrics.Get("/rank", r.RankMetrics)
+	metrics.Get("/activity", r.GetActivityMatrix)
+	metrics.Get("/occupancy", r.GetOccupancy)
+	metrics.Get("/flows", r.GetFlows)
+	metrics.Get("/flows/geojson", r.GetFlowLines)
+	metrics.Get("/violations", r.GetViolationStatsHandler)
+	metrics.Get("/conflicts", r.GetConflictStatsHandler)
+	metrics.Get("/altitude", r.GetAltitudeComplianceHandler)
+	metrics.Get("/altitude/profile", r.GetAltitudeProfileHandler)
+	metrics.Get("/categories", r.GetCategoryMetricsHandler)
+
+	zones := app.Group("/zones")
+	zones.Use(r.RoleMiddleware("admin", "analytic"))
+	zones.Get("/", r.GetZonesHandler)
+	zones.Get("/violations", r.GetViolationsHandler)
+
+	quality := app.Group("/quality")
+	quality.Use(r.RoleMiddleware("admin", "analytic"))
+	quality.Get("/implausible", r.GetImplausibleFlightsHandler)
+	quality.Get("/regions", r.GetRegionAttributionHandler)
+	quality.Get("/regions/flights", r.GetAttributedFlightsHandler)
+
+	conflicts := app.Group("/conflicts")
+	conflicts.Use(r.RoleMiddleware("admin", "analytic"))
+	conflicts.Get("/", r.GetFlightConflictsHandler)
+	conflicts.Get("/days", r.GetConflictDaysHandler)
+
+	sites := app.Group("/sites")
+	sites.Use(r.RoleMiddleware("admin", "analytic"))
+	sites.Get("/", r.GetLaunchSitesHandler)
+	sites.Get("/geojson", r.GetLaunchSitesGeoJSONHandler)
+
+	operators := app.Group("/operators")
+	operators.Use(r.RoleMiddleware("admin", "analytic"))
+	operators.Get("/", r.GetOperatorsHandler)
+	operators.Get("/:id", r.GetOperatorHandler)
+	operators.Get("/:id/flights", r.GetOperatorFlightsHandler)
+
+	aircraft := app.Group("/aircraft")
+	aircraft.Use(r.RoleMiddleware("admin", "analytic"))
+	aircraft.Get("/", r.GetAircraftListHandler)
+	aircraft.Get("/:id", r.GetAircraftHandler)
+
+	areas := app.Group("/areas")
+	areas.Use(r.RoleMiddleware("admin", "analytic"))
+	areas.Get("/", r.GetAreasHandler)
+	areas.Post("/", r.SaveAreaHandler)
+	areas.Post("/metrics", r.CalculateAreaMetricsHandler)
+	areas.Get("/:id/metrics", r.GetAreaMetricsHandler)
+	areas.Delete("/:id", r.DeleteAreaHandler)
+
+	admin := app.Group("/admin")
+	admin.Use(r.RoleMiddleware("admin"))
+	admin.Post("/metrics/rebuild", r.RebuildMetricsHandler)
+	admin.Post("/metrics/refresh", r.RefreshMetricsHandler)
+	admin.Get("/metrics/refresh", r.GetRefreshRunsHandler)
+	admin.Get("/metrics/dirty", r.GetDirtyPartitionsHandler)
+	admin.Post("/zones", r.ImportZonesHandler)
+	admin.Post("/zones/recheck", r.RecheckZonesHandler)
+	admin.Delete("/zones/:id", r.DeleteZoneHandler)
+	admin.Post("/conflicts/detect", r.DetectConflictsHandler)
+	admin.Get("/conflicts/job", r.GetConflictJobHandler)
+	admin.Get("/ceilings", r.GetCeilingsHandler)
+	admin.Post("/ceilings", r.SaveCeilingHandler)
+	admin.Delete("/ceilings/:id", r.DeleteCeilingHandler)
+	admin.Post("/sites/detect", r.DetectLaunchSitesHandler)
+	admin.Get("/sites/job", r.GetLaunchSiteJobHandler)
+	admin.Post("/operators/merge", r.MergeOperatorsHandler)
+	admin.Post("/operators/:id/split", r.SplitOperatorHandler)
+	admin.Post("/aircraft/:id", r.AnnotateAircraftHandler)
 
 }
 
@@ -0,0 +1,102 @@
+package httpv1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
+)
+
+// DetectLaunchSitesHandler
+// @Summary Поиск мест регулярных запусков
+// @Description Запускает в фоне кластеризацию всех точек вылета (DBSCAN): соседние точки — не дальше radius метров, место — не меньше min_flights вылетов. Прежний каталог заменяется
+// @Tags admin
+// @Produce json
+// @Param radius query number false "Радиус соседства, м; по умолчанию из конфигурации"
+// @Param min_flights query int false "Наименьшее число вылетов места; по умолчанию из конфигурации"
+// @Success 202 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Failure 409 {object} httpv1.APIResponse
+// @Router /admin/sites/detect [post]
+func (r *Router) DetectLaunchSitesHandler(ctx *fiber.Ctx) error {
+	err := r.service.SiteService.Start(ctx.QueryFloat("radius", 0), ctx.QueryInt("min_flights", 0))
+	if errors.Is(err, service.ErrInvalidSiteArg) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, err.Error()))
+	}
+	if errors.Is(err, service.ErrSiteJobRunning) {
+		return ctx.Status(fiber.StatusConflict).JSON(r.NewErrorResponse(fiber.StatusConflict, "Поиск мест запуска уже выполняется"))
+	}
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка запуска поиска мест запуска"))
+	}
+	return ctx.Status(fiber.StatusAccepted).JSON(r.NewSuccessResponse(nil, "Поиск мест запуска запущен"))
+}
+
+// GetLaunchSiteJobHandler
+// @Summary Состояние поиска мест запуска
+// @Description Параметры, ход и итог последней кластеризации точек вылета
+// @Tags admin
+// @Produce json
+// @Success 200 {object} httpv1.APIResponse
+// @Router /admin/sites/job [get]
+func (r *Router) GetLaunchSiteJobHandler(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(r.service.SiteService.Job(), ""))
+}
+
+func parseSiteFilter(ctx *fiber.Ctx) model.LaunchSiteFilter {
+	return model.LaunchSiteFilter{
+		RegionID:   ctx.QueryInt("reg_id", 0),
+		MinFlights: ctx.QueryInt("min_flights", 0),
+		Order:      ctx.Query("order", "flights"),
+		Limit:      ctx.QueryInt("limit", 100),
+		Offset:     ctx.QueryInt("offset", 0),
+	}
+}
+
+// GetLaunchSitesHandler
+// @Summary Рейтинг мест запуска
+// @Description Места регулярных запусков с числом полетов, операторами, днями и часами активности
+// @Tags sites
+// @Produce json
+// @Param reg_id query int false "Код региона (0 — все)"
+// @Param min_flights query int false "Не меньше вылетов"
+// @Param order query string false "flights (по умолчанию), operators или active_days"
+// @Param limit query int false "Не более (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /sites [get]
+func (r *Router) GetLaunchSitesHandler(ctx *fiber.Ctx) error {
+	f := parseSiteFilter(ctx)
+	res, err := r.service.SiteService.Sites(context.Background(), f)
+	if err != nil {
+		slog.Error("failed to get launch sites", "reg_id", f.RegionID, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении мест запуска"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetLaunchSitesGeoJSONHandler
+// @Summary Слой мест запуска
+// @Description FeatureCollection контуров мест запуска (выпуклая оболочка точек вылета с отступом в радиус) со свойствами места
+// @Tags sites
+// @Produce json
+// @Param reg_id query int false "Код региона (0 — все)"
+// @Param min_flights query int false "Не меньше вылетов"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 500 {object} httpv1.APIResponse
+// @Router /sites/geojson [get]
+func (r *Router) GetLaunchSitesGeoJSONHandler(ctx *fiber.Ctx) error {
+	f := parseSiteFilter(ctx)
+	raw, err := r.service.SiteService.SitesGeoJSON(context.Background(), f)
+	if err != nil {
+		slog.Error("failed to get launch sites layer", "reg_id", f.RegionID, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при построении слоя мест запуска"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(json.RawMessage(raw), ""))
+}
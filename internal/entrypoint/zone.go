@@ -0,0 +1,168 @@
+package httpv1
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+	"github.com/Xapsiel/bpla_dashboard/internal/service"
+)
+
+// ImportZonesHandler
+// @Summary Загрузка запретных зон
+// @Description Загружает зоны из GeoJSON (FeatureCollection) или KML и проверяет по ним все сохраненные полеты. Свойства зоны: name, kind (no_fly/restricted), min_alt/max_alt (м), active_from/active_to (ЧЧ:ММ UTC), valid_from/valid_to
+// @Tags admin
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Файл .geojson, .json или .kml"
+// @Success 201 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Router /admin/zones [post]
+func (r *Router) ImportZonesHandler(ctx *fiber.Ctx) error {
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		slog.Error("failed to read uploaded file", "error", err)
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Ошибка загрузки файла"))
+	}
+	fi, err := file.Open()
+	if err != nil {
+		slog.Error("error opening file", "error", err)
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Ошибка открытия файла"))
+	}
+	defer fi.Close()
+	data, err := io.ReadAll(fi)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Ошибка чтения файла"))
+	}
+
+	res, err := r.service.ZoneService.ImportZones(context.Background(), file.Filename, data)
+	if err != nil {
+		slog.Error("failed to import zones", "filename", file.Filename, "error", err)
+		if len(res.Zones) == 0 {
+			return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Ошибка загрузки зон: "+err.Error()))
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Зоны сохранены, но проверка полетов не завершена"))
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// DeleteZoneHandler
+// @Summary Удаление зоны
+// @Description Удаляет зону вместе с найденными по ней нарушениями
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID зоны"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 404 {object} httpv1.APIResponse
+// @Router /admin/zones/{id} [delete]
+func (r *Router) DeleteZoneHandler(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный id зоны"))
+	}
+	err = r.service.ZoneService.DeleteZone(context.Background(), id)
+	if errors.Is(err, model.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(r.NewErrorResponse(fiber.StatusNotFound, "Зона не найдена"))
+	}
+	if err != nil {
+		slog.Error("failed to delete zone", "id", id, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при удалении зоны"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(nil, "Зона удалена"))
+}
+
+// RecheckZonesHandler
+// @Summary Перепроверка нарушений зон
+// @Description Заново проверяет все полеты против всех зон
+// @Tags admin
+// @Produce json
+// @Success 200 {object} httpv1.APIResponse
+// @Router /admin/zones/recheck [post]
+func (r *Router) RecheckZonesHandler(ctx *fiber.Ctx) error {
+	n, err := r.service.ZoneService.Recheck(context.Background())
+	if err != nil {
+		slog.Error("failed to recheck zones", "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при проверке нарушений"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(fiber.Map{"violations": n}, ""))
+}
+
+// GetZonesHandler
+// @Summary Список зон
+// @Description Загруженные запретные зоны и зоны ограничения с геометрией GeoJSON и числом полетов-нарушителей
+// @Tags zones
+// @Produce json
+// @Success 200 {object} httpv1.APIResponse
+// @Router /zones [get]
+func (r *Router) GetZonesHandler(ctx *fiber.Ctx) error {
+	res, err := r.service.ZoneService.Zones(context.Background())
+	if err != nil {
+		slog.Error("failed to get zones", "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении зон"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetViolationsHandler
+// @Summary Список нарушений зон
+// @Description Полеты, пересекающие зону точкой вылета, зоной ZONA или маршрутом в пределах высот и времени действия зоны
+// @Tags zones
+// @Produce json
+// @Param period query string false "Период по дате вылета; по умолчанию текущий год"
+// @Param reg_id query int false "Код региона (0 — все)"
+// @Param zone_id query int false "ID зоны (0 — все)"
+// @Param operator query string false "Подстрока оператора (OPR)"
+// @Param limit query int false "Не более (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Router /zones/violations [get]
+func (r *Router) GetViolationsHandler(ctx *fiber.Ctx) error {
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	f := model.ViolationFilter{
+		Period:   period,
+		RegionID: ctx.QueryInt("reg_id", 0),
+		ZoneID:   ctx.QueryInt("zone_id", 0),
+		Operator: ctx.Query("operator"),
+		Limit:    ctx.QueryInt("limit", 100),
+		Offset:   ctx.QueryInt("offset", 0),
+	}
+	res, err := r.service.ZoneService.Violations(context.Background(), f)
+	if err != nil {
+		slog.Error("failed to get violations", "period", period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при получении нарушений"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
+
+// GetViolationStatsHandler
+// @Summary Нарушения зон по регионам или операторам
+// @Description Число полетов с нарушениями, из них в запретных зонах, и число нарушенных зон за период
+// @Tags metrics
+// @Produce json
+// @Param period query string false "Период по дате вылета; по умолчанию текущий год"
+// @Param group query string false "region (по умолчанию) или operator"
+// @Success 200 {object} httpv1.APIResponse
+// @Failure 400 {object} httpv1.APIResponse
+// @Router /metrics/violations [get]
+func (r *Router) GetViolationStatsHandler(ctx *fiber.Ctx) error {
+	period, err := service.ParsePeriod(ctx.Query("period", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(r.NewErrorResponse(fiber.StatusBadRequest, "Некорректный период: "+err.Error()))
+	}
+	res, err := r.service.ZoneService.ViolationStats(context.Background(), period, ctx.Query("group", "region"))
+	if err != nil {
+		slog.Error("failed to get violation stats", "period", period.Label, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(r.NewErrorResponse(fiber.StatusInternalServerError, "Ошибка при подсчете нарушений"))
+	}
+	return ctx.Status(fiber.StatusOK).JSON(r.NewSuccessResponse(res, ""))
+}
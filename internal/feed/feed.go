@@ -0,0 +1,95 @@
+package feed
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+)
+
+// Handler получает пакет телеграмм, прочитанных из источника
+type Handler func(ctx context.Context, raws []string)
+
+// Splitter делит текст на отдельные телеграммы
+type Splitter func(text string) []string
+
+// Feed — локальная замена AFTN-канала: телеграммы читаются из файла или TCP-соединения
+type Feed struct {
+	split  Splitter
+	handle Handler
+}
+
+func New(split Splitter, handle Handler) *Feed {
+	return &Feed{split: split, handle: handle}
+}
+
+// ReadFile отправляет в обработчик все телеграммы из файла
+func (f *Feed) ReadFile(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read feed file: %w", err)
+	}
+	raws := f.split(string(data))
+	if len(raws) > 0 {
+		f.handle(ctx, raws)
+	}
+	slog.Info("feed file processed", "path", path, "telegrams", len(raws))
+	return nil
+}
+
+// ListenTCP принимает телеграммы по TCP. Пустая строка или NNNN завершают телеграмму,
+// накопленный текст передается в обработчик.
+func (f *Feed) ListenTCP(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen feed: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	slog.Info("telegram feed listening", "addr", addr)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			slog.Error("feed accept error", "error", err)
+			continue
+		}
+		go f.serveConn(ctx, conn)
+	}
+}
+
+func (f *Feed) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	var buf strings.Builder
+	flush := func() {
+		if buf.Len() == 0 {
+			return
+		}
+		if raws := f.split(buf.String()); len(raws) > 0 {
+			f.handle(ctx, raws)
+		}
+		buf.Reset()
+	}
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.EqualFold(line, "NNNN") {
+			flush()
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		slog.Error("feed connection error", "remote", conn.RemoteAddr().String(), "error", err)
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS telegrams;
+DROP TYPE IF EXISTS telegram_status;
@@ -0,0 +1,15 @@
+CREATE TYPE telegram_status AS ENUM ('pending', 'matched');
+CREATE TABLE IF NOT EXISTS telegrams(
+    id SERIAL PRIMARY KEY ,
+    type VARCHAR(3) NOT NULL ,
+    sid VARCHAR(100) NOT NULL ,
+    dof DATE,
+    reg VARCHAR(50),
+    event_time TIME NOT NULL ,
+    raw TEXT NOT NULL ,
+    status telegram_status NOT NULL DEFAULT 'pending',
+    message_id INTEGER REFERENCES messages(id) ON DELETE SET NULL ,
+    received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS telegrams_sid_status_idx ON telegrams(sid, status);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS file_conflicts;
+ALTER TABLE files
+    DROP COLUMN IF EXISTS duplicate_count,
+    DROP COLUMN IF EXISTS conflict_count,
+    DROP COLUMN IF EXISTS conflict_policy;
@@ -0,0 +1,19 @@
+ALTER TABLE files
+    ADD COLUMN IF NOT EXISTS duplicate_count INT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS conflict_count INT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS conflict_policy VARCHAR(20) DEFAULT 'skip';
+
+CREATE TABLE IF NOT EXISTS file_conflicts(
+    id SERIAL PRIMARY KEY ,
+    file_id INTEGER REFERENCES files(id) ON DELETE CASCADE ,
+    sid VARCHAR(100) NOT NULL ,
+    sheet TEXT,
+    row_number INTEGER,
+    existing_file_id INTEGER REFERENCES files(id) ON DELETE SET NULL ,
+    fields JSONB NOT NULL ,
+    policy VARCHAR(20) NOT NULL ,
+    resolution VARCHAR(20) NOT NULL ,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS file_conflicts_file_id_idx ON file_conflicts(file_id);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS flight_coordinates_mark_metrics_partition ON flight_coordinates;
+DROP TRIGGER IF EXISTS messages_mark_metrics_partition ON messages;
+DROP FUNCTION IF EXISTS mark_metrics_partition_coordinates();
+DROP FUNCTION IF EXISTS mark_metrics_partition();
+DROP TABLE IF EXISTS metrics_dirty_partitions;
@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS metrics_dirty_partitions(
+    region_code INT NOT NULL ,
+    year INT NOT NULL ,
+    month INT NOT NULL ,
+    reason VARCHAR(20) NOT NULL ,
+    marked_at TIMESTAMP NOT NULL DEFAULT clock_timestamp(),
+    PRIMARY KEY (region_code, year, month)
+);
+
+-- Любое изменение messages (загрузка, удаление, исправление, ATA из телеграммы)
+-- помечает затронутые регион/год/месяц для инкрементального пересчета flight_metrics.
+-- region_code = 0 — полеты вне границ регионов, влияют только на общероссийские метрики.
+CREATE OR REPLACE FUNCTION mark_metrics_partition() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        INSERT INTO metrics_dirty_partitions(region_code, year, month, reason)
+        VALUES (COALESCE(OLD.region, 0), EXTRACT(YEAR FROM OLD.dof), EXTRACT(MONTH FROM OLD.dof), lower(TG_OP))
+        ON CONFLICT (region_code, year, month) DO UPDATE SET reason = EXCLUDED.reason, marked_at = EXCLUDED.marked_at;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO metrics_dirty_partitions(region_code, year, month, reason)
+        VALUES (COALESCE(NEW.region, 0), EXTRACT(YEAR FROM NEW.dof), EXTRACT(MONTH FROM NEW.dof), lower(TG_OP))
+        ON CONFLICT (region_code, year, month) DO UPDATE SET reason = EXCLUDED.reason, marked_at = EXCLUDED.marked_at;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER messages_mark_metrics_partition
+    AFTER INSERT OR UPDATE OR DELETE ON messages
+    FOR EACH ROW EXECUTE FUNCTION mark_metrics_partition();
+
+-- Точки зоны влияют на суммарную дистанцию
+CREATE OR REPLACE FUNCTION mark_metrics_partition_coordinates() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO metrics_dirty_partitions(region_code, year, month, reason)
+    SELECT COALESCE(m.region, 0), EXTRACT(YEAR FROM m.dof), EXTRACT(MONTH FROM m.dof), 'coordinates'
+    FROM messages m
+    WHERE m.sid = COALESCE(NEW.sid, OLD.sid)
+    ON CONFLICT (region_code, year, month) DO UPDATE SET reason = EXCLUDED.reason, marked_at = EXCLUDED.marked_at;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER flight_coordinates_mark_metrics_partition
+    AFTER INSERT OR DELETE ON flight_coordinates
+    FOR EACH ROW EXECUTE FUNCTION mark_metrics_partition_coordinates();
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS metrics_refresh_runs(
+    id SERIAL PRIMARY KEY ,
+    kind VARCHAR(20) NOT NULL ,
+    status VARCHAR(20) NOT NULL ,
+    started_at TIMESTAMP NOT NULL DEFAULT now(),
+    finished_at TIMESTAMP,
+    duration_ms BIGINT DEFAULT 0,
+    total_tasks INT DEFAULT 0,
+    failed_tasks INT DEFAULT 0,
+    failures jsonb
+);
+
+CREATE INDEX IF NOT EXISTS idx_metrics_refresh_runs_started ON metrics_refresh_runs(started_at DESC);
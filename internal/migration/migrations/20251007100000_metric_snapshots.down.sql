@@ -0,0 +1,31 @@
+DROP TABLE IF EXISTS metric_snapshots;
+
+CREATE OR REPLACE FUNCTION mark_metrics_partition() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        INSERT INTO metrics_dirty_partitions(region_code, year, month, reason)
+        VALUES (COALESCE(OLD.region, 0), EXTRACT(YEAR FROM OLD.dof), EXTRACT(MONTH FROM OLD.dof), lower(TG_OP))
+        ON CONFLICT (region_code, year, month) DO UPDATE SET reason = EXCLUDED.reason, marked_at = EXCLUDED.marked_at;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO metrics_dirty_partitions(region_code, year, month, reason)
+        VALUES (COALESCE(NEW.region, 0), EXTRACT(YEAR FROM NEW.dof), EXTRACT(MONTH FROM NEW.dof), lower(TG_OP))
+        ON CONFLICT (region_code, year, month) DO UPDATE SET reason = EXCLUDED.reason, marked_at = EXCLUDED.marked_at;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION mark_metrics_partition_coordinates() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO metrics_dirty_partitions(region_code, year, month, reason)
+    SELECT COALESCE(m.region, 0), EXTRACT(YEAR FROM m.dof), EXTRACT(MONTH FROM m.dof), 'coordinates'
+    FROM messages m
+    WHERE m.sid = COALESCE(NEW.sid, OLD.sid)
+    ON CONFLICT (region_code, year, month) DO UPDATE SET reason = EXCLUDED.reason, marked_at = EXCLUDED.marked_at;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE metrics_refresh_runs DROP COLUMN IF EXISTS file_ids;
+ALTER TABLE metrics_dirty_partitions DROP COLUMN IF EXISTS file_ids;
@@ -0,0 +1,69 @@
+ALTER TABLE metrics_dirty_partitions ADD COLUMN IF NOT EXISTS file_ids INT[] NOT NULL DEFAULT '{}';
+ALTER TABLE metrics_refresh_runs ADD COLUMN IF NOT EXISTS file_ids INT[] NOT NULL DEFAULT '{}';
+
+-- Версии метрик: каждый пересчет сохраняет значения, а не только перезаписывает flight_metrics.
+-- Метрики "на момент" пересчета N — последняя версия с run_id <= N.
+CREATE TABLE IF NOT EXISTS metric_snapshots(
+    id SERIAL PRIMARY KEY ,
+    run_id INT NOT NULL REFERENCES metrics_refresh_runs(id) ON DELETE CASCADE ,
+    region_code INT NOT NULL ,
+    region_name varchar(80),
+    total_flight INT DEFAULT 0,
+    avg_duration_minutes DECIMAL(10,2) DEFAULT  0.00,
+    total_distance_km   DECIMAL(10,2) DEFAULT  0.00,
+    peak_load INT DEFAULT 0,
+    avg_daily_flights DECIMAL(10,2) DEFAULT 0.00,
+    median_daily_flights DECIMAL(10,2) DEFAULT 0.00,
+    monthly_growth jsonb,
+    flight_density DECIMAL(10,2),
+    morning_flights INTEGER,
+    day_flights INTEGER,
+    evening_flights INTEGER,
+    night_flights INTEGER,
+    zero_flight_days DATE[],
+    date int NOT NULL ,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    UNIQUE (run_id, region_code, date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_metric_snapshots_region ON metric_snapshots(region_code, date, run_id DESC);
+
+-- Партиция запоминает файлы, изменения из которых в нее попали
+CREATE OR REPLACE FUNCTION mark_metrics_partition() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        INSERT INTO metrics_dirty_partitions(region_code, year, month, reason, file_ids)
+        VALUES (COALESCE(OLD.region, 0), EXTRACT(YEAR FROM OLD.dof), EXTRACT(MONTH FROM OLD.dof), lower(TG_OP),
+                array_remove(ARRAY[OLD.file_id], NULL))
+        ON CONFLICT (region_code, year, month) DO UPDATE SET
+            reason = EXCLUDED.reason,
+            marked_at = EXCLUDED.marked_at,
+            file_ids = ARRAY(SELECT DISTINCT unnest(metrics_dirty_partitions.file_ids || EXCLUDED.file_ids));
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO metrics_dirty_partitions(region_code, year, month, reason, file_ids)
+        VALUES (COALESCE(NEW.region, 0), EXTRACT(YEAR FROM NEW.dof), EXTRACT(MONTH FROM NEW.dof), lower(TG_OP),
+                array_remove(ARRAY[NEW.file_id], NULL))
+        ON CONFLICT (region_code, year, month) DO UPDATE SET
+            reason = EXCLUDED.reason,
+            marked_at = EXCLUDED.marked_at,
+            file_ids = ARRAY(SELECT DISTINCT unnest(metrics_dirty_partitions.file_ids || EXCLUDED.file_ids));
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION mark_metrics_partition_coordinates() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO metrics_dirty_partitions(region_code, year, month, reason, file_ids)
+    SELECT COALESCE(m.region, 0), EXTRACT(YEAR FROM m.dof), EXTRACT(MONTH FROM m.dof), 'coordinates',
+           array_remove(ARRAY[m.file_id], NULL)
+    FROM messages m
+    WHERE m.sid = COALESCE(NEW.sid, OLD.sid)
+    ON CONFLICT (region_code, year, month) DO UPDATE SET
+        reason = EXCLUDED.reason,
+        marked_at = EXCLUDED.marked_at,
+        file_ids = ARRAY(SELECT DISTINCT unnest(metrics_dirty_partitions.file_ids || EXCLUDED.file_ids));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,2 @@
+ALTER TABLE district_shapes DROP COLUMN IF EXISTS federal_district_id;
+DROP TABLE IF EXISTS federal_districts;
@@ -0,0 +1,53 @@
+CREATE TABLE IF NOT EXISTS federal_districts(
+    id SERIAL PRIMARY KEY ,
+    code VARCHAR(10) NOT NULL UNIQUE ,
+    name VARCHAR(80) NOT NULL
+);
+
+INSERT INTO federal_districts(code, name) VALUES
+    ('ЦФО', 'Центральный федеральный округ'),
+    ('СЗФО', 'Северо-Западный федеральный округ'),
+    ('ЮФО', 'Южный федеральный округ'),
+    ('СКФО', 'Северо-Кавказский федеральный округ'),
+    ('ПФО', 'Приволжский федеральный округ'),
+    ('УФО', 'Уральский федеральный округ'),
+    ('СФО', 'Сибирский федеральный округ'),
+    ('ДФО', 'Дальневосточный федеральный округ')
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE district_shapes ADD COLUMN IF NOT EXISTS federal_district_id INT REFERENCES federal_districts(id);
+
+-- Состав округов задается по названию региона (name_ru), а не по gid: gid зависят от порядка
+-- загрузки шейп-файла. Шаблоны — регулярные выражения без учета регистра, \m — начало слова.
+-- Из регионов с одинаковым названием в округ входит первый (дубликат Севастополя);
+-- Сумская обл. и АР Крым ни под один шаблон не подходят и в рейтинге не участвуют.
+UPDATE district_shapes ds SET federal_district_id = fd.id
+FROM (VALUES
+    ('ЦФО', '\mбелгородск'), ('ЦФО', '\mбрянск'), ('ЦФО', '\mвладимирск'), ('ЦФО', '\mворонежск'),
+    ('ЦФО', '\mивановск'), ('ЦФО', '\mкалужск'), ('ЦФО', '\mкостромск'), ('ЦФО', '\mкурская'),
+    ('ЦФО', '\mлипецк'), ('ЦФО', '\mмосковская'), ('ЦФО', '\mмосква\M'), ('ЦФО', '\mорловск'),
+    ('ЦФО', '\mрязанск'), ('ЦФО', '\mсмоленск'), ('ЦФО', '\mтамбовск'), ('ЦФО', '\mтверск'),
+    ('ЦФО', '\mтульск'), ('ЦФО', '\mярославск'),
+    ('СЗФО', '\mархангельск'), ('СЗФО', '\mвологодск'), ('СЗФО', '\mкалининградск'), ('СЗФО', '\mкарели'),
+    ('СЗФО', '\mкоми\M'), ('СЗФО', '\mленинградск'), ('СЗФО', '\mмурманск'), ('СЗФО', '^ненецк'),
+    ('СЗФО', '\mновгородск'), ('СЗФО', '\mпсковск'), ('СЗФО', '\mсанкт-петербург'),
+    ('ЮФО', '\mадыге'), ('ЮФО', '\mастраханск'), ('ЮФО', '\mволгоградск'), ('ЮФО', '\mкалмык'),
+    ('ЮФО', '\mкраснодарск'), ('ЮФО', '^(республика )?крым'), ('ЮФО', '\mростовск'), ('ЮФО', '\mсевастопол'),
+    ('СКФО', '\mдагестан'), ('СКФО', '\mингуш'), ('СКФО', '\mкабардин'), ('СКФО', '\mкарачаев'),
+    ('СКФО', '\mосети'), ('СКФО', '\mчечен|\mчечня'), ('СКФО', '\mставропольск'),
+    ('ПФО', '\mбашкорт'), ('ПФО', '\mкировск'), ('ПФО', '\mмарий'), ('ПФО', '\mмордов'),
+    ('ПФО', '\mнижегородск'), ('ПФО', '\mоренбургск'), ('ПФО', '\mпензенск'), ('ПФО', '\mпермск'),
+    ('ПФО', '\mсамарск'), ('ПФО', '\mсаратовск'), ('ПФО', '\mтатарстан'), ('ПФО', '\mудмурт'),
+    ('ПФО', '\mульяновск'), ('ПФО', '\mчуваш'),
+    ('УФО', '\mкурганск'), ('УФО', '\mсвердловск'), ('УФО', '\mтюменск'), ('УФО', '\mханты'),
+    ('УФО', '\mямало'), ('УФО', '\mчелябинск'),
+    ('СФО', '\mалтайский'), ('СФО', '\mалтай\M'), ('СФО', '\mиркутск'), ('СФО', '\mкемеровск'),
+    ('СФО', '\mкрасноярск'), ('СФО', '\mновосибирск'), ('СФО', '\mомск'), ('СФО', '\mтомск'),
+    ('СФО', '\mтыва\M|\mтува'), ('СФО', '\mхакас'),
+    ('ДФО', '\mамурск'), ('ДФО', '\mбурят'), ('ДФО', '\mеврейск'), ('ДФО', '\mзабайкальск'),
+    ('ДФО', '\mкамчатск'), ('ДФО', '\mмагаданск'), ('ДФО', '\mприморск'), ('ДФО', '\mсаха\M|\mякут'),
+    ('ДФО', '\mсахалинск'), ('ДФО', '\mхабаровск'), ('ДФО', '\mчукотск')
+) AS r(code, pattern)
+JOIN federal_districts fd ON fd.code = r.code
+WHERE ds.name_ru ~* r.pattern
+    AND ds.gid = (SELECT MIN(d2.gid) FROM district_shapes d2 WHERE d2.name_ru = ds.name_ru);
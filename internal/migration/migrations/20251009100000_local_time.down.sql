@@ -0,0 +1,5 @@
+ALTER TABLE metric_snapshots DROP COLUMN IF EXISTS time_zone;
+ALTER TABLE flight_metrics DROP COLUMN IF EXISTS time_zone;
+DROP VIEW IF EXISTS messages_local;
+DROP FUNCTION IF EXISTS refresh_messages_local_views();
+DROP FUNCTION IF EXISTS messages_local_select();
@@ -0,0 +1,32 @@
+-- ATD/ATA хранятся в UTC (время телеграмм). Представление добавляет время вылета и посадки
+-- в местном времени региона (*_local) и в опорной зоне общероссийских метрик (*_ref, Москва).
+-- Посадка раньше вылета означает переход через полночь. Полеты вне регионов — по Москве.
+-- Представление выбирает m.*, поэтому после изменения столбцов messages его нужно
+-- пересоздать: миграции вызывают refresh_messages_local_views(), а не копируют тело.
+CREATE OR REPLACE FUNCTION messages_local_select() RETURNS TEXT AS $$
+    SELECT $q$
+    SELECT
+        m.*,
+        COALESCE(NULLIF(ds.timezone, ''), 'Europe/Moscow') AS time_zone,
+        ((m.dof + m.atd) AT TIME ZONE 'UTC') AT TIME ZONE COALESCE(NULLIF(ds.timezone, ''), 'Europe/Moscow') AS atd_local,
+        ((m.dof + m.ata + CASE WHEN m.ata < m.atd THEN INTERVAL '1 day' ELSE INTERVAL '0' END) AT TIME ZONE 'UTC')
+            AT TIME ZONE COALESCE(NULLIF(ds.timezone, ''), 'Europe/Moscow') AS ata_local,
+        ((m.dof + m.atd) AT TIME ZONE 'UTC') AT TIME ZONE 'Europe/Moscow' AS atd_ref,
+        ((m.dof + m.ata + CASE WHEN m.ata < m.atd THEN INTERVAL '1 day' ELSE INTERVAL '0' END) AT TIME ZONE 'UTC')
+            AT TIME ZONE 'Europe/Moscow' AS ata_ref
+    FROM messages m
+    LEFT JOIN district_shapes ds ON ds.gid = m.region
+    $q$
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION refresh_messages_local_views() RETURNS void AS $$
+BEGIN
+    DROP VIEW IF EXISTS messages_local;
+    EXECUTE 'CREATE VIEW messages_local AS ' || messages_local_select();
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT refresh_messages_local_views();
+
+ALTER TABLE flight_metrics ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+ALTER TABLE metric_snapshots ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC';
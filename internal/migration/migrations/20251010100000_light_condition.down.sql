@@ -0,0 +1,15 @@
+ALTER TABLE metric_snapshots
+    DROP COLUMN IF EXISTS daylight_flights,
+    DROP COLUMN IF EXISTS twilight_flights,
+    DROP COLUMN IF EXISTS dark_flights,
+    DROP COLUMN IF EXISTS night_share;
+ALTER TABLE flight_metrics
+    DROP COLUMN IF EXISTS daylight_flights,
+    DROP COLUMN IF EXISTS twilight_flights,
+    DROP COLUMN IF EXISTS dark_flights,
+    DROP COLUMN IF EXISTS night_share;
+
+DROP VIEW IF EXISTS messages_local;
+DROP INDEX IF EXISTS idx_messages_light_pending;
+ALTER TABLE messages DROP COLUMN IF EXISTS light_condition;
+SELECT refresh_messages_local_views();
@@ -0,0 +1,18 @@
+-- Освещенность в точке и момент вылета (day, twilight, night), считается в Go пакетом solar.
+-- NULL — еще не классифицирован (новый полет или изменено время вылета).
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS light_condition VARCHAR(16);
+CREATE INDEX IF NOT EXISTS idx_messages_light_pending ON messages(id) WHERE light_condition IS NULL;
+
+-- представление пересоздается, чтобы m.* включало новый столбец
+SELECT refresh_messages_local_views();
+
+ALTER TABLE flight_metrics
+    ADD COLUMN IF NOT EXISTS daylight_flights INT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS twilight_flights INT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS dark_flights INT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS night_share DECIMAL(6,4) DEFAULT 0;
+ALTER TABLE metric_snapshots
+    ADD COLUMN IF NOT EXISTS daylight_flights INT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS twilight_flights INT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS dark_flights INT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS night_share DECIMAL(6,4) DEFAULT 0;
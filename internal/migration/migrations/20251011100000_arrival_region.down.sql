@@ -0,0 +1,4 @@
+DROP VIEW IF EXISTS messages_local;
+DROP INDEX IF EXISTS idx_messages_region_arr_region;
+ALTER TABLE messages DROP COLUMN IF EXISTS arr_region;
+SELECT refresh_messages_local_views();
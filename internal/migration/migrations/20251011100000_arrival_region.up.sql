@@ -0,0 +1,12 @@
+-- Регион посадки по точке прибытия, аналогично region по точке вылета
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS arr_region INTEGER REFERENCES district_shapes(gid);
+
+UPDATE messages m SET arr_region = d.gid
+FROM district_shapes d
+WHERE m.arr_coordinate IS NOT NULL
+    AND ST_Contains(d.geom, ST_SetSRID(m.arr_coordinate::geometry, 0));
+
+CREATE INDEX IF NOT EXISTS idx_messages_region_arr_region ON messages(region, arr_region);
+
+-- представление пересоздается, чтобы m.* включало новый столбец
+SELECT refresh_messages_local_views();
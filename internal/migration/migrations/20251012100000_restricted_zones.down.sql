@@ -0,0 +1,3 @@
+DROP FUNCTION IF EXISTS check_zone_violations(INT, INT);
+DROP TABLE IF EXISTS zone_violations;
+DROP TABLE IF EXISTS restricted_zones;
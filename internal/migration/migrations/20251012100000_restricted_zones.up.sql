@@ -0,0 +1,86 @@
+-- Запретные зоны и зоны ограничения полетов, загружаемые из GeoJSON/KML.
+-- Высоты в метрах (как min_alt/max_alt в messages), окна и период действия — в UTC, как ATD/ATA.
+CREATE TABLE IF NOT EXISTS restricted_zones(
+    id SERIAL PRIMARY KEY ,
+    name VARCHAR(255) NOT NULL ,
+    kind VARCHAR(32) NOT NULL DEFAULT 'restricted', -- no_fly | restricted
+    min_alt INTEGER,        -- NULL — от земли
+    max_alt INTEGER,        -- NULL — без верхней границы
+    active_from TIME,       -- ежедневное окно действия; NULL — круглосуточно
+    active_to TIME,
+    valid_from TIMESTAMP,   -- период действия; NULL — бессрочно
+    valid_to TIMESTAMP,
+    source VARCHAR(255),
+    properties JSONB NOT NULL DEFAULT '{}',
+    geom geometry(Geometry, 4326) NOT NULL ,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_restricted_zones_geom ON restricted_zones USING GIST(geom);
+
+-- Нарушения: полет пересекает зону точкой вылета, зоной ZONA или маршрутом вылет–посадка
+CREATE TABLE IF NOT EXISTS zone_violations(
+    id SERIAL PRIMARY KEY ,
+    message_id INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE ,
+    zone_id INT NOT NULL REFERENCES restricted_zones(id) ON DELETE CASCADE ,
+    kind VARCHAR(16) NOT NULL , -- departure | zone | route
+    detected_at TIMESTAMP NOT NULL DEFAULT now(),
+    UNIQUE (message_id, zone_id, kind)
+);
+CREATE INDEX IF NOT EXISTS idx_zone_violations_zone ON zone_violations(zone_id);
+
+-- Перепроверяет полет p_message_id против зоны p_zone_id; NULL — все полеты или все зоны.
+-- Зона и полет должны пересекаться в пространстве, по высоте и по времени.
+CREATE OR REPLACE FUNCTION check_zone_violations(p_message_id INT, p_zone_id INT) RETURNS INT AS $$
+DECLARE
+    n INT;
+BEGIN
+    DELETE FROM zone_violations v
+    WHERE (p_message_id IS NULL OR v.message_id = p_message_id)
+        AND (p_zone_id IS NULL OR v.zone_id = p_zone_id);
+
+    WITH flights AS (
+        SELECT
+            m.id, m.min_alt, m.max_alt,
+            m.dof + m.atd AS start_at,
+            COALESCE(m.dof + m.ata + CASE WHEN m.ata < m.atd THEN INTERVAL '1 day' ELSE INTERVAL '0' END,
+                     m.dof + m.atd) AS end_at,
+            m.dep_coordinate::geometry AS dep,
+            CASE WHEN m.arr_coordinate IS NOT NULL
+                    AND NOT ST_Equals(m.dep_coordinate::geometry, m.arr_coordinate::geometry)
+                THEN ST_MakeLine(m.dep_coordinate::geometry, m.arr_coordinate::geometry)
+            END AS route,
+            (SELECT ST_ConvexHull(ST_Collect(fc.coordinate::geometry))
+             FROM flight_coordinates fc WHERE fc.sid = m.sid) AS zona
+        FROM messages m
+        WHERE p_message_id IS NULL OR m.id = p_message_id
+    )
+    INSERT INTO zone_violations(message_id, zone_id, kind)
+    SELECT f.id, z.id, k.kind
+    FROM flights f
+    JOIN restricted_zones z
+        ON (p_zone_id IS NULL OR z.id = p_zone_id)
+        AND z.geom && ST_Envelope(ST_Collect(ARRAY[f.dep, f.zona, f.route]))
+        AND (z.min_alt IS NULL OR f.max_alt >= z.min_alt)
+        AND (z.max_alt IS NULL OR f.min_alt <= z.max_alt)
+        AND (z.valid_from IS NULL OR f.end_at >= z.valid_from)
+        AND (z.valid_to IS NULL OR f.start_at <= z.valid_to)
+    CROSS JOIN LATERAL (VALUES
+        ('departure', ST_Intersects(z.geom, f.dep)),
+        ('zone', f.zona IS NOT NULL AND ST_Intersects(z.geom, f.zona)),
+        ('route', f.route IS NOT NULL AND ST_Intersects(z.geom, f.route))
+    ) AS k(kind, hit)
+    WHERE k.hit AND (
+        z.active_from IS NULL OR z.active_to IS NULL OR EXISTS (
+            SELECT 1
+            FROM generate_series((f.start_at::date - 1)::timestamp, f.end_at::date::timestamp, INTERVAL '1 day') AS d
+            WHERE d + z.active_from::interval <= f.end_at
+                AND d + z.active_to::interval
+                    + CASE WHEN z.active_to <= z.active_from THEN INTERVAL '1 day' ELSE INTERVAL '0' END >= f.start_at
+        )
+    )
+    ON CONFLICT DO NOTHING;
+
+    GET DIAGNOSTICS n = ROW_COUNT;
+    RETURN n;
+END;
+$$ LANGUAGE plpgsql;
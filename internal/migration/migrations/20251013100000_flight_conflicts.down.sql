@@ -0,0 +1,3 @@
+DROP FUNCTION IF EXISTS detect_flight_conflicts(DATE, DOUBLE PRECISION);
+DROP TABLE IF EXISTS flight_conflict_days;
+DROP TABLE IF EXISTS flight_conflicts;
@@ -0,0 +1,95 @@
+-- Пары полетов, чьи объемы воздушного пространства сближаются одновременно:
+-- интервалы ATD–ATA пересекаются, диапазоны высот пересекаются, а проекции
+-- (зона ZONA, иначе прямая вылет–посадка) ближе разделения. Время — в UTC, как ATD/ATA.
+CREATE TABLE IF NOT EXISTS flight_conflicts(
+    id SERIAL PRIMARY KEY ,
+    day DATE NOT NULL ,                 -- сутки начала пересечения
+    message_a INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE ,
+    message_b INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE ,
+    overlap_start TIMESTAMP NOT NULL ,
+    overlap_end TIMESTAMP NOT NULL ,
+    overlap_seconds INT NOT NULL ,
+    alt_low INT NOT NULL ,              -- общий диапазон высот, м
+    alt_high INT NOT NULL ,
+    distance_m DOUBLE PRECISION NOT NULL , -- 0 — проекции пересекаются
+    detected_at TIMESTAMP NOT NULL DEFAULT now(),
+    CHECK (message_a < message_b),
+    UNIQUE (message_a, message_b)
+);
+CREATE INDEX IF NOT EXISTS idx_flight_conflicts_day ON flight_conflicts(day);
+CREATE INDEX IF NOT EXISTS idx_flight_conflicts_b ON flight_conflicts(message_b);
+
+-- Проверенные сутки: когда и с каким разделением
+CREATE TABLE IF NOT EXISTS flight_conflict_days(
+    day DATE PRIMARY KEY ,
+    separation_m DOUBLE PRECISION NOT NULL ,
+    flights INT NOT NULL DEFAULT 0,
+    conflicts INT NOT NULL DEFAULT 0,
+    checked_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE OR REPLACE FUNCTION detect_flight_conflicts(p_day DATE, p_separation DOUBLE PRECISION) RETURNS INT AS $$
+DECLARE
+    n INT;
+    total INT;
+BEGIN
+    DELETE FROM flight_conflicts WHERE day = p_day;
+
+    CREATE TEMP TABLE conflict_flights ON COMMIT DROP AS
+    SELECT
+        m.id, m.min_alt, m.max_alt,
+        m.dof + m.atd AS start_at,
+        m.dof + m.ata + CASE WHEN m.ata < m.atd THEN INTERVAL '1 day' ELSE INTERVAL '0' END AS end_at,
+        COALESCE(
+            (SELECT ST_ConvexHull(ST_Collect(fc.coordinate::geometry))
+             FROM flight_coordinates fc WHERE fc.sid = m.sid),
+            CASE WHEN m.arr_coordinate IS NOT NULL
+                THEN ST_MakeLine(m.dep_coordinate::geometry, m.arr_coordinate::geometry)
+                ELSE m.dep_coordinate::geometry
+            END
+        )::geography AS footprint
+    FROM messages m
+    WHERE m.ata IS NOT NULL AND m.dof BETWEEN p_day - 1 AND p_day;
+
+    DELETE FROM conflict_flights WHERE NOT (start_at < p_day + 1 AND end_at > p_day);
+    SELECT COUNT(*) INTO total FROM conflict_flights;
+    CREATE INDEX ON conflict_flights USING GIST(footprint);
+
+    INSERT INTO flight_conflicts(day, message_a, message_b, overlap_start, overlap_end,
+                                 overlap_seconds, alt_low, alt_high, distance_m)
+    SELECT
+        p_day, a.id, b.id,
+        GREATEST(a.start_at, b.start_at), LEAST(a.end_at, b.end_at),
+        EXTRACT(EPOCH FROM LEAST(a.end_at, b.end_at) - GREATEST(a.start_at, b.start_at))::int,
+        GREATEST(a.min_alt, b.min_alt), LEAST(a.max_alt, b.max_alt),
+        ST_Distance(a.footprint, b.footprint)
+    FROM conflict_flights a
+    JOIN conflict_flights b ON a.id < b.id
+        AND a.start_at < b.end_at AND b.start_at < a.end_at
+        AND a.min_alt <= b.max_alt AND b.min_alt <= a.max_alt
+        AND ST_DWithin(a.footprint, b.footprint, p_separation)
+    -- пара относится к суткам, в которые началось пересечение
+    WHERE GREATEST(a.start_at, b.start_at)::date = p_day
+    ON CONFLICT (message_a, message_b) DO UPDATE SET
+        day = EXCLUDED.day,
+        overlap_start = EXCLUDED.overlap_start,
+        overlap_end = EXCLUDED.overlap_end,
+        overlap_seconds = EXCLUDED.overlap_seconds,
+        alt_low = EXCLUDED.alt_low,
+        alt_high = EXCLUDED.alt_high,
+        distance_m = EXCLUDED.distance_m,
+        detected_at = now();
+    GET DIAGNOSTICS n = ROW_COUNT;
+
+    DROP TABLE conflict_flights;
+
+    INSERT INTO flight_conflict_days(day, separation_m, flights, conflicts, checked_at)
+    VALUES (p_day, p_separation, total, n, now())
+    ON CONFLICT (day) DO UPDATE SET
+        separation_m = EXCLUDED.separation_m,
+        flights = EXCLUDED.flights,
+        conflicts = EXCLUDED.conflicts,
+        checked_at = EXCLUDED.checked_at;
+    RETURN n;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,3 @@
+DROP FUNCTION IF EXISTS apply_altitude_ceilings(INT);
+DROP TABLE IF EXISTS flight_altitude_compliance;
+DROP TABLE IF EXISTS altitude_ceilings;
@@ -0,0 +1,58 @@
+-- Потолки высоты: общий (region_code и zone_kind пусты), для региона и/или для вылета
+-- из зоны данного типа (restricted_zones.kind). Полету назначается самый низкий из подходящих.
+CREATE TABLE IF NOT EXISTS altitude_ceilings(
+    id SERIAL PRIMARY KEY ,
+    name VARCHAR(255) NOT NULL ,
+    region_code INT REFERENCES district_shapes(gid) ON DELETE CASCADE ,
+    zone_kind VARCHAR(32),
+    max_alt INT NOT NULL CHECK (max_alt >= 0), -- м
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_altitude_ceilings_scope
+    ON altitude_ceilings(COALESCE(region_code, 0), COALESCE(zone_kind, ''));
+
+INSERT INTO altitude_ceilings(name, max_alt) VALUES ('Общий потолок', 150)
+ON CONFLICT DO NOTHING;
+
+-- Соответствие полета потолку; хранится отдельно, чтобы пересчет не помечал партиции метрик
+CREATE TABLE IF NOT EXISTS flight_altitude_compliance(
+    message_id INT PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE ,
+    ceiling_id INT REFERENCES altitude_ceilings(id) ON DELETE SET NULL ,
+    ceiling_alt INT,
+    above_ceiling BOOLEAN,  -- max_alt полета выше потолка; NULL — потолок не задан
+    checked_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_flight_altitude_compliance_ceiling ON flight_altitude_compliance(ceiling_id);
+
+-- Назначает потолок полету p_message_id (NULL — всем полетам) и возвращает число обработанных
+CREATE OR REPLACE FUNCTION apply_altitude_ceilings(p_message_id INT) RETURNS INT AS $$
+DECLARE
+    n INT;
+BEGIN
+    INSERT INTO flight_altitude_compliance(message_id, ceiling_id, ceiling_alt, above_ceiling, checked_at)
+    SELECT m.id, c.id, c.max_alt, m.max_alt > c.max_alt, now()
+    FROM messages m
+    LEFT JOIN LATERAL (
+        SELECT ac.id, ac.max_alt
+        FROM altitude_ceilings ac
+        WHERE (ac.region_code IS NULL OR ac.region_code = m.region)
+            AND (ac.zone_kind IS NULL OR EXISTS (
+                SELECT 1 FROM restricted_zones z
+                WHERE z.kind = ac.zone_kind AND ST_Intersects(z.geom, m.dep_coordinate::geometry)
+            ))
+        ORDER BY ac.max_alt, ac.id
+        LIMIT 1
+    ) c ON true
+    WHERE p_message_id IS NULL OR m.id = p_message_id
+    ON CONFLICT (message_id) DO UPDATE SET
+        ceiling_id = EXCLUDED.ceiling_id,
+        ceiling_alt = EXCLUDED.ceiling_alt,
+        above_ceiling = EXCLUDED.above_ceiling,
+        checked_at = EXCLUDED.checked_at;
+    GET DIAGNOSTICS n = ROW_COUNT;
+    RETURN n;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT apply_altitude_ceilings(NULL);
@@ -0,0 +1,17 @@
+DROP VIEW IF EXISTS messages_local;
+DROP VIEW IF EXISTS messages_local_all;
+DROP INDEX IF EXISTS idx_messages_plausibility_pending;
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS implausible_reasons,
+    DROP COLUMN IF EXISTS implied_speed_kmh,
+    DROP COLUMN IF EXISTS plausibility_checked;
+
+-- без отбора по правдоподобности представление снова одно
+CREATE OR REPLACE FUNCTION refresh_messages_local_views() RETURNS void AS $$
+BEGIN
+    DROP VIEW IF EXISTS messages_local;
+    EXECUTE 'CREATE VIEW messages_local AS ' || messages_local_select();
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT refresh_messages_local_views();
@@ -0,0 +1,22 @@
+-- Кинематическая правдоподобность полета: коды причин (ata_before_atd, zero_duration,
+-- impossible_speed) и скорость по прямой вылет–посадка. Считается в Go при разборе SHR;
+-- plausibility_checked = false — нужно перепроверить (старые записи, время из DEP/ARR).
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS implausible_reasons TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS implied_speed_kmh DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS plausibility_checked BOOLEAN NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS idx_messages_plausibility_pending ON messages(id) WHERE NOT plausibility_checked;
+
+-- messages_local_all — все полеты; messages_local, по которому считаются метрики, — только
+-- правдоподобные. Последующие миграции пересоздают оба представления этой функцией.
+CREATE OR REPLACE FUNCTION refresh_messages_local_views() RETURNS void AS $$
+BEGIN
+    DROP VIEW IF EXISTS messages_local;
+    DROP VIEW IF EXISTS messages_local_all;
+    EXECUTE 'CREATE VIEW messages_local_all AS ' || messages_local_select();
+    CREATE VIEW messages_local AS
+    SELECT * FROM messages_local_all WHERE cardinality(implausible_reasons) = 0;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT refresh_messages_local_views();
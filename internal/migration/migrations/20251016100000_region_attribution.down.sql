@@ -0,0 +1,14 @@
+DROP VIEW IF EXISTS messages_local;
+DROP VIEW IF EXISTS messages_local_all;
+DROP INDEX IF EXISTS idx_messages_region_method;
+UPDATE messages SET region = NULL WHERE region_method = 'nearest';
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS source_region,
+    DROP COLUMN IF EXISTS region_method,
+    DROP COLUMN IF EXISTS region_distance_m;
+DROP FUNCTION IF EXISTS region_name_matches(TEXT, TEXT);
+DROP FUNCTION IF EXISTS region_name_stem(TEXT);
+DROP FUNCTION IF EXISTS attribute_region(geometry, DOUBLE PRECISION);
+DROP INDEX IF EXISTS idx_district_shapes_geom;
+
+SELECT refresh_messages_local_views();
@@ -0,0 +1,77 @@
+-- Регион из таблицы (столбец 0) хранится рядом с регионом по геометрии точки вылета.
+-- region_method: contains — точка внутри полигона региона, nearest — ближайший регион
+-- в пределах допуска (точка у границы или в море), NULL — регион не определен.
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS source_region TEXT,
+    ADD COLUMN IF NOT EXISTS region_method TEXT,
+    ADD COLUMN IF NOT EXISTS region_distance_m DOUBLE PRECISION;
+
+CREATE INDEX IF NOT EXISTS idx_district_shapes_geom ON district_shapes USING GIST(geom);
+
+-- attribute_region определяет регион точки (WGS84): сначала по вхождению в полигон,
+-- затем ближайший полигон не дальше p_max_distance метров
+CREATE OR REPLACE FUNCTION attribute_region(p_point geometry, p_max_distance DOUBLE PRECISION DEFAULT 50000)
+RETURNS TABLE(gid INTEGER, method TEXT, distance_m DOUBLE PRECISION) AS $$
+DECLARE
+    pt geometry := ST_SetSRID(p_point, 0);
+BEGIN
+    IF p_point IS NULL THEN
+        RETURN QUERY SELECT NULL::int, NULL::text, NULL::float8;
+        RETURN;
+    END IF;
+
+    RETURN QUERY
+    SELECT d.gid, 'contains'::text, 0::float8
+    FROM district_shapes d
+    WHERE ST_Contains(d.geom, pt)
+    ORDER BY d.gid
+    LIMIT 1;
+    IF FOUND THEN
+        RETURN;
+    END IF;
+
+    RETURN QUERY
+    SELECT n.gid, 'nearest'::text, n.dist
+    FROM (
+        SELECT c.gid,
+            ST_Distance(geography(ST_SetSRID(c.geom, 4326)), geography(ST_SetSRID(p_point, 4326))) AS dist
+        FROM (
+            SELECT d.gid, d.geom FROM district_shapes d ORDER BY d.geom <-> pt LIMIT 5
+        ) c
+    ) n
+    WHERE n.dist <= p_max_distance
+    ORDER BY n.dist
+    LIMIT 1;
+    IF FOUND THEN
+        RETURN;
+    END IF;
+
+    RETURN QUERY SELECT NULL::int, NULL::text, NULL::float8;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- region_name_matches сравнивает название региона из таблицы с названием полигона по основе
+-- первого слова: «Ростовский» и «Ростовская обл.» совпадают, «Московский» и «Тверская обл.» — нет
+CREATE OR REPLACE FUNCTION region_name_stem(p_name TEXT) RETURNS TEXT AS $$
+    SELECT regexp_replace(
+        lower(split_part(regexp_replace(trim(p_name), '^(г\.|город|республика)\s+', '', 'i'), ' ', 1)),
+        '(ская|ский|ское|ской|ая|ий|ый|ое|а|я|ь)$', '')
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION region_name_matches(p_source TEXT, p_region TEXT) RETURNS BOOLEAN AS $$
+    SELECT COALESCE(trim(p_source), '') <> '' AND COALESCE(trim(p_region), '') <> ''
+        AND (starts_with(region_name_stem(p_region), region_name_stem(p_source))
+            OR starts_with(region_name_stem(p_source), region_name_stem(p_region)))
+$$ LANGUAGE sql IMMUTABLE;
+
+-- пересчет существующих полетов: вхождение уже записано в region, дополняются метод и ближайший регион
+UPDATE messages SET region_method = 'contains', region_distance_m = 0 WHERE region IS NOT NULL;
+UPDATE messages m SET (region, region_method, region_distance_m) = (
+    SELECT a.gid, a.method, a.distance_m FROM attribute_region(m.dep_coordinate::geometry) a
+)
+WHERE m.region IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_messages_region_method ON messages(region_method);
+
+-- представления пересоздаются, чтобы m.* включало новые столбцы
+SELECT refresh_messages_local_views();
@@ -0,0 +1,3 @@
+DROP FUNCTION IF EXISTS detect_launch_sites(DOUBLE PRECISION, INT);
+DROP TABLE IF EXISTS launch_site_flights;
+DROP TABLE IF EXISTS launch_sites;
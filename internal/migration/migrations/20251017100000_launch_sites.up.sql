@@ -0,0 +1,107 @@
+-- Места регулярных запусков: кластеры точек вылета (DBSCAN) с контуром, числом полетов,
+-- операторами и часами активности. Каталог пересчитывается целиком.
+CREATE TABLE IF NOT EXISTS launch_sites(
+    id SERIAL PRIMARY KEY ,
+    region INTEGER REFERENCES district_shapes(gid) ON DELETE SET NULL ,
+    center geometry(Point, 4326) NOT NULL ,
+    geom geometry(Polygon, 4326) NOT NULL , -- выпуклая оболочка точек с отступом в радиус
+    flights INT NOT NULL ,
+    operators INT NOT NULL ,                -- различных операторов
+    top_operators TEXT[] NOT NULL DEFAULT '{}',
+    first_flight DATE NOT NULL ,
+    last_flight DATE NOT NULL ,
+    active_days INT NOT NULL ,              -- дней с вылетами
+    hours INT[] NOT NULL ,                  -- вылеты по часам местного времени, 24 значения
+    radius_m DOUBLE PRECISION NOT NULL ,
+    min_flights INT NOT NULL ,
+    detected_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_launch_sites_geom ON launch_sites USING GIST(geom);
+CREATE INDEX IF NOT EXISTS idx_launch_sites_region ON launch_sites(region);
+
+CREATE TABLE IF NOT EXISTS launch_site_flights(
+    site_id INT NOT NULL REFERENCES launch_sites(id) ON DELETE CASCADE ,
+    message_id INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE ,
+    PRIMARY KEY (site_id, message_id)
+);
+CREATE INDEX IF NOT EXISTS idx_launch_site_flights_message ON launch_site_flights(message_id);
+
+-- detect_launch_sites кластеризует все точки вылета: соседи — не дальше p_radius метров,
+-- место — не меньше p_min_flights вылетов. Точки переводятся в метры проекцией UTM своей
+-- шестиградусной зоны и кластеризуются внутри зоны: искажение расстояний в UTM не больше
+-- 0.1%, но площадка на границе зон может разделиться на два места.
+CREATE OR REPLACE FUNCTION detect_launch_sites(p_radius DOUBLE PRECISION, p_min_flights INT) RETURNS INT AS $$
+DECLARE
+    n INT;
+BEGIN
+    CREATE TEMP TABLE site_points ON COMMIT DROP AS
+    SELECT
+        m.id, m.region, m.dof, m.atd_local,
+        NULLIF(TRIM(m.opr), '') AS opr,
+        d.pt, z.srid,
+        ST_ClusterDBSCAN(ST_Transform(d.pt, z.srid), p_radius, p_min_flights)
+            OVER (PARTITION BY z.srid) AS cluster
+    FROM messages_local_all m
+    CROSS JOIN LATERAL (SELECT m.dep_coordinate::geometry AS pt) d
+    -- EPSG UTM: 326xx — северное полушарие, 327xx — южное
+    CROSS JOIN LATERAL (
+        SELECT CASE WHEN ST_Y(d.pt) >= 0 THEN 32600 ELSE 32700 END
+            + LEAST(FLOOR((ST_X(d.pt) + 180) / 6)::int + 1, 60) AS srid
+    ) z
+    WHERE m.dep_coordinate IS NOT NULL;
+
+    DELETE FROM launch_sites;
+
+    -- id мест выделяются заранее, чтобы связать полеты с местом по зоне и номеру кластера
+    CREATE TEMP TABLE site_clusters ON COMMIT DROP AS
+    SELECT
+        nextval(pg_get_serial_sequence('launch_sites', 'id'))::int AS site_id,
+        srid, cluster,
+        mode() WITHIN GROUP (ORDER BY region) AS region,
+        ST_SetSRID(ST_Centroid(ST_Collect(pt)), 4326) AS center,
+        ST_SetSRID(ST_ConvexHull(ST_Collect(pt)), 4326) AS hull,
+        COUNT(*) AS flights,
+        COUNT(DISTINCT opr) AS operators,
+        MIN(dof) AS first_flight,
+        MAX(dof) AS last_flight,
+        COUNT(DISTINCT dof) AS active_days
+    FROM site_points
+    WHERE cluster IS NOT NULL
+    GROUP BY srid, cluster;
+
+    INSERT INTO launch_sites(id, region, center, geom, flights, operators, top_operators,
+        first_flight, last_flight, active_days, hours, radius_m, min_flights)
+    SELECT
+        c.site_id, c.region, c.center,
+        ST_Buffer(c.hull::geography, p_radius)::geometry,
+        c.flights, c.operators,
+        COALESCE((
+            SELECT array_agg(o.opr ORDER BY o.cnt DESC, o.opr)
+            FROM (
+                SELECT opr, COUNT(*) AS cnt FROM site_points
+                WHERE srid = c.srid AND cluster = c.cluster AND opr IS NOT NULL
+                GROUP BY opr ORDER BY cnt DESC, opr LIMIT 5
+            ) o
+        ), '{}'),
+        c.first_flight, c.last_flight, c.active_days,
+        (
+            SELECT array_agg(COALESCE(h.cnt, 0) ORDER BY g.hour)
+            FROM generate_series(0, 23) AS g(hour)
+            LEFT JOIN (
+                SELECT EXTRACT(HOUR FROM atd_local)::int AS hour, COUNT(*)::int AS cnt
+                FROM site_points WHERE srid = c.srid AND cluster = c.cluster
+                GROUP BY 1
+            ) h ON h.hour = g.hour
+        ),
+        p_radius, p_min_flights
+    FROM site_clusters c;
+
+    INSERT INTO launch_site_flights(site_id, message_id)
+    SELECT c.site_id, p.id
+    FROM site_points p
+    JOIN site_clusters c ON c.srid = p.srid AND c.cluster = p.cluster;
+
+    SELECT COUNT(*) INTO n FROM launch_sites;
+    RETURN n;
+END;
+$$ LANGUAGE plpgsql;
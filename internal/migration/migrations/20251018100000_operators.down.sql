@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_messages_opr_trim;
+DROP TABLE IF EXISTS operator_aliases;
+DROP TABLE IF EXISTS operators;
@@ -0,0 +1,21 @@
+-- Операторы БВС: записи поля OPR/ сводятся по ключу (имя без телефонов и знаков
+-- препинания в верхнем регистре). Разбор выполняется в Go перед пересчетом метрик.
+CREATE TABLE IF NOT EXISTS operators(
+    id SERIAL PRIMARY KEY ,
+    key TEXT NOT NULL UNIQUE ,
+    name TEXT NOT NULL ,
+    phones TEXT[] NOT NULL DEFAULT '{}',
+    organizations TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- Исходные написания OPR/ (после TRIM) и оператор, к которому они отнесены;
+-- operator_id IS NULL — в записи нет ни имени, ни телефона
+CREATE TABLE IF NOT EXISTS operator_aliases(
+    raw TEXT PRIMARY KEY ,
+    operator_id INT REFERENCES operators(id) ON DELETE CASCADE ,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_operator_aliases_operator ON operator_aliases(operator_id);
+
+CREATE INDEX IF NOT EXISTS idx_messages_opr_trim ON messages((TRIM(opr)));
@@ -0,0 +1,17 @@
+DROP VIEW IF EXISTS messages_local;
+DROP VIEW IF EXISTS messages_local_all;
+
+DROP TRIGGER IF EXISTS messages_mark_metrics_partition_update ON messages;
+DROP TRIGGER IF EXISTS messages_mark_metrics_partition ON messages;
+CREATE TRIGGER messages_mark_metrics_partition
+    AFTER INSERT OR UPDATE OR DELETE ON messages
+    FOR EACH ROW EXECUTE FUNCTION mark_metrics_partition();
+
+DROP INDEX IF EXISTS idx_messages_operator;
+ALTER TABLE messages DROP COLUMN IF EXISTS operator_id;
+ALTER TABLE operators DROP COLUMN IF EXISTS merged_into;
+ALTER TABLE operator_aliases
+    DROP COLUMN IF EXISTS method,
+    DROP COLUMN IF EXISTS score;
+
+SELECT refresh_messages_local_views();
@@ -0,0 +1,33 @@
+-- Канонический оператор каждого полета. Написания OPR/ сводятся к операторам в Go
+-- (свертка регистра, пробелов и латинских двойников, телефоны, нечеткое сравнение);
+-- администратор может объединить операторов или отделить написания в нового.
+
+-- Ключи операторов строятся по-новому, поэтому ранее сведенные операторы пересобираются
+-- при следующем пересчете; ручных изменений до этой миграции не было. Очистка идет до
+-- появления ссылки messages.operator_id: TRUNCATE таблицы, на которую ссылаются, запрещен.
+TRUNCATE operator_aliases, operators RESTART IDENTITY;
+
+ALTER TABLE operator_aliases
+    ADD COLUMN IF NOT EXISTS method TEXT NOT NULL DEFAULT 'exact',
+    ADD COLUMN IF NOT EXISTS score DOUBLE PRECISION NOT NULL DEFAULT 1;
+
+-- Объединенный оператор остается ради ключа и телефонов: новые написания, совпавшие
+-- с ним, относятся к оператору, в который он влит
+ALTER TABLE operators ADD COLUMN IF NOT EXISTS merged_into INT REFERENCES operators(id);
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS operator_id INT REFERENCES operators(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_messages_operator ON messages(operator_id);
+
+-- Смена оператора полета не влияет на flight_metrics и не должна помечать партиции
+DROP TRIGGER IF EXISTS messages_mark_metrics_partition ON messages;
+CREATE TRIGGER messages_mark_metrics_partition
+    AFTER INSERT OR DELETE ON messages
+    FOR EACH ROW EXECUTE FUNCTION mark_metrics_partition();
+CREATE TRIGGER messages_mark_metrics_partition_update
+    AFTER UPDATE ON messages
+    FOR EACH ROW
+    WHEN (to_jsonb(OLD) - 'operator_id' IS DISTINCT FROM to_jsonb(NEW) - 'operator_id')
+    EXECUTE FUNCTION mark_metrics_partition();
+
+-- представления пересоздаются, чтобы m.* включало новый столбец
+SELECT refresh_messages_local_views();
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_messages_reg_normalize;
+DROP TABLE IF EXISTS aircraft;
+DROP FUNCTION IF EXISTS normalize_reg(TEXT);
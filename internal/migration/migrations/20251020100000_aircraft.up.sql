@@ -0,0 +1,27 @@
+-- Реестр БВС по полю REG/. Номер приводится к верхнему регистру без пробелов;
+-- записи без цифр («НЕТ», «Б/Н», «-») номером не считаются.
+CREATE OR REPLACE FUNCTION normalize_reg(reg TEXT) RETURNS TEXT AS $$
+    SELECT CASE
+        WHEN reg ~ '[0-9]' THEN upper(regexp_replace(reg, '\s+', '', 'g'))
+    END
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Борт и его разметка администратором; полеты, часы, типы и операторы считаются
+-- по messages при запросе
+CREATE TABLE IF NOT EXISTS aircraft(
+    id SERIAL PRIMARY KEY ,
+    reg TEXT NOT NULL UNIQUE ,
+    model TEXT NOT NULL DEFAULT '',
+    weight_class TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_aircraft_category ON aircraft(category);
+
+CREATE INDEX IF NOT EXISTS idx_messages_reg_normalize ON messages((normalize_reg(reg)));
+
+INSERT INTO aircraft(reg)
+SELECT DISTINCT normalize_reg(reg) FROM messages WHERE normalize_reg(reg) IS NOT NULL
+ON CONFLICT (reg) DO NOTHING;
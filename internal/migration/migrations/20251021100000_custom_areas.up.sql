@@ -0,0 +1,9 @@
+-- Сохраненные пользователями области интереса для расчета метрик по произвольному полигону
+CREATE TABLE IF NOT EXISTS custom_areas(
+    id SERIAL PRIMARY KEY ,
+    name TEXT NOT NULL ,
+    geom geometry(Geometry, 4326) NOT NULL ,
+    area_km2 DOUBLE PRECISION NOT NULL ,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_custom_areas_geom ON custom_areas USING GIST(geom);
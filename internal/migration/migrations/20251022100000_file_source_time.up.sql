@@ -0,0 +1,6 @@
+-- Время изменения исходного файла — признак свежести для политики keep-newest.
+-- uploaded_at для этого не годится: каждая следующая загрузка позже предыдущей.
+ALTER TABLE files ADD COLUMN IF NOT EXISTS source_modified_at TIMESTAMPTZ;
+
+UPDATE files SET source_modified_at = (metadata->>'mod_time')::timestamptz
+WHERE source_modified_at IS NULL AND metadata ? 'mod_time';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS files_sha256_key;
+ALTER TABLE files DROP COLUMN IF EXISTS sha256;
+ALTER TABLE files ADD CONSTRAINT files_metadata_key UNIQUE (metadata);
@@ -0,0 +1,11 @@
+-- Повторная загрузка файла определяется по содержимому. Путь и время изменения
+-- остаются в metadata, но в ключ не входят: копия в другой папке — тот же файл.
+ALTER TABLE files ADD COLUMN IF NOT EXISTS sha256 VARCHAR(64);
+
+-- из прежних копий одного содержимого ключ получает первая загрузка
+UPDATE files f SET sha256 = f.metadata->>'sha256'
+WHERE f.sha256 IS NULL
+    AND f.id = (SELECT MIN(g.id) FROM files g WHERE g.metadata->>'sha256' = f.metadata->>'sha256');
+
+ALTER TABLE files DROP CONSTRAINT IF EXISTS files_metadata_key;
+CREATE UNIQUE INDEX IF NOT EXISTS files_sha256_key ON files(sha256);
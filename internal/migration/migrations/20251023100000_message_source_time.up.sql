@@ -0,0 +1,7 @@
+-- Время источника полета для политики keep-newest: изменения файла или приема телеграммы.
+-- Хранится на полете, так как у полетов из телеграмм нет файла.
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS source_time TIMESTAMPTZ;
+
+UPDATE messages m SET source_time = f.source_modified_at
+FROM files f
+WHERE f.id = m.file_id AND m.source_time IS NULL;
@@ -0,0 +1,105 @@
+package model
+
+import "time"
+
+// Категории БВС по схеме
+const (
+	CategoryMultirotor = "multirotor" // Мультикоптер
+	CategoryFixedWing  = "fixed_wing" // Самолетный тип
+	CategoryVTOL       = "vtol"       // Самолетный с вертикальным взлетом
+	CategoryHelicopter = "helicopter" // Вертолетный тип
+	CategoryAirship    = "airship"    // Аэростат, дирижабль
+	CategoryOther      = "other"
+
+	CategoryUnknown      = "unknown"      // Борт не размечен
+	CategoryUnregistered = "unregistered" // В сообщении нет регистрационного номера
+)
+
+// AircraftCategories — категории, которые может назначить администратор
+var AircraftCategories = []string{
+	CategoryMultirotor, CategoryFixedWing, CategoryVTOL, CategoryHelicopter, CategoryAirship, CategoryOther,
+}
+
+// Классы по максимальной взлетной массе
+const (
+	WeightMicro  = "micro"  // До 0,25 кг
+	WeightLight  = "light"  // 0,25–30 кг
+	WeightMedium = "medium" // 30–150 кг
+	WeightHeavy  = "heavy"  // Свыше 150 кг
+)
+
+// WeightClasses — классы массы, которые может назначить администратор
+var WeightClasses = []string{WeightMicro, WeightLight, WeightMedium, WeightHeavy}
+
+// Aircraft — борт из реестра, собранного по полю REG/. Пустые Model, WeightClass
+// и Category — борт не размечен.
+type Aircraft struct {
+	ID          int       `json:"id"`
+	Reg         string    `json:"reg"`
+	Model       string    `json:"model"`
+	WeightClass string    `json:"weight_class"`
+	Category    string    `json:"category"`
+	Notes       string    `json:"notes"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// AircraftAnnotation — разметка борта администратором
+type AircraftAnnotation struct {
+	Model       string `json:"model"`
+	WeightClass string `json:"weight_class"`
+	Category    string `json:"category"`
+	Notes       string `json:"notes"`
+}
+
+// AircraftStats — борт с показателями за период
+type AircraftStats struct {
+	Aircraft
+	Types     []string   `json:"types"` // Типы (TYP), указанные в сообщениях
+	Flights   int        `json:"flights"`
+	Hours     float64    `json:"hours"`     // Налет по полетам с известным временем посадки
+	Operators int        `json:"operators"` // Разных операторов
+	FirstSeen *time.Time `json:"first_seen"`
+	LastSeen  *time.Time `json:"last_seen"`
+}
+
+// AircraftOperator — оператор борта и число его полетов
+type AircraftOperator struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Flights int    `json:"flights"`
+}
+
+// AircraftProfile — карточка борта: показатели за период и операторы
+type AircraftProfile struct {
+	AircraftStats
+	Period       Period             `json:"period"`
+	OperatorList []AircraftOperator `json:"operator_list"`
+}
+
+// AircraftFilter — отбор бортов; Period — по дате вылета в местном времени региона
+type AircraftFilter struct {
+	Period   Period
+	RegionID int    // 0 — все регионы
+	Category string // Категория, unknown — неразмеченные; пусто — все
+	Query    string // Подстрока регистрационного номера
+	Limit    int
+	Offset   int
+}
+
+// CategoryStats — полеты категории БВС за период
+type CategoryStats struct {
+	Category           string  `json:"category"`
+	Aircraft           int     `json:"aircraft"`
+	Flights            int     `json:"flights"`
+	Hours              float64 `json:"hours"`
+	AvgDurationMinutes float64 `json:"avg_duration_minutes"`
+	Share              float64 `json:"share"` // Доля полетов периода
+}
+
+// CategoryBreakdown — полеты региона (0 — вся РФ) за период по категориям БВС
+type CategoryBreakdown struct {
+	Period     Period          `json:"period"`
+	RegionID   int             `json:"region_id"`
+	Flights    int             `json:"flights"`
+	Categories []CategoryStats `json:"categories"`
+}
@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// AltitudeCeiling — потолок высоты, м. Пустые RegionID и ZoneKind — общий потолок;
+// ZoneKind — для вылетов из зоны этого типа (RestrictedZone.Kind)
+type AltitudeCeiling struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	RegionID  *int      `json:"region_id"`
+	ZoneKind  *string   `json:"zone_kind"`
+	MaxAlt    int       `json:"max_alt"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CeilingCompliance — полеты, к которым применялся потолок, и доля превысивших его
+type CeilingCompliance struct {
+	CeilingID  int     `json:"ceiling_id"`
+	Name       string  `json:"name"`
+	MaxAlt     int     `json:"max_alt"`
+	Flights    int     `json:"flights"`
+	Above      int     `json:"above"`
+	ShareAbove float64 `json:"share_above"`
+}
+
+// AltitudeCompliance — соблюдение потолков высоты в регионе (0 — вся РФ) за период
+type AltitudeCompliance struct {
+	Period     Period              `json:"period"`
+	RegionID   int                 `json:"region_id"`
+	Flights    int                 `json:"flights"`
+	Checked    int                 `json:"checked"` // Полетов, для которых задан потолок
+	Above      int                 `json:"above"`
+	ShareAbove float64             `json:"share_above"` // Доля превысивших среди проверенных
+	Ceilings   []CeilingCompliance `json:"ceilings"`
+}
+
+// AltitudePoint — заявленный диапазон высот в момент Time
+type AltitudePoint struct {
+	Time   time.Time `json:"time"`
+	MinAlt int       `json:"min_alt"`
+	MaxAlt int       `json:"max_alt"`
+}
+
+// AltitudeProfile — высота полета по SID для линейного графика: заявленный диапазон
+// от вылета до посадки (UTC) и примененный потолок
+type AltitudeProfile struct {
+	SID          string          `json:"sid"`
+	RegionID     int             `json:"region_id"`
+	MinAlt       int             `json:"min_alt"`
+	MaxAlt       int             `json:"max_alt"`
+	CeilingID    *int            `json:"ceiling_id"`
+	CeilingName  *string         `json:"ceiling_name"`
+	CeilingAlt   *int            `json:"ceiling_alt"`
+	AboveCeiling *bool           `json:"above_ceiling"`
+	Points       []AltitudePoint `json:"points"`
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/paulmach/orb"
+)
+
+const (
+	AreaDeparture = "departure" // Точка вылета внутри области
+	AreaCrossing  = "crossing"  // Точка вылета внутри области или зона ZONA либо прямая вылет–посадка пересекает ее
+)
+
+// AreaScopeID — ключ области интереса в результате GetPeriodMetrics
+const AreaScopeID = -1
+
+// CustomArea — сохраненная область интереса
+type CustomArea struct {
+	ID        int             `json:"id"`
+	Name      string          `json:"name"`
+	AreaKm2   float64         `json:"area_km2"`
+	Geometry  orb.Geometry    `json:"-"`
+	GeoJSON   json.RawMessage `json:"geometry,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AreaScope — произвольная область для расчета метрик. Часы и дни считаются в TimeZone.
+type AreaScope struct {
+	Geometry orb.Geometry
+	Mode     string // AreaDeparture или AreaCrossing
+	TimeZone string
+}
+
+// AreaMetrics — метрики полетов области за период
+type AreaMetrics struct {
+	AreaID  int     `json:"area_id,omitempty"` // 0 — область передана в запросе
+	Name    string  `json:"name"`
+	Mode    string  `json:"mode"`
+	Period  Period  `json:"period"`
+	AreaKm2 float64 `json:"area_km2"`
+	Metrics Metrics `json:"metrics"`
+}
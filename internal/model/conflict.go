@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+// FlightConflictSide — участник конфликта
+type FlightConflictSide struct {
+	MessageID  int    `json:"message_id"`
+	SID        string `json:"sid"`
+	RegionID   int    `json:"region_id"`
+	RegionName string `json:"region_name"`
+	Operator   string `json:"operator"`
+	ATD        string `json:"atd"`
+	ATA        string `json:"ata"`
+	MinAlt     int    `json:"min_alt"`
+	MaxAlt     int    `json:"max_alt"`
+}
+
+// FlightConflict — два полета, чьи объемы воздушного пространства сближались одновременно.
+// Время — в UTC, как ATD/ATA.
+type FlightConflict struct {
+	ID             int                `json:"id"`
+	Day            time.Time          `json:"day"`
+	A              FlightConflictSide `json:"a"`
+	B              FlightConflictSide `json:"b"`
+	OverlapStart   time.Time          `json:"overlap_start"`
+	OverlapEnd     time.Time          `json:"overlap_end"`
+	OverlapSeconds int                `json:"overlap_seconds"`
+	AltLow         int                `json:"alt_low"` // Общий диапазон высот, м
+	AltHigh        int                `json:"alt_high"`
+	DistanceM      float64            `json:"distance_m"` // 0 — проекции пересекаются
+}
+
+// FlightConflictFilter — отбор конфликтов по суткам начала пересечения
+type FlightConflictFilter struct {
+	Period   Period
+	RegionID int    // Регион хотя бы одного из полетов; 0 — все
+	Operator string // Подстрока OPR хотя бы одного из полетов
+	Limit    int
+	Offset   int
+}
+
+// FlightConflictCount — число конфликтов с участием полетов региона или оператора
+type FlightConflictCount struct {
+	Key            string  `json:"key"` // Код региона или оператор
+	Name           string  `json:"name"`
+	Conflicts      int     `json:"conflicts"`
+	Flights        int     `json:"flights"` // Полетов региона/оператора, участвовавших в конфликтах
+	OverlapMinutes float64 `json:"overlap_minutes"`
+	MinDistanceM   float64 `json:"min_distance_m"`
+}
+
+// FlightConflictStats — конфликты за период с группировкой по региону или оператору
+type FlightConflictStats struct {
+	Period Period                `json:"period"`
+	Group  string                `json:"group"`
+	Total  int                   `json:"total"`
+	Items  []FlightConflictCount `json:"items"`
+}
+
+// FlightConflictDay — результат проверки суток
+type FlightConflictDay struct {
+	Day         time.Time `json:"day"`
+	SeparationM float64   `json:"separation_m"`
+	Flights     int       `json:"flights"`
+	Conflicts   int       `json:"conflicts"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+// FlightConflictJob — состояние пакетного поиска конфликтов
+type FlightConflictJob struct {
+	Running    bool       `json:"running"`
+	Period     *Period    `json:"period,omitempty"`
+	Done       int        `json:"done"`  // Проверено суток
+	Total      int        `json:"total"` // Всего суток в задании
+	Conflicts  int        `json:"conflicts"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+}
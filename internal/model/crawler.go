@@ -1,6 +1,17 @@
 package model
 
-import "github.com/paulmach/orb"
+import (
+	"errors"
+	"time"
+
+	"github.com/paulmach/orb"
+)
+
+// ErrMessageExists — полет с таким SID уже сохранен
+var ErrMessageExists = errors.New("message already exists")
+
+// ErrFileExists — файл с тем же содержимым уже загружен
+var ErrFileExists = errors.New("file already uploaded")
 
 type ParsedMessage struct {
 	Region      string      `json:"region"`        // Исходный регион (e.g. Ростовский)
@@ -21,4 +32,25 @@ type ParsedMessage struct {
 	RMK         string      `json:"rmk,omitempty"`         // Замечания
 	MinAlt      int         `json:"min_alt"`               // Минимальная высота в метрах
 	MaxAlt      int         `json:"max_alt"`               // Максимальная высота в метрах
+
+	ImplausibleReasons []string `json:"implausible_reasons,omitempty"` // Коды кинематической неправдоподобности
+	ImpliedSpeedKmh    *float64 `json:"implied_speed_kmh,omitempty"`   // Скорость по прямой вылет–посадка
+}
+
+// StoredMessage — сохраненный полет в текстовом виде для сравнения с входящим
+type StoredMessage struct {
+	SID        string
+	DOF        string
+	ATD        string
+	ATA        string
+	DepCoords  string
+	ArrCoords  string
+	OPR        string
+	REG        string
+	TYP        string
+	RMK        string
+	MinAlt     int
+	MaxAlt     int
+	FileID     int
+	SourceTime *time.Time // Время источника сохраненного полета: изменения файла или приема телеграммы; nil — неизвестно
 }
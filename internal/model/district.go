@@ -10,3 +10,11 @@ type District struct {
 	AdminLevel *string `json:"admin_level"`
 	Boundary   *string `json:"boundary"`
 }
+
+// FederalDistrict — федеральный округ и коды входящих в него регионов
+type FederalDistrict struct {
+	ID        int    `json:"id"`
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	RegionIDs []int  `json:"region_ids"`
+}
@@ -1,11 +1,79 @@
 package model
 
+import "time"
+
 type File struct {
-	Filename   string `json:"filename"`
-	Size       int64  `json:"size"`
-	Metadata   []byte `json:"metadata"`
-	AuthorID   string `json:"author_id"`
-	Status     string `json:"status"`
-	ValidCount int    `json:"valid_count"`
-	ErrorCount int    `json:"error_count"`
+	Filename       string     `json:"filename"`
+	Size           int64      `json:"size"`
+	Metadata       []byte     `json:"metadata"`
+	SHA256         string     `json:"sha256"` // Ключ файла: повторная загрузка того же содержимого обновляет запись
+	AuthorID       string     `json:"author_id"`
+	Status         string     `json:"status"`
+	ValidCount     int        `json:"valid_count"`
+	ErrorCount     int        `json:"error_count"`
+	DuplicateCount int        `json:"duplicate_count"`
+	ConflictCount  int        `json:"conflict_count"`
+	ConflictPolicy string     `json:"conflict_policy"`
+	ModifiedAt     *time.Time `json:"modified_at,omitempty"` // Время изменения исходного файла; nil — неизвестно
+}
+
+// RowRef указывает на строку загружаемого файла
+type RowRef struct {
+	Sheet string `json:"sheet"`
+	Row   int    `json:"row"`
+	SID   string `json:"sid,omitempty"`
+}
+
+type RowError struct {
+	Sheet    string   `json:"sheet"`
+	Row      int      `json:"row"`
+	SID      string   `json:"sid,omitempty"`
+	Errors   []string `json:"errors"`             // Причины отклонения строки
+	Warnings []string `json:"warnings,omitempty"` // Ошибки разбора SHR
+}
+
+// ValidationReport — результат пробной загрузки файла без записи в БД
+type ValidationReport struct {
+	Filename       string          `json:"filename"`
+	TotalRows      int             `json:"total_rows"`
+	ValidCount     int             `json:"valid_count"`     // Строки, прошедшие проверку
+	NewCount       int             `json:"new_count"`       // Из них отсутствующие в messages
+	ErrorCount     int             `json:"error_count"`     // Отклоненные строки и нечитаемые листы
+	RowErrors      []RowError      `json:"row_errors"`      // Ошибки по строкам
+	FileDuplicates []RowRef        `json:"file_duplicates"` // Повторы внутри файла
+	ExistingInDB   []RowRef        `json:"existing_in_db"`  // Полеты, уже загруженные ранее
+	Preview        []ParsedMessage `json:"preview"`         // Первые N нормализованных полетов
+}
+
+const (
+	ConflictSkip       = "skip"        // Оставить сохраненный полет
+	ConflictOverwrite  = "overwrite"   // Заменить сохраненный полет входящим
+	ConflictKeepNewest = "keep-newest" // Оставить полет из файла, измененного позже
+)
+
+// FieldDiff — расхождение значения поля между сохраненным и входящим полетом
+type FieldDiff struct {
+	Field    string `json:"field"`
+	Stored   string `json:"stored"`
+	Incoming string `json:"incoming"`
+}
+
+// Conflict — входящий полет с SID, уже сохраненным с другими значениями полей
+type Conflict struct {
+	SID            string      `json:"sid"`
+	Sheet          string      `json:"sheet,omitempty"`
+	Row            int         `json:"row,omitempty"`
+	ExistingFileID int         `json:"existing_file_id,omitempty"`
+	Fields         []FieldDiff `json:"fields"`
+	Policy         string      `json:"policy"`
+	Resolution     string      `json:"resolution"` // kept или overwritten
+}
+
+// ProcessReport — итог загрузки файла
+type ProcessReport struct {
+	ValidCount     int        `json:"valid_count"`
+	ErrorCount     int        `json:"error_count"`
+	DuplicateCount int        `json:"duplicate_count"` // Полеты, уже сохраненные без изменений
+	ConflictCount  int        `json:"conflict_count"`  // Полеты с расхождениями полей
+	Conflicts      []Conflict `json:"conflicts"`
 }
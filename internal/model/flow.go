@@ -0,0 +1,30 @@
+package model
+
+// RegionFlow — полеты из региона вылета в регион посадки за период
+type RegionFlow struct {
+	FromRegionID    int     `json:"from_region_id"`
+	FromRegionName  string  `json:"from_region_name"`
+	ToRegionID      int     `json:"to_region_id"`
+	ToRegionName    string  `json:"to_region_name"`
+	Flights         int     `json:"flights"`
+	TotalDistanceKm float64 `json:"total_distance_km"` // Сумма расстояний по прямой от вылета до посадки
+	AvgDistanceKm   float64 `json:"avg_distance_km"`
+}
+
+// FlowFilter — отбор полетов для матрицы отправления–назначения
+type FlowFilter struct {
+	Period   Period
+	RegionID int  // Только потоки из региона или в регион; 0 — все
+	Internal bool // Включать полеты с посадкой в регионе вылета
+}
+
+// FlowMatrix — матрица отправления–назначения между регионами по вылетам периода
+// (дата вылета — по местному времени региона вылета)
+type FlowMatrix struct {
+	Period        Period       `json:"period"`
+	RegionID      int          `json:"region_id"`
+	Flows         []RegionFlow `json:"flows"`
+	InterRegional int          `json:"inter_regional"` // Полетов с посадкой в другом регионе
+	Internal      int          `json:"internal"`       // Полетов с посадкой в регионе вылета
+	Unresolved    int          `json:"unresolved"`     // Полетов без точки посадки или с посадкой вне регионов
+}
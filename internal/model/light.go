@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// FlightDeparture — точка и момент вылета для расчета освещенности
+type FlightDeparture struct {
+	ID   int
+	Time time.Time // Дата и время вылета в UTC
+	Lat  float64
+	Lon  float64
+}
+
+// SetLightConditions заполняет метрики освещенности и долю ночных полетов
+func (m *Metrics) SetLightConditions(day, twilight, dark int) {
+	m.DaylightFlights, m.TwilightFlights, m.DarkFlights = day, twilight, dark
+	m.NightShare = 0
+	if total := day + twilight + dark; total > 0 {
+		m.NightShare = float64(dark) / float64(total)
+	}
+}
@@ -1,6 +1,12 @@
 package model
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound — запрошенная запись отсутствует
+var ErrNotFound = errors.New("not found")
 
 type Metrics struct {
 	RegionId           int
@@ -17,7 +23,197 @@ type Metrics struct {
 	AvgDailyFlights    float64
 	MedianDailyFlights float64
 
-	ZeroFlightDays     []time.Time
-	Year               int
-	TotalDistance      float64
+	ZeroFlightDays []time.Time
+	Year           int
+	TotalDistance  float64
+	TimeZone       string // Зона, в которой считались часы, дни и месяцы
+
+	// Освещенность в точке и момент вылета по положению Солнца (в отличие от NightFlights — часы 0..5)
+	DaylightFlights int
+	TwilightFlights int     // Гражданские сумерки
+	DarkFlights     int     // Ночь: Солнце ниже 6° под горизонтом
+	NightShare      float64 // Доля ночных полетов среди классифицированных
+}
+
+// ReferenceTimeZone — опорная зона общероссийских метрик; региональные считаются в зоне региона
+const ReferenceTimeZone = "Europe/Moscow"
+
+// MetricsPartition — регион/год/месяц, затронутый изменением messages и ожидающий пересчета.
+// RegionID = 0 — полеты вне границ регионов (влияют только на общероссийские метрики).
+type MetricsPartition struct {
+	RegionID int       `json:"region_id"`
+	Year     int       `json:"year"`
+	Month    int       `json:"month"`
+	Reason   string    `json:"reason"`
+	MarkedAt time.Time `json:"marked_at"`
+	FileIDs  []int     `json:"file_ids"` // Файлы, изменения из которых попали в партицию
+}
+
+const (
+	RefreshFull        = "full"        // Пересчет всех регионов и годов
+	RefreshIncremental = "incremental" // Пересчет только помеченных партиций
+)
+
+const (
+	RefreshStatusRunning  = "running"
+	RefreshStatusSuccess  = "success"
+	RefreshStatusPartial  = "partial" // Часть регионов/годов не пересчитана
+	RefreshStatusFailed   = "failed"
+	RefreshStatusCanceled = "canceled"
+)
+
+// RefreshFailure — регион/год, метрики которого не удалось пересчитать.
+// RegionID = 0 — общероссийские метрики.
+type RefreshFailure struct {
+	RegionID   int    `json:"region_id"`
+	RegionName string `json:"region_name"`
+	Year       int    `json:"year"`
+	Error      string `json:"error"`
+}
+
+// RefreshRun — запись о запуске пересчета метрик
+type RefreshRun struct {
+	ID         int              `json:"id"`
+	Kind       string           `json:"kind"`
+	Status     string           `json:"status"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+	DurationMs int64            `json:"duration_ms"`
+	Total      int              `json:"total"`  // Всего задач регион/год
+	Failed     int              `json:"failed"` // Задач с ошибкой
+	Failures   []RefreshFailure `json:"failures"`
+	FileIDs    []int            `json:"file_ids"` // Файлы, изменения из которых учтены пересчетом
+}
+
+// MetricsSnapshot — версия метрик региона за год, сохраненная пересчетом RunID
+type MetricsSnapshot struct {
+	RunID     int       `json:"run_id"`
+	RunKind   string    `json:"run_kind"`
+	FileIDs   []int     `json:"file_ids"`
+	CreatedAt time.Time `json:"created_at"`
+	Metrics   Metrics   `json:"metrics"`
+}
+
+// MetricFieldDiff — изменение числового показателя между двумя версиями или периодами.
+// DeltaPct отсутствует, если исходное значение равно нулю.
+type MetricFieldDiff struct {
+	Field    string   `json:"field"`
+	From     float64  `json:"from"`
+	To       float64  `json:"to"`
+	Delta    float64  `json:"delta"`
+	DeltaPct *float64 `json:"delta_pct,omitempty"`
+}
+
+// MetricsDiff — изменения метрик региона за год между версиями на момент двух пересчетов
+type MetricsDiff struct {
+	RegionID        int               `json:"region_id"`
+	Year            int               `json:"year"`
+	From            MetricsSnapshot   `json:"from"`
+	To              MetricsSnapshot   `json:"to"`
+	Fields          []MetricFieldDiff `json:"fields"`            // Только изменившиеся показатели
+	ZeroDaysAdded   []time.Time       `json:"zero_days_added"`   // Дни, ставшие днями без полетов
+	ZeroDaysRemoved []time.Time       `json:"zero_days_removed"` // Дни, в которые появились полеты
+}
+
+// MetricsFilter — выборка полетов для расчета метрик за произвольный период
+type MetricsFilter struct {
+	RegionIDs []int     // Коды регионов; 0 — вся РФ
+	From      time.Time // Первый день периода (включительно)
+	To        time.Time // Последний день периода (включительно)
+
+	IncludeImplausible bool // Учитывать полеты, отмеченные как кинематически неправдоподобные
+
+	Area *AreaScope // Произвольная область; ее метрики — под ключом AreaScopeID
+}
+
+// Period — период сравнения: год, квартал или произвольный диапазон дат
+type Period struct {
+	Label string    `json:"label"`
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+}
+
+// RegionComparison — метрики региона за два периода и изменения по каждому показателю
+type RegionComparison struct {
+	RegionID   int               `json:"region_id"`
+	RegionName string            `json:"region_name"`
+	A          Metrics           `json:"a"`
+	B          Metrics           `json:"b"`
+	Fields     []MetricFieldDiff `json:"fields"` // Все показатели: from — период A, to — период B
+}
+
+type MetricsComparison struct {
+	A       Period             `json:"a"`
+	B       Period             `json:"b"`
+	Regions []RegionComparison `json:"regions"`
+}
+
+// MetricRank — положение региона по одному показателю среди всех регионов и внутри округа.
+// Ранг 1 — наибольшее значение; Percentile — доля регионов с меньшим значением.
+type MetricRank struct {
+	Field        string  `json:"field"`
+	Value        float64 `json:"value"`
+	Rank         int     `json:"rank"`
+	Of           int     `json:"of"`
+	Percentile   float64 `json:"percentile"`
+	ZScore       float64 `json:"z_score"`
+	DistrictRank int     `json:"district_rank"`
+	DistrictOf   int     `json:"district_of"`
+}
+
+type RegionRank struct {
+	RegionID        int          `json:"region_id"`
+	RegionName      string       `json:"region_name"`
+	FederalDistrict string       `json:"federal_district"`
+	Ranks           []MetricRank `json:"ranks"`
+}
+
+type MetricsRanking struct {
+	Period    Period            `json:"period"`
+	Regions   []RegionRank      `json:"regions"`
+	Districts []FederalDistrict `json:"districts"`
+}
+
+// ActivityMatrix — активность по часу суток и дню недели в местном времени.
+// Строки — дни недели с понедельника (0) по воскресенье (6), столбцы — часы 0..23.
+type ActivityMatrix struct {
+	RegionID   int            `json:"region_id"`
+	RegionName string         `json:"region_name"`
+	Period     Period         `json:"period"`
+	TimeZone   string         `json:"time_zone"`  // Зона региона; для всей РФ — ReferenceTimeZone
+	Starts     [7][24]int     `json:"starts"`     // Вылетов, начавшихся в этот час
+	Active     [7][24]int     `json:"active"`     // Полетов, выполнявшихся в этот час (сумма за период)
+	ActiveAvg  [7][24]float64 `json:"active_avg"` // Среднее число полетов, выполнявшихся в этот час недели
+}
+
+// FlightInterval — полет от вылета до посадки в местном времени (часы на стене, без зоны)
+type FlightInterval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// OccupancyPoint — число полетов в воздухе начиная с момента Time и до следующей точки
+type OccupancyPoint struct {
+	Time  time.Time `json:"time"`
+	Count int       `json:"count"`
+}
+
+// DayOccupancy — одновременная загрузка воздушного пространства за местные сутки
+type DayOccupancy struct {
+	Date          string           `json:"date"`
+	Flights       int              `json:"flights"`        // Полетов, находившихся в воздухе в эти сутки
+	MaxConcurrent int              `json:"max_concurrent"` // Максимум одновременно выполняемых полетов
+	PeakAt        time.Time        `json:"peak_at"`        // Первый момент, когда достигнут максимум
+	Curve         []OccupancyPoint `json:"curve"`          // Ступенчатая кривая, начинается с полуночи
+}
+
+// Occupancy — одновременная загрузка региона по дням периода по интервалам ATD–ATA
+type Occupancy struct {
+	RegionID      int            `json:"region_id"`
+	RegionName    string         `json:"region_name"`
+	Period        Period         `json:"period"`
+	TimeZone      string         `json:"time_zone"`
+	MaxConcurrent int            `json:"max_concurrent"` // Максимум за период
+	PeakAt        *time.Time     `json:"peak_at"`
+	Days          []DayOccupancy `json:"days"`
 }
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrOperatorExists — оператор с таким ключом уже заведен
+var ErrOperatorExists = errors.New("operator already exists")
+
+// OperatorAlias — исходное написание OPR/ с результатом разбора и сопоставления
+type OperatorAlias struct {
+	Raw           string
+	OperatorID    int    // Найденный оператор; 0 — оператор заводится или находится по Key
+	Key           string // Пусто при OperatorID = 0 — оператор не распознан
+	Name          string
+	Phones        []string
+	Organizations []string
+	Method        string  // exact, phone, fuzzy, manual или new
+	Score         float64 // Сходство при нечетком совпадении
+}
+
+// OperatorKey — ключ и телефоны оператора для сопоставления новых написаний.
+// У объединенного оператора ID — оператор, в который он влит.
+type OperatorKey struct {
+	ID     int
+	Key    string
+	Phones []string
+}
+
+// OperatorMerge — объединение операторов: Sources вливаются в Target
+type OperatorMerge struct {
+	Target  int   `json:"target_id"`
+	Sources []int `json:"source_ids"`
+}
+
+// OperatorSplit — отделение написаний OPR/ оператора в нового оператора
+type OperatorSplit struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+// Operator — оператор БВС, сведенный из написаний поля OPR/
+type Operator struct {
+	ID            int                 `json:"id"`
+	Name          string              `json:"name"`
+	Phones        []string            `json:"phones"`
+	Organizations []string            `json:"organizations"`
+	Registrations []string            `json:"registrations,omitempty"` // Известные регистрационные номера БВС
+	Aliases       []OperatorAliasInfo `json:"aliases,omitempty"`       // Исходные написания OPR/
+}
+
+// OperatorAliasInfo — написание OPR/ и способ, которым оно отнесено к оператору
+type OperatorAliasInfo struct {
+	Raw     string  `json:"raw"`
+	Method  string  `json:"method"`
+	Score   float64 `json:"score"`
+	Flights int     `json:"flights"`
+}
+
+// OperatorStats — показатели оператора за период
+type OperatorStats struct {
+	Operator
+	Flights            int        `json:"flights"`
+	Regions            int        `json:"regions"`     // Регионов вылета
+	DistanceKm         float64    `json:"distance_km"` // Сумма расстояний по прямой вылет–посадка
+	AvgDurationMinutes float64    `json:"avg_duration_minutes"`
+	MedianMinAlt       float64    `json:"median_min_alt"` // Типичный диапазон высот, м
+	MedianMaxAlt       float64    `json:"median_max_alt"`
+	Hours              []int      `json:"hours"` // Вылеты по часам местного времени
+	FirstFlight        *time.Time `json:"first_flight"`
+	LastFlight         *time.Time `json:"last_flight"`
+}
+
+// OperatorRegion — полеты оператора в регионе
+type OperatorRegion struct {
+	RegionID   int    `json:"region_id"`
+	RegionName string `json:"region_name"`
+	Flights    int    `json:"flights"`
+}
+
+// OperatorProfile — карточка оператора: показатели, регионы и типы БВС
+type OperatorProfile struct {
+	OperatorStats
+	Period     Period           `json:"period"`
+	RegionList []OperatorRegion `json:"region_list"`
+	Types      map[string]int   `json:"types"` // Полеты по типу (TYP)
+}
+
+// OperatorFilter — отбор операторов; Period — по дате вылета в местном времени региона
+type OperatorFilter struct {
+	Period   Period
+	RegionID int    // 0 — все регионы
+	Query    string // Подстрока имени или цифры телефона
+	Order    string // flights, distance или regions
+	Limit    int
+	Offset   int
+}
+
+// OperatorFlight — полет оператора
+type OperatorFlight struct {
+	MessageID  int       `json:"message_id"`
+	SID        string    `json:"sid"`
+	DOF        time.Time `json:"dof"`
+	ATD        string    `json:"atd"`
+	ATA        string    `json:"ata"`
+	RegionID   int       `json:"region_id"`
+	RegionName string    `json:"region_name"`
+	OPR        string    `json:"opr"`
+	REG        string    `json:"reg"`
+	TYP        string    `json:"typ"`
+	MinAlt     int       `json:"min_alt"`
+	MaxAlt     int       `json:"max_alt"`
+	DistanceKm *float64  `json:"distance_km"`
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"time"
+
+	"github.com/paulmach/orb"
+)
+
+const (
+	ReasonATABeforeATD    = "ata_before_atd"   // Посадка раньше вылета, и переход через полночь дает неправдоподобную длительность
+	ReasonZeroDuration    = "zero_duration"    // Посадка в ту же минуту, что и вылет
+	ReasonImpossibleSpeed = "impossible_speed" // Скорость по прямой вылет–посадка выше возможной для БВС
+)
+
+// FlightKinematics — данные полета для проверки правдоподобности
+type FlightKinematics struct {
+	ID        int
+	DepLatLon orb.Point
+	ArrLatLon *orb.Point // nil — точка посадки не указана
+	ATD       string     // чч:мм
+	ATA       string     // чч:мм, пусто — нет посадки
+}
+
+// Plausibility — результат проверки полета
+type Plausibility struct {
+	ID              int
+	Reasons         []string
+	ImpliedSpeedKmh *float64
+}
+
+// ImplausibleFlight — полет, исключенный из метрик как неправдоподобный
+type ImplausibleFlight struct {
+	MessageID       int       `json:"message_id"`
+	SID             string    `json:"sid"`
+	DOF             time.Time `json:"dof"`
+	ATD             string    `json:"atd"`
+	ATA             string    `json:"ata"`
+	RegionID        int       `json:"region_id"`
+	RegionName      string    `json:"region_name"`
+	Operator        string    `json:"operator"`
+	FileID          *int      `json:"file_id"`
+	Reasons         []string  `json:"reasons"`
+	ImpliedSpeedKmh *float64  `json:"implied_speed_kmh"`
+}
+
+// QualityFilter — отбор неправдоподобных полетов по дате вылета в местном времени региона
+type QualityFilter struct {
+	Period   Period
+	RegionID int    // 0 — все регионы
+	Reason   string // Пусто — любая причина
+	Limit    int
+	Offset   int
+}
+
+// QualityReport — качество данных за период: сколько полетов исключено из метрик и почему
+type QualityReport struct {
+	Period      Period              `json:"period"`
+	RegionID    int                 `json:"region_id"`
+	Flights     int                 `json:"flights"`     // Всего полетов
+	Implausible int                 `json:"implausible"` // Исключено из метрик
+	Pending     int                 `json:"pending"`     // Еще не проверено
+	ByReason    map[string]int      `json:"by_reason"`
+	Items       []ImplausibleFlight `json:"items"`
+}
+
+const (
+	AttributionContains = "contains" // Точка вылета внутри полигона региона
+	AttributionNearest  = "nearest"  // Ближайший регион в пределах допуска
+)
+
+// RegionPair — сочетание региона из таблицы и региона по геометрии с числом полетов
+type RegionPair struct {
+	SourceRegion string `json:"source_region"` // Пусто — регион в таблице не указан
+	RegionID     int    `json:"region_id"`     // 0 — регион по геометрии не определен
+	RegionName   string `json:"region_name"`
+	Flights      int    `json:"flights"`
+	Nearest      int    `json:"nearest"` // Из них отнесены к ближайшему региону
+	Matches      bool   `json:"matches"` // Названия совпадают по основе
+}
+
+// RegionAttributionReport — сверка региона из таблицы с регионом по точке вылета за период
+type RegionAttributionReport struct {
+	Period       Period       `json:"period"`
+	Flights      int          `json:"flights"`
+	Contains     int          `json:"contains"`     // Точка вылета внутри полигона
+	Nearest      int          `json:"nearest"`      // Отнесены к ближайшему региону
+	Unattributed int          `json:"unattributed"` // Регион не определен, полет не попадает в метрики регионов
+	Mismatched   int          `json:"mismatched"`   // Регион из таблицы не совпадает с регионом по геометрии
+	Pairs        []RegionPair `json:"pairs"`        // Сначала несовпадающие, по убыванию числа полетов
+}
+
+// AttributionFilter — отбор полетов для сверки регионов: kind — mismatch, nearest или unattributed
+type AttributionFilter struct {
+	Period Period
+	Kind   string
+	Limit  int
+	Offset int
+}
+
+// AttributedFlight — полет с регионом из таблицы и регионом по геометрии
+type AttributedFlight struct {
+	MessageID    int       `json:"message_id"`
+	SID          string    `json:"sid"`
+	DOF          time.Time `json:"dof"`
+	ATD          string    `json:"atd"`
+	SourceRegion string    `json:"source_region"`
+	RegionID     int       `json:"region_id"`
+	RegionName   string    `json:"region_name"`
+	Method       string    `json:"method"`
+	DistanceM    *float64  `json:"distance_m"` // Расстояние до ближайшего региона
+	Lat          float64   `json:"lat"`
+	Lon          float64   `json:"lon"`
+	FileID       *int      `json:"file_id"`
+}
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// LaunchSite — место регулярных запусков: кластер точек вылета
+type LaunchSite struct {
+	ID           int       `json:"id"`
+	RegionID     int       `json:"region_id"`
+	RegionName   string    `json:"region_name"`
+	Lat          float64   `json:"lat"` // Центр кластера
+	Lon          float64   `json:"lon"`
+	Flights      int       `json:"flights"`
+	Operators    int       `json:"operators"`     // Различных операторов
+	TopOperators []string  `json:"top_operators"` // До пяти самых частых
+	FirstFlight  time.Time `json:"first_flight"`
+	LastFlight   time.Time `json:"last_flight"`
+	ActiveDays   int       `json:"active_days"`
+	Hours        []int     `json:"hours"` // Вылеты по часам местного времени
+	PeakHour     int       `json:"peak_hour"`
+	AreaKm2      float64   `json:"area_km2"`
+	DetectedAt   time.Time `json:"detected_at"`
+}
+
+// SetPeakHour определяет час с наибольшим числом вылетов
+func (s *LaunchSite) SetPeakHour() {
+	for h, n := range s.Hours {
+		if n > s.Hours[s.PeakHour] {
+			s.PeakHour = h
+		}
+	}
+}
+
+// LaunchSiteFilter — отбор и порядок мест запуска
+type LaunchSiteFilter struct {
+	RegionID   int    // 0 — все регионы
+	MinFlights int    // 0 — без ограничения
+	Order      string // flights, operators или active_days
+	Limit      int
+	Offset     int
+}
+
+// LaunchSiteJob — состояние кластеризации точек вылета
+type LaunchSiteJob struct {
+	Running    bool       `json:"running"`
+	Radius     float64    `json:"radius_m"`
+	MinFlights int        `json:"min_flights"`
+	Sites      int        `json:"sites"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+}
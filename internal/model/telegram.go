@@ -0,0 +1,46 @@
+package model
+
+const (
+	TelegramSHR = "SHR" // План полета
+	TelegramDEP = "DEP" // Фактический вылет (DEP / IDEP)
+	TelegramARR = "ARR" // Фактическая посадка (ARR / IARR)
+)
+
+const (
+	TelegramStatusSaved     = "saved"     // SHR сохранен в messages
+	TelegramStatusDuplicate = "duplicate" // SHR уже сохранен с теми же полями
+	TelegramStatusConflict  = "conflict"  // SHR расходится с сохраненным, см. Conflict.Resolution
+	TelegramStatusMatched   = "matched"   // DEP/ARR сопоставлен с SHR
+	TelegramStatusPending   = "pending"   // DEP/ARR ожидает появления SHR
+	TelegramStatusFailed    = "failed"    // Ошибка разбора или сохранения
+)
+
+type Telegram struct {
+	Type    string         `json:"type"`              // SHR, DEP или ARR
+	Raw     string         `json:"raw"`               // Исходный текст телеграммы
+	SID     string         `json:"sid"`               // Идентификатор полета
+	DOF     string         `json:"dof,omitempty"`     // Дата YYYY-MM-DD, для IARR — дата посадки (ADA)
+	REG     string         `json:"reg,omitempty"`     // Регистрация
+	Time    string         `json:"time,omitempty"`    // ATD для DEP, ATA для ARR (чч:мм)
+	Message *ParsedMessage `json:"message,omitempty"` // Разобранный план для SHR
+}
+
+type TelegramResult struct {
+	Index    int       `json:"index"`
+	Type     string    `json:"type,omitempty"`
+	SID      string    `json:"sid,omitempty"`
+	Status   string    `json:"status"`
+	Conflict *Conflict `json:"conflict,omitempty"` // Расхождения SHR с сохраненным полетом
+	Error    string    `json:"error,omitempty"`
+}
+
+type IngestReport struct {
+	Received   int              `json:"received"`
+	Saved      int              `json:"saved"`      // Новые SHR и SHR, заменившие сохраненные
+	Duplicates int              `json:"duplicates"` // SHR без изменений
+	Conflicts  int              `json:"conflicts"`  // SHR с расхождениями полей
+	Matched    int              `json:"matched"`
+	Pending    int              `json:"pending"`
+	Failed     int              `json:"failed"`
+	Results    []TelegramResult `json:"results"`
+}
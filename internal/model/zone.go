@@ -0,0 +1,92 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/paulmach/orb"
+)
+
+const (
+	ZoneNoFly      = "no_fly"     // Полеты запрещены
+	ZoneRestricted = "restricted" // Полеты ограничены
+)
+
+const (
+	ViolationDeparture = "departure" // Точка вылета внутри зоны
+	ViolationZone      = "zone"      // Зона полета ZONA пересекает зону
+	ViolationRoute     = "route"     // Прямая вылет–посадка пересекает зону
+)
+
+// RestrictedZone — запретная зона или зона ограничения. Высоты в метрах,
+// окно и период действия — в UTC, как время телеграмм.
+type RestrictedZone struct {
+	ID         int             `json:"id"`
+	Name       string          `json:"name"`
+	Kind       string          `json:"kind"`
+	MinAlt     *int            `json:"min_alt"`     // nil — от земли
+	MaxAlt     *int            `json:"max_alt"`     // nil — без верхней границы
+	ActiveFrom *string         `json:"active_from"` // ЧЧ:ММ, nil — круглосуточно
+	ActiveTo   *string         `json:"active_to"`
+	ValidFrom  *time.Time      `json:"valid_from"` // nil — бессрочно
+	ValidTo    *time.Time      `json:"valid_to"`
+	Source     string          `json:"source"`
+	Properties map[string]any  `json:"properties"`
+	Geometry   orb.Geometry    `json:"-"`
+	GeoJSON    json.RawMessage `json:"geometry,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Violations int             `json:"violations"` // Полетов с нарушением зоны
+}
+
+// ZoneImport — итог загрузки файла зон
+type ZoneImport struct {
+	Source     string `json:"source"`
+	Zones      []int  `json:"zones"`      // id загруженных зон
+	Violations int    `json:"violations"` // Найдено нарушений по новым зонам
+}
+
+// Violation — нарушение зоны полетом
+type Violation struct {
+	MessageID  int       `json:"message_id"`
+	SID        string    `json:"sid"`
+	DOF        time.Time `json:"dof"`
+	ATD        string    `json:"atd"`
+	ATA        string    `json:"ata"`
+	RegionID   int       `json:"region_id"`
+	RegionName string    `json:"region_name"`
+	Operator   string    `json:"operator"`
+	MinAlt     int       `json:"min_alt"`
+	MaxAlt     int       `json:"max_alt"`
+	ZoneID     int       `json:"zone_id"`
+	ZoneName   string    `json:"zone_name"`
+	ZoneKind   string    `json:"zone_kind"`
+	Kinds      []string  `json:"kinds"` // Чем полет пересек зону: departure, zone, route
+}
+
+// ViolationFilter — отбор нарушений по дате вылета в местном времени региона
+type ViolationFilter struct {
+	Period   Period
+	RegionID int    // 0 — все регионы
+	ZoneID   int    // 0 — все зоны
+	Operator string // Подстрока OPR, без учета регистра
+	Limit    int
+	Offset   int
+}
+
+// ViolationCount — число нарушений по региону или оператору
+type ViolationCount struct {
+	Key        string `json:"key"` // Код региона или оператор
+	Name       string `json:"name"`
+	Flights    int    `json:"flights"`    // Полетов хотя бы с одним нарушением
+	NoFly      int    `json:"no_fly"`     // Из них в запретных зонах
+	Violations int    `json:"violations"` // Пар полет–зона
+	Zones      int    `json:"zones"`      // Различных нарушенных зон
+}
+
+// ViolationStats — нарушения за период с группировкой по региону или оператору
+type ViolationStats struct {
+	Period Period           `json:"period"`
+	Group  string           `json:"group"`
+	Total  int              `json:"total"` // Полетов с нарушениями
+	Items  []ViolationCount `json:"items"`
+}
@@ -0,0 +1,140 @@
+package operator
+
+import (
+	"slices"
+	"unicode/utf8"
+)
+
+const (
+	MethodExact  = "exact"  // Совпал ключ
+	MethodPhone  = "phone"  // Совпал телефон
+	MethodFuzzy  = "fuzzy"  // Ключи близки по расстоянию Левенштейна
+	MethodManual = "manual" // Назначено вручную (объединение или разделение)
+	MethodNew    = "new"    // Заведен новый оператор
+)
+
+const (
+	// FuzzyThreshold — наименьшее сходство ключей для нечеткого совпадения
+	FuzzyThreshold = 0.88
+	// fuzzyMinLength — ключи короче сопоставляются только точно: в них одна опечатка
+	// уже меняет смысл («ПЕТРОВ» и «ПЕТРОВА»)
+	fuzzyMinLength = 8
+)
+
+// Candidate — известный оператор, с которым сопоставляется новое написание
+type Candidate struct {
+	ID     int
+	Key    string
+	Phones []string
+}
+
+// Match — результат сопоставления: ID оператора (0 — не найден), способ и сходство
+type Match struct {
+	ID     int
+	Method string
+	Score  float64
+}
+
+// Index ищет оператора для разобранного написания среди известных
+type Index struct {
+	byKey   map[string]int
+	byPhone map[string]int
+	list    []Candidate
+}
+
+func NewIndex(candidates []Candidate) *Index {
+	idx := &Index{byKey: make(map[string]int), byPhone: make(map[string]int)}
+	for _, c := range candidates {
+		idx.Add(c)
+	}
+	return idx
+}
+
+// Add добавляет оператора в индекс; первый добавленный оператор с ключом или телефоном
+// остается владельцем этого ключа или телефона
+func (idx *Index) Add(c Candidate) {
+	if _, ok := idx.byKey[c.Key]; !ok {
+		idx.byKey[c.Key] = c.ID
+	}
+	for _, ph := range c.Phones {
+		if _, ok := idx.byPhone[ph]; !ok {
+			idx.byPhone[ph] = c.ID
+		}
+	}
+	idx.list = append(idx.list, c)
+}
+
+// Find сопоставляет написание: точный ключ, затем телефон, затем ближайший ключ
+// со сходством не ниже FuzzyThreshold. Нечеткое совпадение отклоняется, если у обоих
+// операторов есть телефоны и ни один не совпадает.
+func (idx *Index) Find(p Parsed) Match {
+	if id, ok := idx.byKey[p.Key]; ok {
+		return Match{ID: id, Method: MethodExact, Score: 1}
+	}
+	for _, ph := range p.Phones {
+		if id, ok := idx.byPhone[ph]; ok {
+			return Match{ID: id, Method: MethodPhone, Score: 1}
+		}
+	}
+	n := utf8.RuneCountInString(p.Key)
+	if n < fuzzyMinLength {
+		return Match{}
+	}
+	best := Match{}
+	for _, c := range idx.list {
+		m := utf8.RuneCountInString(c.Key)
+		// при разнице длин больше допустимой сходство заведомо ниже порога
+		if m < fuzzyMinLength || float64(abs(n-m)) > (1-FuzzyThreshold)*float64(max(n, m)) {
+			continue
+		}
+		if len(p.Phones) > 0 && len(c.Phones) > 0 && !sharesPhone(p.Phones, c.Phones) {
+			continue
+		}
+		if s := Similarity(p.Key, c.Key); s >= FuzzyThreshold && s > best.Score {
+			best = Match{ID: c.ID, Method: MethodFuzzy, Score: s}
+		}
+	}
+	return best
+}
+
+// Similarity — сходство строк от 0 до 1: единица минус расстояние Левенштейна по символам,
+// деленное на длину большей строки
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+func sharesPhone(a, b []string) bool {
+	for _, ph := range a {
+		if slices.Contains(b, ph) {
+			return true
+		}
+	}
+	return false
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,111 @@
+package operator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"РОМАШКА", "РОМАШКА", 1},
+		{"РОМАШКА", "", 0},
+		{"РОМАШКА", "РОМАШКИ", 1 - 1.0/7},
+		{"РОМАШКА", "РОМАШК", 1 - 1.0/7},
+		{"KITTEN", "SITTING", 1 - 3.0/7},
+		// расстояние считается по символам, а не по байтам
+		{"ЁЖ", "ЕЖ", 0.5},
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := Similarity(tt.b, tt.a); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestIndexFind(t *testing.T) {
+	idx := NewIndex([]Candidate{
+		{ID: 1, Key: "РОМАШКА СЕРВИС", Phones: []string{"79123456789"}},
+		{ID: 2, Key: "АЭРОФОТОСЪЕМКА"},
+		{ID: 3, Key: "АБВГДЕЖЗ"},
+		{ID: 4, Key: "КЛМНОПРСТ"},
+		{ID: 5, Key: "ПЕТРОВ"},
+		// второй оператор с тем же ключом и телефоном не перехватывает их
+		{ID: 6, Key: "РОМАШКА СЕРВИС", Phones: []string{"79123456789"}},
+	})
+	tests := []struct {
+		name string
+		in   Parsed
+		want Match
+	}{
+		{
+			name: "exact key",
+			in:   Parsed{Key: "РОМАШКА СЕРВИС"},
+			want: Match{ID: 1, Method: MethodExact, Score: 1},
+		},
+		{
+			name: "exact key wins over other phone",
+			in:   Parsed{Key: "АЭРОФОТОСЪЕМКА", Phones: []string{"79123456789"}},
+			want: Match{ID: 2, Method: MethodExact, Score: 1},
+		},
+		{
+			name: "phone",
+			in:   Parsed{Key: "ДРУГОЕ НАЗВАНИЕ", Phones: []string{"79000000000", "79123456789"}},
+			want: Match{ID: 1, Method: MethodPhone, Score: 1},
+		},
+		{
+			name: "fuzzy without phones",
+			in:   Parsed{Key: "АЭРОФОТОСЕМКА"},
+			want: Match{ID: 2, Method: MethodFuzzy, Score: 1 - 1.0/14},
+		},
+		{
+			name: "fuzzy when only one side has phones",
+			in:   Parsed{Key: "РОМАШКА СЕРВИЗ"},
+			want: Match{ID: 1, Method: MethodFuzzy, Score: 1 - 1.0/14},
+		},
+		{
+			name: "different phones veto fuzzy match",
+			in:   Parsed{Key: "РОМАШКА СЕРВИЗ", Phones: []string{"79990000000"}},
+			want: Match{},
+		},
+		{
+			name: "one typo in nine letters reaches threshold",
+			in:   Parsed{Key: "КЛМНОПРСУ"},
+			want: Match{ID: 4, Method: MethodFuzzy, Score: 1 - 1.0/9},
+		},
+		{
+			name: "one typo in eight letters is below threshold",
+			in:   Parsed{Key: "АБВГДЕЖК"},
+			want: Match{},
+		},
+		{
+			name: "key shorter than fuzzyMinLength matches only exactly",
+			in:   Parsed{Key: "ПЕТРОВА"},
+			want: Match{},
+		},
+		{
+			name: "candidate shorter than fuzzyMinLength is skipped",
+			in:   Parsed{Key: "ПЕТРОВ ИВ"},
+			want: Match{},
+		},
+		{
+			name: "unknown",
+			in:   Parsed{Key: "СОВСЕМ ДРУГОЙ ОПЕРАТОР"},
+			want: Match{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := idx.Find(tt.in)
+			if got.ID != tt.want.ID || got.Method != tt.want.Method || math.Abs(got.Score-tt.want.Score) > 1e-9 {
+				t.Errorf("Find(%+v) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package operator
+
+import (
+	"strings"
+	"unicode"
+)
+
+// homoglyphs — латинские буквы, неотличимые от кириллических в верхнем регистре
+var homoglyphs = map[rune]rune{
+	'A': 'А', 'B': 'В', 'C': 'С', 'E': 'Е', 'H': 'Н', 'K': 'К', 'M': 'М',
+	'O': 'О', 'P': 'Р', 'T': 'Т', 'X': 'Х', 'Y': 'У',
+}
+
+// translit — обратная транслитерация латиницы (ГОСТ 7.79-2000, схема Б, и паспортная);
+// многобуквенные сочетания проверяются раньше одиночных букв
+var translit = []struct{ lat, cyr string }{
+	{"SHCH", "Щ"}, {"SCH", "Щ"}, {"ZH", "Ж"}, {"KH", "Х"}, {"TS", "Ц"}, {"CH", "Ч"}, {"SH", "Ш"},
+	{"YU", "Ю"}, {"IU", "Ю"}, {"YA", "Я"}, {"IA", "Я"}, {"YE", "Е"}, {"YO", "Е"}, {"JO", "Е"},
+	{"A", "А"}, {"B", "Б"}, {"V", "В"}, {"W", "В"}, {"G", "Г"}, {"D", "Д"}, {"E", "Е"}, {"Z", "З"},
+	{"I", "И"}, {"J", "Й"}, {"K", "К"}, {"L", "Л"}, {"M", "М"}, {"N", "Н"}, {"O", "О"}, {"P", "П"},
+	{"R", "Р"}, {"S", "С"}, {"T", "Т"}, {"U", "У"}, {"F", "Ф"}, {"H", "Х"}, {"C", "К"}, {"Y", "Ы"},
+	{"Q", "К"}, {"X", "КС"},
+}
+
+// foldWords разбивает имя на слова в верхнем регистре и сворачивает написания:
+// Ё → Е, слово целиком латиницей транслитерируется, отдельные латинские буквы-двойники
+// в кириллическом слове заменяются кириллическими
+func foldWords(s string) []string {
+	words := strings.FieldsFunc(strings.ToUpper(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		w = strings.ReplaceAll(w, "Ё", "Е")
+		switch {
+		case isLatin(w):
+			w = transliterate(w)
+		case hasLatin(w):
+			w = strings.Map(func(r rune) rune {
+				if c, ok := homoglyphs[r]; ok {
+					return c
+				}
+				return r
+			}, w)
+		}
+		words[i] = w
+	}
+	return words
+}
+
+// isLatin сообщает, что в слове есть буквы и все они латинские
+func isLatin(w string) bool {
+	letters := false
+	for _, r := range w {
+		if unicode.IsLetter(r) {
+			if r > unicode.MaxASCII {
+				return false
+			}
+			letters = true
+		}
+	}
+	return letters
+}
+
+func hasLatin(w string) bool {
+	for _, r := range w {
+		if r <= unicode.MaxASCII && unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func transliterate(w string) string {
+	var b strings.Builder
+	for i := 0; i < len(w); {
+		matched := false
+		for _, t := range translit {
+			if strings.HasPrefix(w[i:], t.lat) {
+				b.WriteString(t.cyr)
+				i += len(t.lat)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(w[i])
+			i++
+		}
+	}
+	return b.String()
+}
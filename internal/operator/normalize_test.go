@@ -0,0 +1,34 @@
+package operator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFoldWords(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{name: "cyrillic", in: "Ромашка сервис", want: []string{"РОМАШКА", "СЕРВИС"}},
+		{name: "yo", in: "Алёшин", want: []string{"АЛЕШИН"}},
+		{name: "latin homoglyphs in cyrillic word", in: "POMAШKA", want: []string{"РОМАШКА"}},
+		{name: "homoglyph y in cyrillic word", in: "Kyзнeцoв", want: []string{"КУЗНЕЦОВ"}},
+		{name: "latin word is transliterated", in: "Romashka", want: []string{"РОМАШКА"}},
+		{name: "multi-letter combinations first", in: "Shchukin Zhukov", want: []string{"ЩУКИН", "ЖУКОВ"}},
+		{name: "passport ia and iu", in: "Mariia Iurieva", want: []string{"МАРИЯ", "ЮРИЕВА"}},
+		{name: "kh and ts", in: "Tsvetkov Khramov", want: []string{"ЦВЕТКОВ", "ХРАМОВ"}},
+		{name: "separators and digits", in: "Аэро-Сервис №2, «Юг»", want: []string{"АЭРО", "СЕРВИС", "2", "ЮГ"}},
+		{name: "digits only word kept as is", in: "2024", want: []string{"2024"}},
+		{name: "empty", in: " ,. ", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := foldWords(tt.in)
+			if !slices.Equal(got, tt.want) && !(len(got) == 0 && len(tt.want) == 0) {
+				t.Errorf("foldWords(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// Package operator разбирает свободный текст поля OPR/ телеграммы SHR: выделяет телефоны
+// и токены организационно-правовой формы, строит ключ, по которому записи одного
+// оператора сводятся в одну сущность, и нечетко сопоставляет ключи разных написаний.
+package operator
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Parsed — разобранное поле OPR
+type Parsed struct {
+	Key           string   // Ключ сопоставления: свернутое имя без телефонов и организационно-правовой формы
+	Name          string   // Отображаемое имя без телефонов
+	Phones        []string // Телефоны в виде 7XXXXXXXXXX
+	Organizations []string // Токены организационно-правовой формы и ведомств (ООО, ГБУ, МЧС...)
+}
+
+// phoneRe находит телефоны: +7/8 и десять цифр с произвольными разделителями
+var phoneRe = regexp.MustCompile(`(?:\+7|\b8|\b7)[\s\-()]*\d{3}[\s\-()]*\d{3}[\s\-]*\d{2}[\s\-]*\d{2}\b`)
+
+// orgTokens — слова, указывающие на организацию, а не на физическое лицо
+var orgTokens = map[string]struct{}{
+	"ООО": {}, "ОАО": {}, "ЗАО": {}, "АО": {}, "ПАО": {}, "НАО": {}, "ИП": {}, "АНО": {}, "НКО": {},
+	"ГУ": {}, "ГБУ": {}, "ГКУ": {}, "ГАУ": {}, "МБУ": {}, "МКУ": {}, "МУП": {}, "ГУП": {}, "ФГУП": {},
+	"ФГБУ": {}, "ФГКУ": {}, "ФГАУ": {}, "ФКУ": {}, "ФГБОУ": {}, "ФГАОУ": {}, "ОГБУ": {}, "КГБУ": {},
+	"МЧС": {}, "МВД": {}, "ФСБ": {}, "ФСО": {}, "РОСГВАРДИЯ": {}, "МИНОБОРОНЫ": {}, "УМВД": {}, "ГУМВД": {},
+	"ДОСААФ": {}, "ЦЕНТР": {}, "АВИАЦИОННЫЙ": {}, "ЛЕСООХРАНА": {}, "АВИАЛЕСООХРАНА": {},
+}
+
+// legalForms — организационно-правовые формы, не входящие в ключ
+var legalForms = map[string]struct{}{
+	"ООО": {}, "ОАО": {}, "ЗАО": {}, "АО": {}, "ПАО": {}, "НАО": {}, "ИП": {}, "АНО": {},
+}
+
+// Parse разбирает поле OPR. Пустой Key означает, что оператор не указан.
+func Parse(raw string) Parsed {
+	p := Parsed{Phones: []string{}, Organizations: []string{}}
+	for _, m := range phoneRe.FindAllString(raw, -1) {
+		if phone := normalizePhone(m); phone != "" && !slices.Contains(p.Phones, phone) {
+			p.Phones = append(p.Phones, phone)
+		}
+	}
+	name := phoneRe.ReplaceAllString(raw, " ")
+	p.Name = strings.Join(strings.Fields(strings.Trim(name, " ,;.-")), " ")
+
+	words := foldWords(p.Name)
+	keyWords := make([]string, 0, len(words))
+	for _, w := range words {
+		if _, ok := orgTokens[w]; ok && !slices.Contains(p.Organizations, w) {
+			p.Organizations = append(p.Organizations, w)
+		}
+		if _, ok := legalForms[w]; !ok {
+			keyWords = append(keyWords, w)
+		}
+	}
+	// «ООО РОМАШКА», «РОМАШКА ООО» и «РОМАШКА» — один оператор
+	if len(keyWords) == 0 {
+		keyWords = words
+	}
+	p.Key = strings.Join(keyWords, " ")
+	// оператор указан только телефоном
+	if p.Key == "" && len(p.Phones) > 0 {
+		p.Key = "TEL " + p.Phones[0]
+		p.Name = "+" + p.Phones[0]
+	}
+	return p
+}
+
+// normalizePhone приводит телефон к виду 7XXXXXXXXXX
+func normalizePhone(s string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	if len(digits) != 11 {
+		return ""
+	}
+	return "7" + digits[1:]
+}
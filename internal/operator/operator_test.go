@@ -0,0 +1,99 @@
+package operator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		raw           string
+		key           string
+		displayName   string
+		phones        []string
+		organizations []string
+	}{
+		{
+			name:          "legal form before name, phone with brackets",
+			raw:           "ООО Ромашка +7 (912) 345-67-89",
+			key:           "РОМАШКА",
+			displayName:   "ООО Ромашка",
+			phones:        []string{"79123456789"},
+			organizations: []string{"ООО"},
+		},
+		{
+			name:          "legal form after name, two phones in different formats",
+			raw:           "Ромашка ООО 8-912-345-67-89, 89001112233",
+			key:           "РОМАШКА",
+			displayName:   "Ромашка ООО",
+			phones:        []string{"79123456789", "79001112233"},
+			organizations: []string{"ООО"},
+		},
+		{
+			name:          "repeated phone is kept once",
+			raw:           "Иванов И.И. +79123456789 89123456789",
+			key:           "ИВАНОВ И И",
+			displayName:   "Иванов И.И",
+			phones:        []string{"79123456789"},
+			organizations: []string{},
+		},
+		{
+			name:          "agency stays in key",
+			raw:           "МЧС России",
+			key:           "МЧС РОССИИ",
+			displayName:   "МЧС России",
+			phones:        []string{},
+			organizations: []string{"МЧС"},
+		},
+		{
+			name:          "legal form only",
+			raw:           "ИП",
+			key:           "ИП",
+			displayName:   "ИП",
+			phones:        []string{},
+			organizations: []string{"ИП"},
+		},
+		{
+			name:          "phone only",
+			raw:           "+7 912 345 67 89",
+			key:           "TEL 79123456789",
+			displayName:   "+79123456789",
+			phones:        []string{"79123456789"},
+			organizations: []string{},
+		},
+		{
+			name:          "short number is not a phone",
+			raw:           "Ромашка 12345",
+			key:           "РОМАШКА 12345",
+			displayName:   "Ромашка 12345",
+			phones:        []string{},
+			organizations: []string{},
+		},
+		{
+			name:          "empty",
+			raw:           "  ",
+			key:           "",
+			displayName:   "",
+			phones:        []string{},
+			organizations: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Parse(tt.raw)
+			if p.Key != tt.key {
+				t.Errorf("Key = %q, want %q", p.Key, tt.key)
+			}
+			if p.Name != tt.displayName {
+				t.Errorf("Name = %q, want %q", p.Name, tt.displayName)
+			}
+			if !slices.Equal(p.Phones, tt.phones) {
+				t.Errorf("Phones = %v, want %v", p.Phones, tt.phones)
+			}
+			if !slices.Equal(p.Organizations, tt.organizations) {
+				t.Errorf("Organizations = %v, want %v", p.Organizations, tt.organizations)
+			}
+		})
+	}
+}
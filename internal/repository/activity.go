@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// localFlightsCTE — полеты периода с временем вылета в местном времени региона
+// (для всей РФ — в опорной зоне) и длительностью с учетом перехода через полночь
+const localFlightsCTE = `
+	WITH flights AS (
+		SELECT
+			CASE WHEN $3::int = 0 THEN m.atd_ref ELSE m.atd_local END AS start_local,
+			m.ata_local - m.atd_local AS duration
+		FROM messages_local m
+		WHERE ($3::int = 0 AND m.atd_ref::date BETWEEN $1::date AND $2::date)
+			OR ($3::int <> 0 AND m.region = $3::int AND m.atd_local::date BETWEEN $1::date AND $2::date)
+	)
+`
+
+// GetActivityMatrix считает матрицу час × день недели: число вылетов и число полетов,
+// выполнявшихся в каждый час, в местном времени. regionID = 0 — вся РФ.
+func (r *Repository) GetActivityMatrix(ctx context.Context, regionID int, from, to time.Time) (model.ActivityMatrix, error) {
+	res := model.ActivityMatrix{RegionID: regionID}
+	queries := []struct {
+		name   string
+		query  string
+		target *[7][24]int
+	}{
+		{"flight starts", localFlightsCTE + `
+			SELECT EXTRACT(ISODOW FROM start_local)::int, EXTRACT(HOUR FROM start_local)::int, COUNT(*)
+			FROM flights
+			GROUP BY 1, 2
+		`, &res.Starts},
+		{"active flights", localFlightsCTE + `
+			SELECT EXTRACT(ISODOW FROM h)::int, EXTRACT(HOUR FROM h)::int, COUNT(*)
+			FROM flights f
+			CROSS JOIN LATERAL generate_series(
+				DATE_TRUNC('hour', f.start_local),
+				GREATEST(f.start_local, f.start_local + f.duration - INTERVAL '1 second'),
+				INTERVAL '1 hour'
+			) AS h
+			WHERE f.duration IS NOT NULL
+			GROUP BY 1, 2
+		`, &res.Active},
+	}
+	for _, q := range queries {
+		rows, err := r.db.Query(ctx, q.query, from, to, regionID)
+		if err != nil {
+			return res, fmt.Errorf("failed to query %s: %w", q.name, err)
+		}
+		for rows.Next() {
+			var dow, hour, count int
+			if err := rows.Scan(&dow, &hour, &count); err != nil {
+				rows.Close()
+				return res, fmt.Errorf("failed to scan row: %w", err)
+			}
+			q.target[dow-1][hour] = count
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return res, fmt.Errorf("row iteration error: %w", err)
+		}
+	}
+	return res, nil
+}
+
+// GetFlightIntervals возвращает завершенные полеты, пересекающие период, с вылетом и посадкой
+// в местном времени региона (для всей РФ — в опорной зоне). Посадка после полуночи
+// относится к следующим суткам.
+func (r *Repository) GetFlightIntervals(ctx context.Context, regionID int, from, to time.Time) ([]model.FlightInterval, error) {
+	query := `
+		SELECT start_local, end_local
+		FROM (
+			SELECT
+				CASE WHEN $3::int = 0 THEN m.atd_ref ELSE m.atd_local END AS start_local,
+				CASE WHEN $3::int = 0 THEN m.ata_ref ELSE m.ata_local END AS end_local
+			FROM messages_local m
+			WHERE m.ata IS NOT NULL
+				AND ($3::int = 0 OR m.region = $3::int)
+				AND m.dof BETWEEN $1::date - 2 AND $2::date + 1
+		) f
+		WHERE start_local < $2::date + 1 AND end_local > $1::date
+		ORDER BY start_local
+	`
+	rows, err := r.db.Query(ctx, query, from, to, regionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query flight intervals: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.FlightInterval{}
+	for rows.Next() {
+		var iv model.FlightInterval
+		if err := rows.Scan(&iv.Start, &iv.End); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, iv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// GetRegionTimeZone возвращает часовой пояс региона из district_shapes
+func (r *Repository) GetRegionTimeZone(ctx context.Context, regionID int) (string, error) {
+	var tz string
+	err := r.db.QueryRow(ctx, `
+		SELECT COALESCE(NULLIF(timezone, ''), $2) FROM district_shapes WHERE gid = $1
+	`, regionID, model.ReferenceTimeZone).Scan(&tz)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", model.ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get region time zone: %w", err)
+	}
+	return tz, nil
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// SyncAircraft заносит в реестр номера REG/, встретившиеся в сообщениях впервые
+func (r *Repository) SyncAircraft(ctx context.Context) (int, error) {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO aircraft(reg)
+		SELECT DISTINCT normalize_reg(m.reg)
+		FROM messages m
+		WHERE normalize_reg(m.reg) IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM aircraft a WHERE a.reg = normalize_reg(m.reg))
+		ON CONFLICT (reg) DO NOTHING
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sync aircraft: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// aircraftFlightsCTE — полеты бортов реестра с вылетом в периоде ($1..$2, местное время
+// региона), в регионе $3 (0 — все)
+const aircraftFlightsCTE = `
+	WITH ac_flights AS (
+		SELECT
+			a.id AS aircraft_id, m.id AS message_id, m.dof, m.ata, m.atd_local, m.ata_local,
+			NULLIF(TRIM(m.typ), '') AS typ, m.operator_id
+		FROM messages_local m
+		JOIN aircraft a ON a.reg = normalize_reg(m.reg)
+		WHERE m.atd_local::date BETWEEN $1::date AND $2::date
+			AND ($3::int = 0 OR m.region = $3::int)
+	)
+`
+
+// GetAircraftStats возвращает борта с полетами за период, самые летающие первыми.
+// aircraftID > 0 ограничивает выборку одним бортом.
+func (r *Repository) GetAircraftStats(ctx context.Context, f model.AircraftFilter, aircraftID int) ([]model.AircraftStats, error) {
+	query := aircraftFlightsCTE + `
+		SELECT
+			a.id, a.reg, a.model, a.weight_class, a.category, a.notes, a.updated_at,
+			COALESCE(array_agg(DISTINCT f.typ) FILTER (WHERE f.typ IS NOT NULL), '{}'),
+			COUNT(*),
+			COALESCE(SUM(EXTRACT(EPOCH FROM (f.ata_local - f.atd_local)) / 3600) FILTER (WHERE f.ata IS NOT NULL), 0),
+			COUNT(DISTINCT f.operator_id),
+			MIN(f.dof), MAX(f.dof)
+		FROM ac_flights f
+		JOIN aircraft a ON a.id = f.aircraft_id
+		WHERE ($4::int = 0 OR a.id = $4::int)
+			AND ($5 = '' OR a.category = $5 OR ($5 = 'unknown' AND a.category = ''))
+			AND ($6 = '' OR a.reg LIKE '%' || upper($6) || '%')
+		GROUP BY a.id
+		ORDER BY COUNT(*) DESC, a.id
+		LIMIT $7 OFFSET $8
+	`
+	rows, err := r.db.Query(ctx, query,
+		f.Period.From, f.Period.To, f.RegionID, aircraftID, f.Category,
+		strings.Join(strings.Fields(f.Query), ""), f.Limit, f.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aircraft stats: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.AircraftStats{}
+	for rows.Next() {
+		var s model.AircraftStats
+		if err := rows.Scan(
+			&s.ID, &s.Reg, &s.Model, &s.WeightClass, &s.Category, &s.Notes, &s.UpdatedAt,
+			&s.Types, &s.Flights, &s.Hours, &s.Operators, &s.FirstSeen, &s.LastSeen,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// GetAircraft возвращает борт реестра
+func (r *Repository) GetAircraft(ctx context.Context, id int) (model.Aircraft, error) {
+	var a model.Aircraft
+	err := r.db.QueryRow(ctx, `
+		SELECT id, reg, model, weight_class, category, notes, updated_at
+		FROM aircraft
+		WHERE id = $1
+	`, id).Scan(&a.ID, &a.Reg, &a.Model, &a.WeightClass, &a.Category, &a.Notes, &a.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return a, model.ErrNotFound
+	}
+	if err != nil {
+		return a, fmt.Errorf("failed to query aircraft: %w", err)
+	}
+	return a, nil
+}
+
+// GetAircraftOperators возвращает операторов борта за период по числу полетов
+func (r *Repository) GetAircraftOperators(ctx context.Context, id int, p model.Period) ([]model.AircraftOperator, error) {
+	rows, err := r.db.Query(ctx, aircraftFlightsCTE+`
+		SELECT o.id, o.name, COUNT(*)
+		FROM ac_flights f
+		JOIN operators o ON o.id = f.operator_id
+		WHERE f.aircraft_id = $4
+		GROUP BY o.id
+		ORDER BY COUNT(*) DESC, o.id
+	`, p.From, p.To, 0, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aircraft operators: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.AircraftOperator{}
+	for rows.Next() {
+		var o model.AircraftOperator
+		if err := rows.Scan(&o.ID, &o.Name, &o.Flights); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// AnnotateAircraft сохраняет разметку борта
+func (r *Repository) AnnotateAircraft(ctx context.Context, id int, ann model.AircraftAnnotation) (model.Aircraft, error) {
+	var a model.Aircraft
+	err := r.db.QueryRow(ctx, `
+		UPDATE aircraft SET model = $2, weight_class = $3, category = $4, notes = $5, updated_at = now()
+		WHERE id = $1
+		RETURNING id, reg, model, weight_class, category, notes, updated_at
+	`, id, ann.Model, ann.WeightClass, ann.Category, ann.Notes).
+		Scan(&a.ID, &a.Reg, &a.Model, &a.WeightClass, &a.Category, &a.Notes, &a.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return a, model.ErrNotFound
+	}
+	if err != nil {
+		return a, fmt.Errorf("failed to annotate aircraft: %w", err)
+	}
+	return a, nil
+}
+
+// GetCategoryStats считает полеты региона (0 — вся РФ) за период по категориям БВС.
+// Полеты без номера относятся к unregistered, неразмеченных бортов — к unknown.
+func (r *Repository) GetCategoryStats(ctx context.Context, p model.Period, regionID int) ([]model.CategoryStats, error) {
+	query := `
+		SELECT
+			CASE
+				WHEN normalize_reg(m.reg) IS NULL THEN 'unregistered'
+				ELSE COALESCE(NULLIF(a.category, ''), 'unknown')
+			END,
+			COUNT(DISTINCT normalize_reg(m.reg)),
+			COUNT(*),
+			COALESCE(SUM(EXTRACT(EPOCH FROM (m.ata_local - m.atd_local)) / 3600) FILTER (WHERE m.ata IS NOT NULL), 0),
+			COALESCE(AVG(EXTRACT(EPOCH FROM (m.ata_local - m.atd_local)) / 60) FILTER (WHERE m.ata IS NOT NULL), 0)
+		FROM messages_local m
+		LEFT JOIN aircraft a ON a.reg = normalize_reg(m.reg)
+		WHERE m.atd_local::date BETWEEN $1::date AND $2::date
+			AND ($3::int = 0 OR m.region = $3::int)
+		GROUP BY 1
+		ORDER BY COUNT(*) DESC, 1
+	`
+	rows, err := r.db.Query(ctx, query, p.From, p.To, regionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query category stats: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.CategoryStats{}
+	for rows.Next() {
+		var c model.CategoryStats
+		if err := rows.Scan(&c.Category, &c.Aircraft, &c.Flights, &c.Hours, &c.AvgDurationMinutes); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
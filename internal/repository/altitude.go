@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// applyCeilingsBySID назначает потолок высоты полету по SID; вызывается в транзакции сохранения полета
+const applyCeilingsBySID = `SELECT apply_altitude_ceilings(id) FROM messages WHERE sid = $1`
+
+// ApplyAltitudeCeilings переназначает потолки всем полетам и возвращает их число
+func (r *Repository) ApplyAltitudeCeilings(ctx context.Context) (int, error) {
+	var n int
+	if err := r.db.QueryRow(ctx, `SELECT apply_altitude_ceilings(NULL)`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to apply altitude ceilings: %w", err)
+	}
+	return n, nil
+}
+
+// GetAltitudeCeilings возвращает все потолки высоты
+func (r *Repository) GetAltitudeCeilings(ctx context.Context) ([]model.AltitudeCeiling, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, name, region_code, zone_kind, max_alt, created_at, updated_at
+		FROM altitude_ceilings
+		ORDER BY region_code NULLS FIRST, zone_kind NULLS FIRST, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query altitude ceilings: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.AltitudeCeiling{}
+	for rows.Next() {
+		var c model.AltitudeCeiling
+		if err := rows.Scan(&c.ID, &c.Name, &c.RegionID, &c.ZoneKind, &c.MaxAlt, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// SaveAltitudeCeiling создает потолок или обновляет существующий для того же региона и типа зоны
+func (r *Repository) SaveAltitudeCeiling(ctx context.Context, c model.AltitudeCeiling) (model.AltitudeCeiling, error) {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO altitude_ceilings(name, region_code, zone_kind, max_alt)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (COALESCE(region_code, 0), COALESCE(zone_kind, '')) DO UPDATE SET
+			name = EXCLUDED.name,
+			max_alt = EXCLUDED.max_alt,
+			updated_at = now()
+		RETURNING id, created_at, updated_at
+	`, c.Name, c.RegionID, c.ZoneKind, c.MaxAlt).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return c, fmt.Errorf("failed to save altitude ceiling: %w", err)
+	}
+	return c, nil
+}
+
+// DeleteAltitudeCeiling удаляет потолок
+func (r *Repository) DeleteAltitudeCeiling(ctx context.Context, id int) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM altitude_ceilings WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete altitude ceiling: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// GetAltitudeCompliance считает превышения потолков по полетам с вылетом в периоде
+// (местное время региона); regionID = 0 — вся РФ
+func (r *Repository) GetAltitudeCompliance(ctx context.Context, regionID int, from, to time.Time) (model.AltitudeCompliance, error) {
+	res := model.AltitudeCompliance{RegionID: regionID, Ceilings: []model.CeilingCompliance{}}
+	cte := `
+		WITH flights AS (
+			SELECT m.id, fc.ceiling_id, fc.above_ceiling
+			FROM messages_local m
+			LEFT JOIN flight_altitude_compliance fc ON fc.message_id = m.id
+			WHERE ($3::int = 0 AND m.atd_ref::date BETWEEN $1::date AND $2::date)
+				OR ($3::int <> 0 AND m.region = $3::int AND m.atd_local::date BETWEEN $1::date AND $2::date)
+		)
+	`
+	err := r.db.QueryRow(ctx, cte+`
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE above_ceiling IS NOT NULL),
+			COUNT(*) FILTER (WHERE above_ceiling)
+		FROM flights
+	`, from, to, regionID).Scan(&res.Flights, &res.Checked, &res.Above)
+	if err != nil {
+		return res, fmt.Errorf("failed to count altitude compliance: %w", err)
+	}
+	if res.Checked > 0 {
+		res.ShareAbove = float64(res.Above) / float64(res.Checked)
+	}
+
+	rows, err := r.db.Query(ctx, cte+`
+		SELECT ac.id, ac.name, ac.max_alt, COUNT(*), COUNT(*) FILTER (WHERE f.above_ceiling)
+		FROM flights f
+		JOIN altitude_ceilings ac ON ac.id = f.ceiling_id
+		GROUP BY ac.id, ac.name, ac.max_alt
+		ORDER BY ac.max_alt, ac.id
+	`, from, to, regionID)
+	if err != nil {
+		return res, fmt.Errorf("failed to query altitude compliance: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c model.CeilingCompliance
+		if err := rows.Scan(&c.CeilingID, &c.Name, &c.MaxAlt, &c.Flights, &c.Above); err != nil {
+			return res, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if c.Flights > 0 {
+			c.ShareAbove = float64(c.Above) / float64(c.Flights)
+		}
+		res.Ceilings = append(res.Ceilings, c)
+	}
+	if err := rows.Err(); err != nil {
+		return res, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// GetAltitudeProfile возвращает заявленный диапазон высот полета по SID от вылета до посадки (UTC)
+func (r *Repository) GetAltitudeProfile(ctx context.Context, sid string) (model.AltitudeProfile, error) {
+	p := model.AltitudeProfile{SID: sid}
+	var atd time.Time
+	var ata *time.Time
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			COALESCE(m.region, 0), m.min_alt, m.max_alt,
+			m.dof + m.atd,
+			m.dof + m.ata + CASE WHEN m.ata < m.atd THEN INTERVAL '1 day' ELSE INTERVAL '0' END,
+			fc.ceiling_id, ac.name, fc.ceiling_alt, fc.above_ceiling
+		FROM messages m
+		LEFT JOIN flight_altitude_compliance fc ON fc.message_id = m.id
+		LEFT JOIN altitude_ceilings ac ON ac.id = fc.ceiling_id
+		WHERE m.sid = $1
+	`, sid).Scan(
+		&p.RegionID, &p.MinAlt, &p.MaxAlt, &atd, &ata,
+		&p.CeilingID, &p.CeilingName, &p.CeilingAlt, &p.AboveCeiling,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return p, model.ErrNotFound
+	}
+	if err != nil {
+		return p, fmt.Errorf("failed to get altitude profile: %w", err)
+	}
+	p.Points = []model.AltitudePoint{{Time: atd, MinAlt: p.MinAlt, MaxAlt: p.MaxAlt}}
+	if ata != nil {
+		p.Points = append(p.Points, model.AltitudePoint{Time: *ata, MinAlt: p.MinAlt, MaxAlt: p.MaxAlt})
+	}
+	return p, nil
+}
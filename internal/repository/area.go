@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/paulmach/orb/encoding/wkb"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// SaveArea сохраняет область интереса
+func (r *Repository) SaveArea(ctx context.Context, a model.CustomArea) (model.CustomArea, error) {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO custom_areas(name, geom, area_km2)
+		VALUES ($1, ST_SetSRID(ST_GeomFromWKB($2), 4326), $3)
+		RETURNING id, created_at
+	`, a.Name, wkb.Value(a.Geometry), a.AreaKm2).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		return a, fmt.Errorf("failed to save area %q: %w", a.Name, err)
+	}
+	return a, nil
+}
+
+// GetAreas возвращает сохраненные области с геометрией в GeoJSON
+func (r *Repository) GetAreas(ctx context.Context) ([]model.CustomArea, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, name, area_km2, ST_AsGeoJSON(geom, 6)::jsonb, created_at
+		FROM custom_areas
+		ORDER BY name, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query areas: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.CustomArea{}
+	for rows.Next() {
+		var a model.CustomArea
+		if err := rows.Scan(&a.ID, &a.Name, &a.AreaKm2, &a.GeoJSON, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// GetArea возвращает сохраненную область с геометрией
+func (r *Repository) GetArea(ctx context.Context, id int) (model.CustomArea, error) {
+	var a model.CustomArea
+	s := wkb.Scanner(nil)
+	err := r.db.QueryRow(ctx, `
+		SELECT id, name, area_km2, ST_AsBinary(geom), ST_AsGeoJSON(geom, 6)::jsonb, created_at
+		FROM custom_areas
+		WHERE id = $1
+	`, id).Scan(&a.ID, &a.Name, &a.AreaKm2, s, &a.GeoJSON, &a.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return a, model.ErrNotFound
+	}
+	if err != nil {
+		return a, fmt.Errorf("failed to query area: %w", err)
+	}
+	a.Geometry = s.Geometry
+	return a, nil
+}
+
+// DeleteArea удаляет сохраненную область
+func (r *Repository) DeleteArea(ctx context.Context, id int) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM custom_areas WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete area: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// GetAreaTimeZone возвращает зону региона, к которому относится внутренняя точка области;
+// вне регионов — опорную зону
+func (r *Repository) GetAreaTimeZone(ctx context.Context, a model.CustomArea) (string, error) {
+	var tz string
+	err := r.db.QueryRow(ctx, `
+		SELECT COALESCE(
+			(SELECT NULLIF(ds.timezone, '')
+			FROM attribute_region(ST_PointOnSurface(ST_GeomFromWKB($1))) ar
+			JOIN district_shapes ds ON ds.gid = ar.gid),
+			$2)
+	`, wkb.Value(a.Geometry), model.ReferenceTimeZone).Scan(&tz)
+	if err != nil {
+		return "", fmt.Errorf("failed to get area time zone: %w", err)
+	}
+	return tz, nil
+}
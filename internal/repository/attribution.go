@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// attributionCTE — полеты периода ($1..$2, местное время региона) с регионом из таблицы и по геометрии
+const attributionCTE = `
+	WITH attributed AS (
+		SELECT
+			m.id AS message_id, m.sid, m.dof, m.atd, m.file_id,
+			COALESCE(m.source_region, '') AS source_region,
+			COALESCE(m.region, 0) AS region_id, COALESCE(ds.name_ru, ds.name, '') AS region_name,
+			COALESCE(m.region_method, '') AS method, m.region_distance_m,
+			ST_Y(m.dep_coordinate::geometry) AS lat, ST_X(m.dep_coordinate::geometry) AS lon,
+			m.source_region IS NOT NULL AND m.region IS NOT NULL
+				AND NOT region_name_matches(m.source_region, COALESCE(ds.name_ru, ds.name)) AS mismatch
+		FROM messages_local_all m
+		LEFT JOIN district_shapes ds ON ds.gid = m.region
+		WHERE m.atd_local::date BETWEEN $1::date AND $2::date
+	)
+`
+
+// GetRegionAttribution сводит полеты периода по сочетаниям региона из таблицы и региона по геометрии
+func (r *Repository) GetRegionAttribution(ctx context.Context, p model.Period) (model.RegionAttributionReport, error) {
+	res := model.RegionAttributionReport{Period: p, Pairs: []model.RegionPair{}}
+	rows, err := r.db.Query(ctx, attributionCTE+`
+		SELECT source_region, region_id, region_name, COUNT(*),
+			COUNT(*) FILTER (WHERE method = 'nearest'), bool_or(mismatch)
+		FROM attributed
+		GROUP BY source_region, region_id, region_name
+		ORDER BY bool_or(mismatch) DESC, COUNT(*) DESC, source_region, region_id
+	`, p.From, p.To)
+	if err != nil {
+		return res, fmt.Errorf("failed to query region attribution: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pr model.RegionPair
+		var mismatch bool
+		if err := rows.Scan(&pr.SourceRegion, &pr.RegionID, &pr.RegionName, &pr.Flights, &pr.Nearest, &mismatch); err != nil {
+			return res, fmt.Errorf("failed to scan row: %w", err)
+		}
+		pr.Matches = !mismatch && pr.SourceRegion != "" && pr.RegionID != 0
+		res.Flights += pr.Flights
+		res.Nearest += pr.Nearest
+		if pr.RegionID == 0 {
+			res.Unattributed += pr.Flights
+		} else {
+			res.Contains += pr.Flights - pr.Nearest
+		}
+		if mismatch {
+			res.Mismatched += pr.Flights
+		}
+		res.Pairs = append(res.Pairs, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return res, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// GetAttributedFlights возвращает полеты периода с несовпадающим, ближайшим или неопределенным регионом
+func (r *Repository) GetAttributedFlights(ctx context.Context, f model.AttributionFilter) ([]model.AttributedFlight, error) {
+	query := attributionCTE + `
+		SELECT message_id, sid, dof, to_char(atd, 'HH24:MI'), source_region, region_id, region_name,
+			method, region_distance_m, lat, lon, file_id
+		FROM attributed
+		WHERE CASE $3
+			WHEN 'mismatch' THEN mismatch
+			WHEN 'nearest' THEN method = 'nearest'
+			ELSE region_id = 0
+		END
+		ORDER BY dof DESC, atd DESC, message_id
+		LIMIT $4 OFFSET $5
+	`
+	rows, err := r.db.Query(ctx, query, f.Period.From, f.Period.To, f.Kind, f.Limit, f.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attributed flights: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.AttributedFlight{}
+	for rows.Next() {
+		var a model.AttributedFlight
+		if err := rows.Scan(
+			&a.MessageID, &a.SID, &a.DOF, &a.ATD, &a.SourceRegion, &a.RegionID, &a.RegionName,
+			&a.Method, &a.DistanceM, &a.Lat, &a.Lon, &a.FileID,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
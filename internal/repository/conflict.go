@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// DetectConflicts ищет конфликты за сутки day (UTC) с разделением separation метров
+// и возвращает их число; прежние результаты за эти сутки заменяются
+func (r *Repository) DetectConflicts(ctx context.Context, day time.Time, separation float64) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx, `SELECT detect_flight_conflicts($1::date, $2)`, day, separation).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to detect conflicts for %s: %w", day.Format(time.DateOnly), err)
+	}
+	return n, nil
+}
+
+// GetConflictDays возвращает результаты проверки суток периода
+func (r *Repository) GetConflictDays(ctx context.Context, from, to time.Time) ([]model.FlightConflictDay, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT day, separation_m, flights, conflicts, checked_at
+		FROM flight_conflict_days
+		WHERE day BETWEEN $1::date AND $2::date
+		ORDER BY day
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conflict days: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.FlightConflictDay{}
+	for rows.Next() {
+		var d model.FlightConflictDay
+		if err := rows.Scan(&d.Day, &d.SeparationM, &d.Flights, &d.Conflicts, &d.CheckedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// conflictFlightColumns — поля участника конфликта из messages (m) и district_shapes (ds)
+func conflictFlightColumns(m, ds string) string {
+	return strings.NewReplacer("m.", m+".", "ds.", ds+".").Replace(`
+		m.id, m.sid, COALESCE(m.region, 0), COALESCE(ds.name_ru, ds.name, ''),
+		COALESCE(NULLIF(TRIM(m.opr), ''), ''),
+		to_char(m.atd, 'HH24:MI'), COALESCE(to_char(m.ata, 'HH24:MI'), ''),
+		m.min_alt, m.max_alt`)
+}
+
+// GetFlightConflicts возвращает конфликты по фильтру, начиная с самых длительных пересечений
+func (r *Repository) GetFlightConflicts(ctx context.Context, f model.FlightConflictFilter) ([]model.FlightConflict, error) {
+	query := `
+		SELECT
+			c.id, c.day, c.overlap_start, c.overlap_end, c.overlap_seconds,
+			c.alt_low, c.alt_high, c.distance_m,` +
+		conflictFlightColumns("ma", "da") + `,` +
+		conflictFlightColumns("mb", "db") + `
+		FROM flight_conflicts c
+		JOIN messages ma ON ma.id = c.message_a
+		JOIN messages mb ON mb.id = c.message_b
+		LEFT JOIN district_shapes da ON da.gid = ma.region
+		LEFT JOIN district_shapes db ON db.gid = mb.region
+		WHERE c.day BETWEEN $1::date AND $2::date
+			AND ($3::int = 0 OR ma.region = $3::int OR mb.region = $3::int)
+			AND ($4 = '' OR ma.opr ILIKE '%' || $4 || '%' OR mb.opr ILIKE '%' || $4 || '%')
+		ORDER BY c.overlap_seconds DESC, c.day, c.id
+		LIMIT $5 OFFSET $6
+	`
+	rows, err := r.db.Query(ctx, query,
+		f.Period.From, f.Period.To, f.RegionID, strings.TrimSpace(f.Operator), f.Limit, f.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conflicts: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.FlightConflict{}
+	for rows.Next() {
+		var c model.FlightConflict
+		if err := rows.Scan(
+			&c.ID, &c.Day, &c.OverlapStart, &c.OverlapEnd, &c.OverlapSeconds,
+			&c.AltLow, &c.AltHigh, &c.DistanceM,
+			&c.A.MessageID, &c.A.SID, &c.A.RegionID, &c.A.RegionName, &c.A.Operator,
+			&c.A.ATD, &c.A.ATA, &c.A.MinAlt, &c.A.MaxAlt,
+			&c.B.MessageID, &c.B.SID, &c.B.RegionID, &c.B.RegionName, &c.B.Operator,
+			&c.B.ATD, &c.B.ATA, &c.B.MinAlt, &c.B.MaxAlt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// GetConflictStats считает конфликты за период по регионам (group = "region") или операторам
+// полетов-участников; конфликт между двумя регионами учитывается в каждом из них
+func (r *Repository) GetConflictStats(ctx context.Context, p model.Period, group string) (model.FlightConflictStats, error) {
+	res := model.FlightConflictStats{Period: p, Group: group, Items: []model.FlightConflictCount{}}
+	key, name := "COALESCE(m.region, 0)::text", "COALESCE(ds.name_ru, ds.name, '')"
+	if group == "operator" {
+		key = "COALESCE(NULLIF(TRIM(m.opr), ''), '')"
+		name = key
+	}
+	query := fmt.Sprintf(`
+		WITH participants AS (
+			SELECT %s AS key, %s AS name, c.id, m.id AS message_id, c.overlap_seconds, c.distance_m
+			FROM flight_conflicts c
+			JOIN messages m ON m.id IN (c.message_a, c.message_b)
+			LEFT JOIN district_shapes ds ON ds.gid = m.region
+			WHERE c.day BETWEEN $1::date AND $2::date
+		), per_conflict AS (
+			SELECT DISTINCT key, name, id, overlap_seconds, distance_m FROM participants
+		), flights AS (
+			SELECT key, COUNT(DISTINCT message_id) AS flights FROM participants GROUP BY key
+		)
+		SELECT
+			pc.key, pc.name, COUNT(*), f.flights,
+			SUM(pc.overlap_seconds) / 60.0, MIN(pc.distance_m)
+		FROM per_conflict pc
+		JOIN flights f ON f.key = pc.key
+		GROUP BY pc.key, pc.name, f.flights
+		ORDER BY 3 DESC, 1
+	`, key, name)
+	rows, err := r.db.Query(ctx, query, p.From, p.To)
+	if err != nil {
+		return res, fmt.Errorf("failed to query conflict stats: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c model.FlightConflictCount
+		if err := rows.Scan(&c.Key, &c.Name, &c.Conflicts, &c.Flights, &c.OverlapMinutes, &c.MinDistanceM); err != nil {
+			return res, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res.Items = append(res.Items, c)
+	}
+	if err := rows.Err(); err != nil {
+		return res, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	err = r.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM flight_conflicts WHERE day BETWEEN $1::date AND $2::date
+	`, p.From, p.To).Scan(&res.Total)
+	if err != nil {
+		return res, fmt.Errorf("failed to count conflicts: %w", err)
+	}
+	return res, nil
+}
@@ -2,94 +2,300 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/paulmach/orb/encoding/wkb"
 
 	"github.com/Xapsiel/bpla_dashboard/internal/model"
 )
 
-func (r *Repository) SaveMessage(ctx context.Context, mes *model.ParsedMessage, fileID int) error {
+// uniqueViolation — код ошибки PostgreSQL при нарушении уникальности
+const uniqueViolation = "23505"
+
+// SaveMessage сохраняет новый полет. Если полет с тем же SID уже есть, возвращает model.ErrMessageExists.
+// sourceTime — время источника полета (изменения файла или приема телеграммы), nil — неизвестно.
+func (r *Repository) SaveMessage(ctx context.Context, mes *model.ParsedMessage, fileID int, sourceTime *time.Time) error {
 	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel: pgx.ReadUncommitted,
 	})
 	if err != nil {
 		return err
 	}
+	// регион вылета — по вхождению в полигон, иначе ближайший в пределах допуска
 	query := `
+        WITH attr AS (SELECT * FROM attribute_region(ST_GeomFromWKB($7)))
         INSERT INTO messages(
                              region,
             sid, dof, atd, ata, dep_coords_normalize, arr_coords_normalize,
-            dep_coordinate, arr_coordinate, arr_region_rf, opr, reg, typ, rmk, min_alt, max_alt,file_id
+            dep_coordinate, arr_coordinate, arr_region_rf, opr, reg, typ, rmk, min_alt, max_alt,file_id,
+            arr_region, implausible_reasons, implied_speed_kmh, plausibility_checked,
+            source_region, region_method, region_distance_m, operator_id, source_time
         )
-        VALUES ((SELECT d.gid FROM district_shapes as d WHERE st_contains(d.geom,ST_SetSRID(ST_GeomFromWKB($7),0))),$1, $2, $3, $4, $5, $6, ST_GeomFromWKB($7), ST_GeomFromWKB($8), $9, $10, $11, $12, $13, $14, $15,$16)
-        ON CONFLICT (sid,atd, dep_coordinate, arr_coordinate) DO NOTHING;
+        VALUES ((SELECT gid FROM attr),$1, $2, $3, $4, $5, $6, ST_GeomFromWKB($7), ST_GeomFromWKB($8), $9, $10, $11, $12, $13, $14, $15,$16,
+            (SELECT d.gid FROM district_shapes as d WHERE st_contains(d.geom,ST_SetSRID(ST_GeomFromWKB($8),0))),
+            COALESCE($17::text[], '{}'), $18, true,
+            NULLIF(TRIM($19), ''), (SELECT method FROM attr), (SELECT distance_m FROM attr),
+            (SELECT operator_id FROM operator_aliases WHERE raw = TRIM($10)), $20);
     `
-	slog.Info("Executing insert query", "sid", mes.SID)
-	if mes.SID == "7771464892" {
-		fmt.Println(mes.SID)
-	}
 	_, err = tx.Exec(ctx, query,
-		mes.SID, mes.DOF, mes.ATD, mes.ATA, mes.DepCoords, mes.ArrCoords,
+		mes.SID, mes.DOF, mes.ATD, nullString(mes.ATA), mes.DepCoords, mes.ArrCoords,
 		wkb.Value(mes.DepLatLon), wkb.Value(mes.ArrLatLon), mes.ArrRegionRF,
-		mes.OPR, mes.REG, mes.TYP, mes.RMK, mes.MinAlt, mes.MaxAlt, fileID)
+		mes.OPR, mes.REG, mes.TYP, mes.RMK, mes.MinAlt, mes.MaxAlt, nullInt(fileID),
+		mes.ImplausibleReasons, mes.ImpliedSpeedKmh, mes.Region, sourceTime)
+	if pgErr := (*pgconn.PgError)(nil); errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		tx.Rollback(ctx)
+		return model.ErrMessageExists
+	}
 	if err != nil {
 		tx.Rollback(ctx)
 		slog.Error("Failed to execute query", "sid", mes.SID, "err", err)
 		return err
 	}
-	if len(mes.ZoneLatLon) > 0 {
-		insertFlightCood := `
+	if err = saveZoneCoordinates(ctx, tx, mes); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+	if _, err = tx.Exec(ctx, checkZonesBySID, mes.SID); err != nil {
+		tx.Rollback(ctx)
+		slog.Error("Failed to check zone violations", "sid", mes.SID, "err", err)
+		return err
+	}
+	if _, err = tx.Exec(ctx, applyCeilingsBySID, mes.SID); err != nil {
+		tx.Rollback(ctx)
+		slog.Error("Failed to apply altitude ceilings", "sid", mes.SID, "err", err)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// saveZoneCoordinates сохраняет промежуточные точки зоны полета
+func saveZoneCoordinates(ctx context.Context, tx pgx.Tx, mes *model.ParsedMessage) error {
+	if len(mes.ZoneLatLon) == 0 {
+		return nil
+	}
+	insertFlightCood := `
             INSERT INTO flight_coordinates(sid, coordinate) 
             VALUES ($1, ST_GeomFromWKB($2))
         `
-		for _, coord := range mes.ZoneLatLon {
-			if coord[1] > 90 || coord[1] < -90 || coord[0] > 180 || coord[0] < -180 {
-				slog.Error("Invalid coordinate", "sid", mes.SID, "coord", coord)
-				continue
-			}
-			_, err := tx.Exec(ctx, insertFlightCood, mes.SID, wkb.Value(coord))
-			if err != nil {
-				tx.Rollback(ctx)
-				slog.Error("Failed to insert flight coordinates", "sid", mes.SID, "err", err)
-				return err
-			}
+	for _, coord := range mes.ZoneLatLon {
+		if coord[1] > 90 || coord[1] < -90 || coord[0] > 180 || coord[0] < -180 {
+			slog.Error("Invalid coordinate", "sid", mes.SID, "coord", coord)
+			continue
+		}
+		_, err := tx.Exec(ctx, insertFlightCood, mes.SID, wkb.Value(coord))
+		if err != nil {
+			slog.Error("Failed to insert flight coordinates", "sid", mes.SID, "err", err)
+			return err
 		}
 	}
-	tx.Commit(ctx)
 	return nil
 }
 
+// GetStoredMessage возвращает сохраненный полет по SID в нормализованном виде для сравнения с входящим
+// или nil, если полета нет
+func (r *Repository) GetStoredMessage(ctx context.Context, sid string) (*model.StoredMessage, error) {
+	query := `
+		SELECT
+			m.sid, to_char(m.dof, 'YYYY-MM-DD'), to_char(m.atd, 'HH24:MI'), COALESCE(to_char(m.ata, 'HH24:MI'), ''),
+			m.dep_coords_normalize, COALESCE(m.arr_coords_normalize, ''),
+			COALESCE(m.opr, ''), COALESCE(m.reg, ''), COALESCE(m.typ, ''), COALESCE(m.rmk, ''),
+			m.min_alt, m.max_alt, COALESCE(m.file_id, 0), m.source_time
+		FROM messages m
+		WHERE m.sid = $1
+	`
+	var sm model.StoredMessage
+	err := r.db.QueryRow(ctx, query, sid).Scan(
+		&sm.SID, &sm.DOF, &sm.ATD, &sm.ATA,
+		&sm.DepCoords, &sm.ArrCoords,
+		&sm.OPR, &sm.REG, &sm.TYP, &sm.RMK,
+		&sm.MinAlt, &sm.MaxAlt, &sm.FileID,
+		&sm.SourceTime,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stored message: %w", err)
+	}
+	return &sm, nil
+}
+
+// OverwriteMessage заменяет сохраненный полет с тем же SID входящим вместе со временем источника
+func (r *Repository) OverwriteMessage(ctx context.Context, mes *model.ParsedMessage, fileID int, sourceTime *time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE messages SET
+			region = a.gid, region_method = a.method, region_distance_m = a.distance_m,
+			source_region = NULLIF(TRIM($19), ''),
+			operator_id = (SELECT operator_id FROM operator_aliases WHERE raw = TRIM($10)),
+			arr_region = (SELECT d.gid FROM district_shapes as d WHERE st_contains(d.geom,ST_SetSRID(ST_GeomFromWKB($8),0))),
+			dof = $2, atd = $3, ata = $4,
+			dep_coords_normalize = $5, arr_coords_normalize = $6,
+			dep_coordinate = ST_GeomFromWKB($7), arr_coordinate = ST_GeomFromWKB($8),
+			arr_region_rf = $9, opr = $10, reg = $11, typ = $12, rmk = $13,
+			min_alt = $14, max_alt = $15, file_id = $16, source_time = $20,
+			light_condition = NULL,
+			implausible_reasons = COALESCE($17::text[], '{}'), implied_speed_kmh = $18, plausibility_checked = true
+		FROM attribute_region(ST_GeomFromWKB($7)) a
+		WHERE sid = $1
+	`
+	_, err = tx.Exec(ctx, query,
+		mes.SID, mes.DOF, mes.ATD, nullString(mes.ATA), mes.DepCoords, mes.ArrCoords,
+		wkb.Value(mes.DepLatLon), wkb.Value(mes.ArrLatLon), mes.ArrRegionRF,
+		mes.OPR, mes.REG, mes.TYP, mes.RMK, mes.MinAlt, mes.MaxAlt, nullInt(fileID),
+		mes.ImplausibleReasons, mes.ImpliedSpeedKmh, mes.Region, sourceTime)
+	if err != nil {
+		slog.Error("Failed to overwrite message", "sid", mes.SID, "err", err)
+		return err
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM flight_coordinates WHERE sid = $1`, mes.SID); err != nil {
+		return err
+	}
+	if err = saveZoneCoordinates(ctx, tx, mes); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, checkZonesBySID, mes.SID); err != nil {
+		slog.Error("Failed to check zone violations", "sid", mes.SID, "err", err)
+		return err
+	}
+	if _, err = tx.Exec(ctx, applyCeilingsBySID, mes.SID); err != nil {
+		slog.Error("Failed to apply altitude ceilings", "sid", mes.SID, "err", err)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SaveConflicts сохраняет отчет о конфликтах файла
+func (r *Repository) SaveConflicts(ctx context.Context, fileID int, conflicts []model.Conflict) error {
+	if len(conflicts) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, c := range conflicts {
+		fields, err := json.Marshal(c.Fields)
+		if err != nil {
+			return fmt.Errorf("failed to marshal conflict fields: %w", err)
+		}
+		batch.Queue(`
+			INSERT INTO file_conflicts(file_id, sid, sheet, row_number, existing_file_id, fields, policy, resolution)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, nullInt(fileID), c.SID, c.Sheet, c.Row, nullInt(c.ExistingFileID), fields, c.Policy, c.Resolution)
+	}
+	return r.db.SendBatch(ctx, batch).Close()
+}
+
+// GetConflicts возвращает отчет о конфликтах файла
+func (r *Repository) GetConflicts(ctx context.Context, fileID int) ([]model.Conflict, error) {
+	query := `
+		SELECT sid, COALESCE(sheet, ''), COALESCE(row_number, 0), COALESCE(existing_file_id, 0), fields, policy, resolution
+		FROM file_conflicts
+		WHERE file_id = $1
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conflicts: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.Conflict{}
+	for rows.Next() {
+		var c model.Conflict
+		var fields []byte
+		if err := rows.Scan(&c.SID, &c.Sheet, &c.Row, &c.ExistingFileID, &fields, &c.Policy, &c.Resolution); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err := json.Unmarshal(fields, &c.Fields); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal conflict fields: %w", err)
+		}
+		res = append(res, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// nullString возвращает NULL для пустой строки (например, ATA у телеграммы без посадки)
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullInt возвращает NULL для нулевого идентификатора (сообщение без файла-источника)
+func nullInt(i int) interface{} {
+	if i == 0 {
+		return nil
+	}
+	return i
+}
+
+// SaveFileInfo регистрирует загрузку. Файл с уже загруженным содержимым (sha256) не
+// регистрируется повторно: возвращается id первой загрузки и model.ErrFileExists,
+// а ее счетчики и статус остаются прежними.
 func (r *Repository) SaveFileInfo(ctx context.Context, mf model.File, valid_count int, error_count int) (int, error) {
 	query := `
   				INSERT INTO files(
-					user_id, filename, size, valid_count, error_count, metadata, status
+					user_id, filename, size, valid_count, error_count, metadata, status,
+					duplicate_count, conflict_count, conflict_policy, source_modified_at, sha256
 				)
-				VALUES ($1, $2, $3, $4, $5, $6, $7)
-				ON CONFLICT (metadata) 
-				DO UPDATE SET 
-					valid_count = EXCLUDED.valid_count,
-					error_count = EXCLUDED.error_count,
-					status = EXCLUDED.status
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE(NULLIF($10, ''), 'skip'), $11, NULLIF($12, ''))
+				ON CONFLICT (sha256) DO NOTHING
 				RETURNING id;
-
-				
 			 `
-	row := r.db.QueryRow(ctx, query, mf.AuthorID, mf.Filename, mf.Size, valid_count, error_count, mf.Metadata, mf.Status)
+	row := r.db.QueryRow(ctx, query, mf.AuthorID, mf.Filename, mf.Size, valid_count, error_count, mf.Metadata, mf.Status,
+		mf.DuplicateCount, mf.ConflictCount, mf.ConflictPolicy, mf.ModifiedAt, mf.SHA256)
 	var id int
 	err := row.Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if err = r.db.QueryRow(ctx, `SELECT id FROM files WHERE sha256 = $1`, mf.SHA256).Scan(&id); err != nil {
+			return 0, fmt.Errorf("failed to get uploaded file: %w", err)
+		}
+		return id, model.ErrFileExists
+	}
 	if err != nil {
 		return 0, err
 	}
 	return id, nil
 }
 
+// UpdateFileInfo сохраняет итоговый статус и счетчики загрузки id
+func (r *Repository) UpdateFileInfo(ctx context.Context, id int, mf model.File, validCount int, errorCount int) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE files SET
+			valid_count = $2,
+			error_count = $3,
+			status = $4,
+			duplicate_count = $5,
+			conflict_count = $6,
+			conflict_policy = COALESCE(NULLIF($7, ''), 'skip')
+		WHERE id = $1
+	`, id, validCount, errorCount, mf.Status, mf.DuplicateCount, mf.ConflictCount, mf.ConflictPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to update file info: %w", err)
+	}
+	return nil
+}
+
 func (r *Repository) GetFile(background context.Context, id int) (model.File, error) {
 	query := `
 				SELECT
-					user_id,filename, size, valid_count, error_count, metadata, status
+					user_id,filename, size, valid_count, error_count, metadata, status,
+					COALESCE(duplicate_count, 0), COALESCE(conflict_count, 0), COALESCE(conflict_policy, 'skip'),
+					source_modified_at, COALESCE(sha256, '')
 				FROM files
 					WHERE id = $1;
 			 `
@@ -97,6 +303,8 @@ func (r *Repository) GetFile(background context.Context, id int) (model.File, er
 	var f model.File
 	err := row.Scan(
 		&f.AuthorID, &f.Filename, &f.Size, &f.ValidCount, &f.ErrorCount, &f.Metadata, &f.Status,
+		&f.DuplicateCount, &f.ConflictCount, &f.ConflictPolicy,
+		&f.ModifiedAt, &f.SHA256,
 	)
 	if err != nil {
 		slog.Error("GetFile", "query", query, "err", err)
@@ -104,3 +312,27 @@ func (r *Repository) GetFile(background context.Context, id int) (model.File, er
 	}
 	return f, nil
 }
+
+// GetExistingSIDs возвращает SID из списка, которые уже есть в messages
+func (r *Repository) GetExistingSIDs(ctx context.Context, sids []string) ([]string, error) {
+	res := []string{}
+	if len(sids) == 0 {
+		return res, nil
+	}
+	rows, err := r.db.Query(ctx, `SELECT sid FROM messages WHERE sid = ANY($1)`, sids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query existing sids: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sid string
+		if err := rows.Scan(&sid); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, sid)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/Xapsiel/bpla_dashboard/internal/model"
 )
@@ -156,3 +157,32 @@ func (r *Repository) GetFlightYears(ctx context.Context) []int {
 	}
 	return res
 }
+
+// GetFederalDistricts возвращает федеральные округа с кодами входящих в них регионов
+func (r *Repository) GetFederalDistricts(ctx context.Context) ([]model.FederalDistrict, error) {
+	query := `
+		SELECT fd.id, fd.code, fd.name, COALESCE(ARRAY_AGG(ds.gid ORDER BY ds.gid) FILTER (WHERE ds.gid IS NOT NULL), '{}')
+		FROM federal_districts fd
+		LEFT JOIN district_shapes ds ON ds.federal_district_id = fd.id
+		GROUP BY fd.id, fd.code, fd.name
+		ORDER BY fd.id
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query federal districts: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.FederalDistrict{}
+	for rows.Next() {
+		var fd model.FederalDistrict
+		if err := rows.Scan(&fd.ID, &fd.Code, &fd.Name, &fd.RegionIDs); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, fd)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
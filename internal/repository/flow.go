@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// flowsCTE — полеты периода с известным регионом вылета; $3 — регион вылета или посадки (0 — все)
+const flowsCTE = `
+	WITH flows AS (
+		SELECT m.region AS from_region, m.arr_region AS to_region,
+			ST_Distance(m.dep_coordinate, m.arr_coordinate) / 1000 AS distance_km
+		FROM messages_local m
+		WHERE m.region IS NOT NULL
+			AND m.atd_local::date BETWEEN $1::date AND $2::date
+			AND ($3::int = 0 OR m.region = $3::int OR m.arr_region = $3::int)
+	)
+`
+
+// GetRegionFlows возвращает потоки между регионами за период, по убыванию числа полетов,
+// а также число полетов внутри регионов и без определенного региона посадки
+func (r *Repository) GetRegionFlows(ctx context.Context, f model.FlowFilter) (model.FlowMatrix, error) {
+	res := model.FlowMatrix{Period: f.Period, RegionID: f.RegionID, Flows: []model.RegionFlow{}}
+	err := r.db.QueryRow(ctx, flowsCTE+`
+		SELECT
+			COUNT(*) FILTER (WHERE to_region IS NOT NULL AND to_region <> from_region),
+			COUNT(*) FILTER (WHERE to_region = from_region),
+			COUNT(*) FILTER (WHERE to_region IS NULL)
+		FROM flows
+	`, f.Period.From, f.Period.To, f.RegionID).Scan(&res.InterRegional, &res.Internal, &res.Unresolved)
+	if err != nil {
+		return res, fmt.Errorf("failed to count flows: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, flowsCTE+`
+		SELECT
+			f.from_region, COALESCE(o.name_ru, o.name, ''),
+			f.to_region, COALESCE(d.name_ru, d.name, ''),
+			COUNT(*), COALESCE(SUM(f.distance_km), 0), COALESCE(AVG(f.distance_km), 0)
+		FROM flows f
+		JOIN district_shapes o ON o.gid = f.from_region
+		JOIN district_shapes d ON d.gid = f.to_region
+		WHERE $4::bool OR f.to_region <> f.from_region
+		GROUP BY f.from_region, o.name_ru, o.name, f.to_region, d.name_ru, d.name
+		ORDER BY COUNT(*) DESC, f.from_region, f.to_region
+	`, f.Period.From, f.Period.To, f.RegionID, f.Internal)
+	if err != nil {
+		return res, fmt.Errorf("failed to query flows: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fl model.RegionFlow
+		if err := rows.Scan(
+			&fl.FromRegionID, &fl.FromRegionName,
+			&fl.ToRegionID, &fl.ToRegionName,
+			&fl.Flights, &fl.TotalDistanceKm, &fl.AvgDistanceKm,
+		); err != nil {
+			return res, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res.Flows = append(res.Flows, fl)
+	}
+	if err := rows.Err(); err != nil {
+		return res, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// GetFlowLinesGeoJSON возвращает FeatureCollection линий между внутренними точками регионов
+// вылета и посадки; полеты внутри одного региона в слой не попадают
+func (r *Repository) GetFlowLinesGeoJSON(ctx context.Context, f model.FlowFilter) ([]byte, error) {
+	query := flowsCTE + `
+	, features AS (
+		SELECT jsonb_build_object(
+			'type', 'Feature',
+			'geometry', ST_AsGeoJSON(ST_MakeLine(ST_PointOnSurface(o.geom), ST_PointOnSurface(d.geom)), 6)::jsonb,
+			'properties', jsonb_build_object(
+				'from_region_id', o.gid,
+				'from_region_name', COALESCE(o.name_ru, o.name),
+				'to_region_id', d.gid,
+				'to_region_name', COALESCE(d.name_ru, d.name),
+				'flights', COUNT(*),
+				'total_distance_km', ROUND(COALESCE(SUM(f.distance_km), 0)::numeric, 2)
+			)
+		) AS feature
+		FROM flows f
+		JOIN district_shapes o ON o.gid = f.from_region
+		JOIN district_shapes d ON d.gid = f.to_region
+		WHERE f.to_region <> f.from_region
+		GROUP BY o.gid, d.gid
+	)
+	SELECT jsonb_build_object('type', 'FeatureCollection', 'features', COALESCE(jsonb_agg(feature), '[]'::jsonb))
+	FROM features
+	`
+	var raw json.RawMessage
+	if err := r.db.QueryRow(ctx, query, f.Period.From, f.Period.To, f.RegionID).Scan(&raw); err != nil {
+		return nil, fmt.Errorf("failed to build flow lines: %w", err)
+	}
+	return raw, nil
+}
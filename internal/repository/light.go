@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// GetUnclassifiedDepartures возвращает до limit полетов без отметки освещенности
+func (r *Repository) GetUnclassifiedDepartures(ctx context.Context, limit int) ([]model.FlightDeparture, error) {
+	query := `
+		SELECT id, (dof + atd) AT TIME ZONE 'UTC', ST_Y(dep_coordinate::geometry), ST_X(dep_coordinate::geometry)
+		FROM messages
+		WHERE light_condition IS NULL AND dep_coordinate IS NOT NULL
+		ORDER BY id
+		LIMIT $1
+	`
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unclassified departures: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.FlightDeparture{}
+	for rows.Next() {
+		var d model.FlightDeparture
+		if err := rows.Scan(&d.ID, &d.Time, &d.Lat, &d.Lon); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// SetLightConditions сохраняет освещенность вылета по id полета
+func (r *Repository) SetLightConditions(ctx context.Context, conditions map[int]string) error {
+	if len(conditions) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(conditions))
+	values := make([]string, 0, len(conditions))
+	for id, c := range conditions {
+		ids = append(ids, id)
+		values = append(values, c)
+	}
+	query := `
+		UPDATE messages m SET light_condition = c.value
+		FROM unnest($1::int[], $2::text[]) AS c(id, value)
+		WHERE m.id = c.id
+	`
+	if _, err := r.db.Exec(ctx, query, ids, values); err != nil {
+		return fmt.Errorf("failed to set light conditions: %w", err)
+	}
+	return nil
+}
+
+// GetLightConditions считает вылеты днем, в гражданские сумерки и ночью для региона
+// за год по местному времени; regID = 0 — вся РФ по опорной зоне
+func (r *Repository) GetLightConditions(ctx context.Context, regID int, year int) (int, int, int, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE m.light_condition = 'day'),
+			COUNT(*) FILTER (WHERE m.light_condition = 'twilight'),
+			COUNT(*) FILTER (WHERE m.light_condition = 'night')
+		FROM messages_local m
+		WHERE m.ata IS NOT NULL
+	`
+	args := []interface{}{year}
+	if regID != 0 {
+		query += " AND EXTRACT(YEAR FROM m.atd_local) = $1 AND m.region = $2"
+		args = append(args, regID)
+	} else {
+		query += " AND EXTRACT(YEAR FROM m.atd_ref) = $1"
+	}
+	var day, twilight, night int
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&day, &twilight, &night); err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to query light conditions: %w", err)
+	}
+	return day, twilight, night, nil
+}
@@ -23,7 +23,10 @@ func (r *Repository) GetMetrics(ctx context.Context, id int, year int) (model.Me
 					monthly_growth,flight_density,
 					morning_flights,day_flights,
 					evening_flights,night_flights,
-					zero_flight_days,date
+					zero_flight_days,date,
+					time_zone,
+					COALESCE(daylight_flights, 0),COALESCE(twilight_flights, 0),
+					COALESCE(dark_flights, 0),COALESCE(night_share, 0)
 				FROM flight_metrics
 				WHERE region_code = $1 AND date = $2
 			 `
@@ -39,17 +42,20 @@ func (r *Repository) GetMetrics(ctx context.Context, id int, year int) (model.Me
 		&res.MorningFlights, &res.DayFlights,
 		&res.EveningFlights, &res.NightFlights,
 		&res.ZeroFlightDays, &res.Year,
+		&res.TimeZone,
+		&res.DaylightFlights, &res.TwilightFlights,
+		&res.DarkFlights, &res.NightShare,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return res, err
 		}
-		slog.Error("error with scan metrics: %v", err)
+		slog.Error("error with scan metrics", "error", err)
 		return res, err
 	}
 	err = json.Unmarshal(jsonDate, &res.MonthlyGrowth)
 	if err != nil {
-		slog.Error("error with unmarshal metrics: %v", err)
+		slog.Error("error with unmarshal metrics", "error", err)
 		return res, err
 	}
 	return res, nil
@@ -68,13 +74,8 @@ func (r *Repository) TotalFlightAndAVGDuration(ctx context.Context, regID int, y
 			m.region AS region_code,
 			ds.name AS region_name,
 			COUNT(DISTINCT m.sid) AS total_flight,
-			AVG(EXTRACT(EPOCH FROM (
-				CASE
-					WHEN m.atd > m.ata THEN (m.dof + INTERVAL '1 day' + m.ata) - (m.dof + m.atd)
-					ELSE (m.dof + m.ata) - (m.dof + m.atd)
-				END
-			)) / 60) AS avg_duration_minutes
-		FROM messages m
+			AVG(EXTRACT(EPOCH FROM (m.ata_local - m.atd_local)) / 60) AS avg_duration_minutes
+		FROM messages_local m
 		LEFT JOIN district_shapes ds ON m.region = ds.gid
 		WHERE m.ata IS NOT NULL AND m.arr_coordinate IS NOT NULL
 	`
@@ -86,7 +87,7 @@ func (r *Repository) TotalFlightAndAVGDuration(ctx context.Context, regID int, y
 	}
 	if year != 0 {
 		paramPos := len(args) + 1
-		query += fmt.Sprintf(" AND EXTRACT(YEAR FROM m.dof) = $%d", paramPos)
+		query += fmt.Sprintf(" AND EXTRACT(YEAR FROM m.atd_local) = $%d", paramPos)
 		args = append(args, year)
 	}
 
@@ -134,10 +135,10 @@ func (r *Repository) GetPeakLoad(ctx context.Context, regID int, year int) ([]st
 			SELECT
 				ds.gid AS region_code,
 				ds.name AS region_name,
-				DATE_TRUNC('hour', m.dof + m.atd) AS hour,
+				DATE_TRUNC('hour', m.atd_local) AS hour,
 				COUNT(*) AS hourly_count
 			FROM district_shapes ds
-			LEFT JOIN messages m ON m.region = ds.gid AND m.ata IS NOT NULL
+			LEFT JOIN messages_local m ON m.region = ds.gid AND m.ata IS NOT NULL
 			WHERE 1=1
 	`
 	args := []interface{}{}
@@ -147,11 +148,11 @@ func (r *Repository) GetPeakLoad(ctx context.Context, regID int, year int) ([]st
 	}
 	if year != 0 {
 		paramPos := len(args) + 1
-		query += fmt.Sprintf(" AND EXTRACT(YEAR FROM m.dof) = $%d", paramPos)
+		query += fmt.Sprintf(" AND EXTRACT(YEAR FROM m.atd_local) = $%d", paramPos)
 		args = append(args, year)
 	}
 	query += `
-			GROUP BY ds.gid, ds.name, DATE_TRUNC('hour', m.dof + m.atd)
+			GROUP BY ds.gid, ds.name, DATE_TRUNC('hour', m.atd_local)
 		),
 		peak_hours AS (
 			SELECT
@@ -205,9 +206,9 @@ func (r *Repository) GetMonthlyGrowth(ctx context.Context, regID int, year int)
 			SELECT
 				m.region AS region_code,
 				ds.name AS region_name,
-				DATE_TRUNC('month', m.dof) AS month_date,
+				DATE_TRUNC('month', m.atd_local) AS month_date,
 				COUNT(m.sid) AS monthly_flight_count
-			FROM messages m
+			FROM messages_local m
 			JOIN district_shapes ds ON m.region = ds.gid
 			WHERE m.ata IS NOT NULL
 	`
@@ -217,11 +218,11 @@ func (r *Repository) GetMonthlyGrowth(ctx context.Context, regID int, year int)
 		args = append(args, regID)
 	}
 	if year != 0 {
-		query += " AND EXTRACT(YEAR FROM m.dof) = $" + fmt.Sprintf("%d", len(args)+1)
+		query += " AND EXTRACT(YEAR FROM m.atd_local) = $" + fmt.Sprintf("%d", len(args)+1)
 		args = append(args, year)
 	}
 	query += `
-			GROUP BY m.region, ds.name, DATE_TRUNC('month', m.dof)
+			GROUP BY m.region, ds.name, DATE_TRUNC('month', m.atd_local)
 		),
 		growth_calc AS (
 			SELECT
@@ -332,12 +333,9 @@ func (r *Repository) GetDailyFlightMetrics(ctx context.Context, regID int, year
 			SELECT
 				m.region AS region_code,
 				ds.name AS region_name,
-				CASE
-					WHEN m.atd > m.ata THEN m.dof + INTERVAL '1 day'
-					ELSE m.dof
-				END AS flight_date,
+				m.ata_local::date AS flight_date,
 				COUNT(m.sid) AS daily_flight_count
-			FROM messages m
+			FROM messages_local m
 			JOIN district_shapes ds ON m.region = ds.gid
 			WHERE m.ata IS NOT NULL
 	`
@@ -349,15 +347,12 @@ func (r *Repository) GetDailyFlightMetrics(ctx context.Context, regID int, year
 	}
 	if year != 0 {
 		paramPos := len(args) + 1
-		query += fmt.Sprintf(" AND EXTRACT(YEAR FROM m.dof) = $%d", paramPos)
+		query += fmt.Sprintf(" AND EXTRACT(YEAR FROM m.atd_local) = $%d", paramPos)
 		args = append(args, year)
 	}
 
 	query += `
-			GROUP BY m.region, ds.name, CASE
-				WHEN m.atd > m.ata THEN m.dof + INTERVAL '1 day'
-				ELSE m.dof
-			END
+			GROUP BY m.region, ds.name, m.ata_local::date
 		)
 		SELECT
 			region_code,
@@ -410,7 +405,7 @@ func (r *Repository) GetFlightDensity(ctx context.Context, regID int, year int)
 			m.region AS region_code,
 			ds.name AS region_name,
 			(COUNT(DISTINCT m.sid)::NUMERIC / (ds.area_km2 / 1000)) AS flight_density
-		FROM messages m
+		FROM messages_local m
 		JOIN district_shapes ds ON m.region = ds.gid
 		WHERE m.ata IS NOT NULL AND m.arr_coordinate IS NOT NULL
 	`
@@ -422,7 +417,7 @@ func (r *Repository) GetFlightDensity(ctx context.Context, regID int, year int)
 	}
 	if year != 0 {
 		paramPos := len(args) + 1
-		query += fmt.Sprintf(" AND EXTRACT(YEAR FROM m.dof) = $%d", paramPos)
+		query += fmt.Sprintf(" AND EXTRACT(YEAR FROM m.atd_local) = $%d", paramPos)
 		args = append(args, year)
 	}
 
@@ -473,11 +468,11 @@ func (r *Repository) GetFlightTimes(ctx context.Context, regID int, year int) ([
 		SELECT
 			m.region AS region_code,
 			ds.name AS region_name,
-			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd) BETWEEN 6 AND 11 THEN 1 ELSE 0 END) AS morning_flights,
-			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd) BETWEEN 12 AND 17 THEN 1 ELSE 0 END) AS day_flights,
-			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd) BETWEEN 18 AND 23 THEN 1 ELSE 0 END) AS evening_flights,
-			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd) BETWEEN 0 AND 5 THEN 1 ELSE 0 END) AS night_flights
-		FROM messages m
+			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd_local) BETWEEN 6 AND 11 THEN 1 ELSE 0 END) AS morning_flights,
+			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd_local) BETWEEN 12 AND 17 THEN 1 ELSE 0 END) AS day_flights,
+			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd_local) BETWEEN 18 AND 23 THEN 1 ELSE 0 END) AS evening_flights,
+			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd_local) BETWEEN 0 AND 5 THEN 1 ELSE 0 END) AS night_flights
+		FROM messages_local m
 		JOIN district_shapes ds ON m.region = ds.gid
 		WHERE m.ata IS NOT NULL
 	`
@@ -488,7 +483,7 @@ func (r *Repository) GetFlightTimes(ctx context.Context, regID int, year int) ([
 	}
 	if year != 0 {
 		paramPos := len(args) + 1
-		query += fmt.Sprintf(" AND EXTRACT(YEAR FROM m.dof) = $%d", paramPos)
+		query += fmt.Sprintf(" AND EXTRACT(YEAR FROM m.atd_local) = $%d", paramPos)
 		args = append(args, year)
 	}
 	query += " GROUP BY m.region, ds.name"
@@ -541,11 +536,11 @@ func (r *Repository) GetZeroFlightDays(ctx context.Context, regID int, year int)
 			SELECT
 				m.region AS region_code,
 				ds.name AS region_name,
-				m.dof
-			FROM messages m
+				m.atd_local::date AS dof
+			FROM messages_local m
 			JOIN district_shapes ds ON m.region = ds.gid
 			WHERE m.ata IS NOT NULL
-				AND EXTRACT(YEAR FROM m.dof) = $1
+				AND EXTRACT(YEAR FROM m.atd_local) = $1
 	`
 	args := []interface{}{year}
 	if regID != 0 {
@@ -553,7 +548,7 @@ func (r *Repository) GetZeroFlightDays(ctx context.Context, regID int, year int)
 		args = append(args, regID)
 	}
 	query += `
-			GROUP BY m.region, ds.name, m.dof
+			GROUP BY m.region, ds.name, m.atd_local::date
 		),
 		zero_flight_days AS (
 			SELECT
@@ -624,8 +619,8 @@ func (r *Repository) GetTotalDistance(ctx context.Context, regID int, year int)
 				ds.name AS region_name,
 				m.dep_coordinate AS coordinate,
 				0 AS coord_order,
-				m.dof
-			FROM messages m
+				m.atd_local::date AS dof
+			FROM messages_local m
 			JOIN district_shapes ds ON m.region = ds.gid
 			WHERE m.ata IS NOT NULL AND m.arr_coordinate IS NOT NULL
 
@@ -637,9 +632,9 @@ func (r *Repository) GetTotalDistance(ctx context.Context, regID int, year int)
 				ds.name AS region_name,
 				fc.coordinate,
 				fc.id AS coord_order,
-				m.dof
+				m.atd_local::date AS dof
 			FROM flight_coordinates fc
-			JOIN messages m ON fc.sid = m.sid
+			JOIN messages_local m ON fc.sid = m.sid
 			JOIN district_shapes ds ON m.region = ds.gid
 
 			UNION ALL
@@ -650,8 +645,8 @@ func (r *Repository) GetTotalDistance(ctx context.Context, regID int, year int)
 				ds.name AS region_name,
 				m.arr_coordinate AS coordinate,
 				999999999 AS coord_order,
-				m.dof
-			FROM messages m
+				m.atd_local::date AS dof
+			FROM messages_local m
 			JOIN district_shapes ds ON m.region = ds.gid
 			WHERE m.ata IS NOT NULL AND m.arr_coordinate IS NOT NULL
 		),
@@ -729,16 +724,20 @@ func (r *Repository) GetTotalDistance(ctx context.Context, regID int, year int)
 	}
 	return results, nil
 }
-func (r *Repository) UpdateMetrics(ctx context.Context, metrics chan *model.Metrics) error {
+
+// UpsertMetrics сохраняет метрики региона (или всей РФ при RegionId = 0) за год
+// и их версию в metric_snapshots для пересчета runID
+func (r *Repository) UpsertMetrics(ctx context.Context, runID int, m *model.Metrics) error {
 	query := `
 		INSERT INTO flight_metrics (
 			region_code, region_name, total_flight, avg_duration_minutes,
 			total_distance_km, peak_load, avg_daily_flights, median_daily_flights,
 			monthly_growth, flight_density, morning_flights, day_flights,
-			evening_flights, night_flights, zero_flight_days, date
+			evening_flights, night_flights, zero_flight_days, date, time_zone,
+			daylight_flights, twilight_flights, dark_flights, night_share
 		)
 		VALUES (
-			$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16
+			$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21
 		)
 		ON CONFLICT (region_code,date) 
 		DO UPDATE SET
@@ -756,54 +755,70 @@ func (r *Repository) UpdateMetrics(ctx context.Context, metrics chan *model.Metr
 			evening_flights = EXCLUDED.evening_flights,
 			night_flights = EXCLUDED.night_flights,
 			zero_flight_days = EXCLUDED.zero_flight_days,
-			date = EXCLUDED.date
+			date = EXCLUDED.date,
+			time_zone = EXCLUDED.time_zone,
+			daylight_flights = EXCLUDED.daylight_flights,
+			twilight_flights = EXCLUDED.twilight_flights,
+			dark_flights = EXCLUDED.dark_flights,
+			night_share = EXCLUDED.night_share
 	`
 
-	for m := range metrics {
-		if m.RegionName == "" {
-			continue
-		}
-		jsonData, err := json.Marshal(m.MonthlyGrowth)
-		if err != nil {
-			return fmt.Errorf("failed to marshal monthly growth: %w", err)
-		}
-		if jsonData == nil || m.RegionId == 17 {
-			fmt.Println("a")
-		}
-		_, err = r.db.Exec(ctx, query,
-			m.RegionId, m.RegionName,
-			m.TotalFlight, m.AvgDurationMinutes,
-			m.TotalDistance, m.PeakLoad,
-			m.AvgDailyFlights, m.MedianDailyFlights,
-			jsonData, m.FlightDensity,
-			m.MorningFlights, m.DayFlights,
-			m.EveningFlights, m.NightFlights,
-			m.ZeroFlightDays, m.Year,
+	jsonData, err := json.Marshal(m.MonthlyGrowth)
+	if err != nil {
+		return fmt.Errorf("failed to marshal monthly growth: %w", err)
+	}
+	args := []interface{}{
+		m.RegionId, m.RegionName,
+		m.TotalFlight, m.AvgDurationMinutes,
+		m.TotalDistance, m.PeakLoad,
+		m.AvgDailyFlights, m.MedianDailyFlights,
+		jsonData, m.FlightDensity,
+		m.MorningFlights, m.DayFlights,
+		m.EveningFlights, m.NightFlights,
+		m.ZeroFlightDays, m.Year,
+		m.TimeZone,
+		m.DaylightFlights, m.TwilightFlights,
+		m.DarkFlights, m.NightShare,
+	}
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to upsert flight metrics: %w", err)
+	}
+	snapshot := `
+		INSERT INTO metric_snapshots (
+			region_code, region_name, total_flight, avg_duration_minutes,
+			total_distance_km, peak_load, avg_daily_flights, median_daily_flights,
+			monthly_growth, flight_density, morning_flights, day_flights,
+			evening_flights, night_flights, zero_flight_days, date, time_zone,
+			daylight_flights, twilight_flights, dark_flights, night_share, run_id
 		)
-		if err != nil {
-			slog.Info("error upserting flight metrics: ", err)
-			continue
-		}
+		VALUES (
+			$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22
+		)
+		ON CONFLICT (run_id, region_code, date) DO NOTHING
+	`
+	if _, err = tx.Exec(ctx, snapshot, append(args, runID)...); err != nil {
+		return fmt.Errorf("failed to save metrics snapshot: %w", err)
 	}
-	return nil
+	return tx.Commit(ctx)
 }
 
 func (r *Repository) TotalFlightAndAVGDurationAllRussia(ctx context.Context, year int) (int, float32, error) {
 	query := `
 		SELECT
 			COUNT(DISTINCT m.sid) AS total_flight,
-			AVG(EXTRACT(EPOCH FROM (
-				CASE
-					WHEN m.atd > m.ata THEN (m.dof + INTERVAL '1 day' + m.ata) - (m.dof + m.atd)
-					ELSE (m.dof + m.ata) - (m.dof + m.atd)
-				END
-			)) / 60) AS avg_duration_minutes
-		FROM messages m
+			AVG(EXTRACT(EPOCH FROM (m.ata_ref - m.atd_ref)) / 60) AS avg_duration_minutes
+		FROM messages_local m
 		WHERE m.ata IS NOT NULL AND m.arr_coordinate IS NOT NULL
 	`
 	args := []interface{}{}
 	if year != 0 {
-		query += " AND EXTRACT(YEAR FROM m.dof) = $1"
+		query += " AND EXTRACT(YEAR FROM m.atd_ref) = $1"
 		args = append(args, year)
 	}
 
@@ -820,18 +835,18 @@ func (r *Repository) GetDailyFlightMetricsAllRussia(ctx context.Context, year in
 	query := `
 		WITH daily_flights AS (
 			SELECT
-				CASE WHEN m.atd > m.ata THEN m.dof + INTERVAL '1 day' ELSE m.dof END AS flight_date,
+				m.ata_ref::date AS flight_date,
 				COUNT(m.sid) AS daily_flight_count
-			FROM messages m
+			FROM messages_local m
 			WHERE m.ata IS NOT NULL
 	`
 	args := []interface{}{}
 	if year != 0 {
-		query += " AND EXTRACT(YEAR FROM m.dof) = $1"
+		query += " AND EXTRACT(YEAR FROM m.atd_ref) = $1"
 		args = append(args, year)
 	}
 	query += `
-			GROUP BY CASE WHEN m.atd > m.ata THEN m.dof + INTERVAL '1 day' ELSE m.dof END
+			GROUP BY m.ata_ref::date
 		)
 		SELECT
 			AVG(daily_flight_count) AS avg_daily_flights,
@@ -851,11 +866,11 @@ func (r *Repository) GetRussiaMonthlyGrowth(ctx context.Context, year int) (map[
 	query := `
 WITH monthly_counts AS (
     SELECT
-        DATE_TRUNC('month', m.dof) AS month_date,
+        DATE_TRUNC('month', m.atd_ref) AS month_date,
         COUNT(m.sid) AS monthly_flight_count
-    FROM messages m
-    WHERE m.ata IS NOT NULL AND EXTRACT(YEAR FROM m.dof) = $1
-    GROUP BY DATE_TRUNC('month', m.dof)
+    FROM messages_local m
+    WHERE m.ata IS NOT NULL AND EXTRACT(YEAR FROM m.atd_ref) = $1
+    GROUP BY DATE_TRUNC('month', m.atd_ref)
 ),
 growth_calc AS (
     SELECT
@@ -901,13 +916,13 @@ func (r *Repository) GetFlightDensityAllRussia(ctx context.Context, year int) (f
 	query := `
 		SELECT
 			COUNT(DISTINCT m.sid)::NUMERIC / SUM(ds.area_km2 / 1000) AS flight_density
-		FROM messages m
+		FROM messages_local m
 		JOIN district_shapes ds ON m.region = ds.gid
 		WHERE m.ata IS NOT NULL AND m.arr_coordinate IS NOT NULL
 	`
 	args := []interface{}{}
 	if year != 0 {
-		query += " AND EXTRACT(YEAR FROM m.dof) = $1"
+		query += " AND EXTRACT(YEAR FROM m.atd_ref) = $1"
 		args = append(args, year)
 	}
 
@@ -922,16 +937,16 @@ func (r *Repository) GetFlightDensityAllRussia(ctx context.Context, year int) (f
 func (r *Repository) GetFlightTimesAllRussia(ctx context.Context, year int) (int, int, int, int, error) {
 	query := `
 		SELECT
-			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd) BETWEEN 6 AND 11 THEN 1 ELSE 0 END) AS morning_flights,
-			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd) BETWEEN 12 AND 17 THEN 1 ELSE 0 END) AS day_flights,
-			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd) BETWEEN 18 AND 23 THEN 1 ELSE 0 END) AS evening_flights,
-			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd) BETWEEN 0 AND 5 THEN 1 ELSE 0 END) AS night_flights
-		FROM messages m
+			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd_ref) BETWEEN 6 AND 11 THEN 1 ELSE 0 END) AS morning_flights,
+			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd_ref) BETWEEN 12 AND 17 THEN 1 ELSE 0 END) AS day_flights,
+			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd_ref) BETWEEN 18 AND 23 THEN 1 ELSE 0 END) AS evening_flights,
+			SUM(CASE WHEN EXTRACT(HOUR FROM m.atd_ref) BETWEEN 0 AND 5 THEN 1 ELSE 0 END) AS night_flights
+		FROM messages_local m
 		WHERE m.ata IS NOT NULL
 	`
 	args := []interface{}{}
 	if year != 0 {
-		query += " AND EXTRACT(YEAR FROM m.dof) = $1"
+		query += " AND EXTRACT(YEAR FROM m.atd_ref) = $1"
 		args = append(args, year)
 	}
 
@@ -949,10 +964,10 @@ func (r *Repository) GetZeroFlightDaysAllRussia(ctx context.Context, year int) (
 
 	query := `
         WITH flight_days AS (
-            SELECT DISTINCT m.dof AS flight_date
-            FROM messages m
+            SELECT DISTINCT m.atd_ref::date AS flight_date
+            FROM messages_local m
             WHERE m.ata IS NOT NULL
-              AND EXTRACT(YEAR FROM m.dof) = $1
+              AND EXTRACT(YEAR FROM m.atd_ref) = $1
         ),
         all_days AS (
             SELECT generate_series(
@@ -977,17 +992,17 @@ func (r *Repository) GetZeroFlightDaysAllRussia(ctx context.Context, year int) (
 
 func (r *Repository) GetTotalDistanceAllRussia(ctx context.Context, year int) (float64, error) {
 	query := `
-		WITH all_coordinates AS (SELECT m.sid, m.dep_coordinate AS coordinate, 0 AS coord_order, m.dof
-								 FROM messages m
+		WITH all_coordinates AS (SELECT m.sid, m.dep_coordinate AS coordinate, 0 AS coord_order, m.atd_ref::date AS dof
+								 FROM messages_local m
 								 WHERE m.ata IS NOT NULL
 								   AND m.arr_coordinate IS NOT NULL
 								 UNION ALL
-								 SELECT fc.sid, fc.coordinate, fc.id AS coord_order, m.dof
+								 SELECT fc.sid, fc.coordinate, fc.id AS coord_order, m.atd_ref::date AS dof
 								 FROM flight_coordinates fc
-										  JOIN messages m ON fc.sid = m.sid
+										  JOIN messages_local m ON fc.sid = m.sid
 								 UNION ALL
-								 SELECT m.sid, m.arr_coordinate, 999999999 AS coord_order, m.dof
-								 FROM messages m
+								 SELECT m.sid, m.arr_coordinate, 999999999 AS coord_order, m.atd_ref::date AS dof
+								 FROM messages_local m
 								 WHERE m.ata IS NOT NULL
 								   AND m.arr_coordinate IS NOT NULL),
 			coordinate_pairs AS (
@@ -1021,20 +1036,20 @@ func (r *Repository) GetPeakLoadAllRussia(ctx context.Context, year int) (int, e
 	query := `
 		WITH hourly_load AS (
     SELECT
-        DATE_TRUNC('hour', m.dof + m.atd) AS hour,
+        DATE_TRUNC('hour', m.atd_ref) AS hour,
         COUNT(*) AS hourly_count
-    FROM messages m
+    FROM messages_local m
     WHERE m.ata IS NOT NULL
 
 
 	`
 	args := []interface{}{}
 	if year != 0 {
-		query += " AND EXTRACT(YEAR FROM m.dof) = $1"
+		query += " AND EXTRACT(YEAR FROM m.atd_ref) = $1"
 		args = append(args, year)
 	}
 	query += `
-    GROUP BY DATE_TRUNC('hour', m.dof + m.atd)
+    GROUP BY DATE_TRUNC('hour', m.atd_ref)
 		)
 		SELECT
 			hourly_count AS peak_load
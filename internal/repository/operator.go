@@ -0,0 +1,452 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// GetUnresolvedOperators возвращает до limit написаний OPR/, еще не отнесенных к оператору
+func (r *Repository) GetUnresolvedOperators(ctx context.Context, limit int) ([]string, error) {
+	query := `
+		SELECT DISTINCT TRIM(m.opr)
+		FROM messages m
+		WHERE COALESCE(TRIM(m.opr), '') <> ''
+			AND NOT EXISTS (SELECT 1 FROM operator_aliases a WHERE a.raw = TRIM(m.opr))
+		LIMIT $1
+	`
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unresolved operators: %w", err)
+	}
+	defer rows.Close()
+
+	res := []string{}
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, raw)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// GetOperatorKeys возвращает ключи и телефоны всех операторов для сопоставления новых написаний
+func (r *Repository) GetOperatorKeys(ctx context.Context) ([]model.OperatorKey, error) {
+	rows, err := r.db.Query(ctx, `SELECT COALESCE(merged_into, id), key, phones FROM operators ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operator keys: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.OperatorKey{}
+	for rows.Next() {
+		var k model.OperatorKey
+		if err := rows.Scan(&k.ID, &k.Key, &k.Phones); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// SaveOperatorAliases связывает написания OPR/ с найденными операторами (дополняя их
+// телефоны и организации) или заводит операторов по ключу, затем проставляет оператора
+// полетам с этими написаниями
+func (r *Repository) SaveOperatorAliases(ctx context.Context, aliases []model.OperatorAlias) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	raws := make([]string, 0, len(aliases))
+	for _, a := range aliases {
+		raws = append(raws, a.Raw)
+		switch {
+		case a.OperatorID > 0:
+			batch.Queue(`
+				WITH op AS (
+					UPDATE operators SET
+						phones = ARRAY(SELECT DISTINCT unnest(phones || $3::text[])),
+						organizations = ARRAY(SELECT DISTINCT unnest(organizations || $4::text[]))
+					WHERE id = $2
+					RETURNING id
+				)
+				INSERT INTO operator_aliases(raw, operator_id, method, score)
+				SELECT $1, id, $5, $6 FROM op
+				ON CONFLICT (raw) DO NOTHING
+			`, a.Raw, a.OperatorID, a.Phones, a.Organizations, a.Method, a.Score)
+		case a.Key != "":
+			batch.Queue(`
+				WITH op AS (
+					INSERT INTO operators(key, name, phones, organizations)
+					VALUES ($2, $3, $4, $5)
+					ON CONFLICT (key) DO UPDATE SET
+						phones = ARRAY(SELECT DISTINCT unnest(operators.phones || EXCLUDED.phones)),
+						organizations = ARRAY(SELECT DISTINCT unnest(operators.organizations || EXCLUDED.organizations))
+					RETURNING COALESCE(merged_into, id) AS id
+				)
+				INSERT INTO operator_aliases(raw, operator_id, method, score)
+				SELECT $1, id, $6, $7 FROM op
+				ON CONFLICT (raw) DO NOTHING
+			`, a.Raw, a.Key, a.Name, a.Phones, a.Organizations, a.Method, a.Score)
+		default:
+			batch.Queue(`INSERT INTO operator_aliases(raw) VALUES ($1) ON CONFLICT (raw) DO NOTHING`, a.Raw)
+		}
+	}
+	if err = tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to save operator aliases: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE messages m SET operator_id = a.operator_id
+		FROM operator_aliases a
+		WHERE a.raw = ANY($1::text[]) AND TRIM(m.opr) = a.raw
+			AND m.operator_id IS DISTINCT FROM a.operator_id
+	`, raws)
+	if err != nil {
+		return fmt.Errorf("failed to assign message operators: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// MergeOperators вливает операторов-источников в целевого: написания и полеты переходят
+// к целевому, источники остаются с пометкой merged_into ради своих ключей
+func (r *Repository) MergeOperators(ctx context.Context, m model.OperatorMerge) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var n int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM operators
+		WHERE merged_into IS NULL AND (id = $1 OR id = ANY($2::int[]))
+	`, m.Target, m.Sources).Scan(&n)
+	if err != nil {
+		return fmt.Errorf("failed to check operators: %w", err)
+	}
+	if n != len(m.Sources)+1 {
+		return model.ErrNotFound
+	}
+	queries := []string{
+		`UPDATE operators SET
+			phones = ARRAY(SELECT DISTINCT p FROM operators s, unnest(s.phones) AS p WHERE s.id = $1 OR s.id = ANY($2::int[])),
+			organizations = ARRAY(SELECT DISTINCT o FROM operators s, unnest(s.organizations) AS o WHERE s.id = $1 OR s.id = ANY($2::int[]))
+		WHERE id = $1`,
+		`UPDATE operators SET merged_into = $1 WHERE id = ANY($2::int[]) OR merged_into = ANY($2::int[])`,
+		`UPDATE operator_aliases SET operator_id = $1, method = 'manual', score = 1 WHERE operator_id = ANY($2::int[])`,
+		`UPDATE messages SET operator_id = $1 WHERE operator_id = ANY($2::int[])`,
+	}
+	for _, q := range queries {
+		if _, err = tx.Exec(ctx, q, m.Target, m.Sources); err != nil {
+			return fmt.Errorf("failed to merge operators: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// SplitOperator заводит оператора split по ключу и переносит к нему написания raws
+// оператора id вместе с полетами; у оператора id остаются телефоны и организации rest
+func (r *Repository) SplitOperator(ctx context.Context, id int, raws []string, split, rest model.OperatorAlias) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var newID int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO operators(key, name, phones, organizations)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO NOTHING
+		RETURNING id
+	`, split.Key, split.Name, split.Phones, split.Organizations).Scan(&newID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, model.ErrOperatorExists
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to create operator: %w", err)
+	}
+	tag, err := tx.Exec(ctx, `
+		UPDATE operator_aliases SET operator_id = $1, method = 'manual', score = 1
+		WHERE operator_id = $2 AND raw = ANY($3::text[])
+	`, newID, id, raws)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move aliases: %w", err)
+	}
+	if int(tag.RowsAffected()) != len(raws) {
+		return 0, model.ErrNotFound
+	}
+	if _, err = tx.Exec(ctx, `
+		UPDATE messages SET operator_id = $1 WHERE operator_id = $2 AND TRIM(opr) = ANY($3::text[])
+	`, newID, id, raws); err != nil {
+		return 0, fmt.Errorf("failed to move flights: %w", err)
+	}
+	if _, err = tx.Exec(ctx, `UPDATE operators SET phones = $2, organizations = $3 WHERE id = $1`,
+		id, rest.Phones, rest.Organizations); err != nil {
+		return 0, fmt.Errorf("failed to update operator: %w", err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return newID, nil
+}
+
+// operatorFlightsCTE — полеты операторов с вылетом в периоде ($1..$2, местное время региона),
+// в регионе $3 (0 — все)
+const operatorFlightsCTE = `
+	WITH op_flights AS (
+		SELECT
+			m.operator_id, m.id AS message_id, m.sid, m.dof, m.atd, m.ata, m.atd_local, m.ata_local,
+			COALESCE(m.region, 0) AS region, m.opr, m.reg, m.typ, m.min_alt, m.max_alt,
+			ST_Distance(m.dep_coordinate, m.arr_coordinate) / 1000 AS distance_km
+		FROM messages_local m
+		WHERE m.operator_id IS NOT NULL
+			AND m.atd_local::date BETWEEN $1::date AND $2::date
+			AND ($3::int = 0 OR m.region = $3::int)
+	)
+`
+
+// operatorOrder — допустимые порядки списка операторов
+var operatorOrder = map[string]string{
+	"flights":  "COUNT(*) DESC",
+	"distance": "COALESCE(SUM(f.distance_km), 0) DESC",
+	"regions":  "COUNT(DISTINCT f.region) DESC, COUNT(*) DESC",
+}
+
+// GetOperatorStats возвращает показатели операторов за период. operatorID > 0 ограничивает
+// выборку одним оператором.
+func (r *Repository) GetOperatorStats(ctx context.Context, f model.OperatorFilter, operatorID int) ([]model.OperatorStats, error) {
+	order, ok := operatorOrder[f.Order]
+	if !ok {
+		order = operatorOrder["flights"]
+	}
+	query := operatorFlightsCTE + fmt.Sprintf(`
+		SELECT
+			o.id, o.name, o.phones, o.organizations,
+			COUNT(*), COUNT(DISTINCT f.region) FILTER (WHERE f.region <> 0),
+			COALESCE(SUM(f.distance_km), 0),
+			COALESCE(AVG(EXTRACT(EPOCH FROM (f.ata_local - f.atd_local)) / 60) FILTER (WHERE f.ata IS NOT NULL), 0),
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY f.min_alt),
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY f.max_alt),
+			MIN(f.dof), MAX(f.dof)
+		FROM op_flights f
+		JOIN operators o ON o.id = f.operator_id
+		WHERE ($4::int = 0 OR o.id = $4::int)
+			AND ($5 = ''
+				OR o.name ILIKE '%%' || $5 || '%%'
+				OR (regexp_replace($5, '\D', '', 'g') <> ''
+					AND array_to_string(o.phones, ' ') LIKE '%%' || regexp_replace($5, '\D', '', 'g') || '%%'))
+		GROUP BY o.id
+		ORDER BY %s, o.id
+		LIMIT $6 OFFSET $7
+	`, order)
+	rows, err := r.db.Query(ctx, query,
+		f.Period.From, f.Period.To, f.RegionID, operatorID, strings.TrimSpace(f.Query), f.Limit, f.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operator stats: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.OperatorStats{}
+	index := make(map[int]int)
+	for rows.Next() {
+		var s model.OperatorStats
+		if err := rows.Scan(
+			&s.ID, &s.Name, &s.Phones, &s.Organizations,
+			&s.Flights, &s.Regions, &s.DistanceKm, &s.AvgDurationMinutes,
+			&s.MedianMinAlt, &s.MedianMaxAlt, &s.FirstFlight, &s.LastFlight,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		s.Hours = make([]int, 24)
+		index[s.ID] = len(res)
+		res = append(res, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	rows.Close()
+	if len(res) == 0 {
+		return res, nil
+	}
+
+	ids := make([]int, 0, len(res))
+	for id := range index {
+		ids = append(ids, id)
+	}
+	rows, err = r.db.Query(ctx, operatorFlightsCTE+`
+		SELECT operator_id, EXTRACT(HOUR FROM atd_local)::int, COUNT(*)
+		FROM op_flights
+		WHERE operator_id = ANY($4::int[])
+		GROUP BY 1, 2
+	`, f.Period.From, f.Period.To, f.RegionID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operator hours: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, hour, n int
+		if err := rows.Scan(&id, &hour, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res[index[id]].Hours[hour] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// GetOperator возвращает оператора с известными регистрационными номерами и написаниями OPR/
+func (r *Repository) GetOperator(ctx context.Context, id int) (model.Operator, error) {
+	query := `
+		SELECT
+			o.id, o.name, o.phones, o.organizations,
+			COALESCE((
+				SELECT array_agg(DISTINCT TRIM(m.reg) ORDER BY TRIM(m.reg))
+				FROM messages m
+				WHERE m.operator_id = o.id AND COALESCE(TRIM(m.reg), '') <> ''
+			), '{}')
+		FROM operators o
+		WHERE o.id = $1 AND o.merged_into IS NULL
+	`
+	var o model.Operator
+	err := r.db.QueryRow(ctx, query, id).Scan(&o.ID, &o.Name, &o.Phones, &o.Organizations, &o.Registrations)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return o, model.ErrNotFound
+	}
+	if err != nil {
+		return o, fmt.Errorf("failed to query operator: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT a.raw, a.method, a.score, (SELECT COUNT(*) FROM messages m WHERE TRIM(m.opr) = a.raw)
+		FROM operator_aliases a
+		WHERE a.operator_id = $1
+		ORDER BY a.raw
+	`, id)
+	if err != nil {
+		return o, fmt.Errorf("failed to query operator aliases: %w", err)
+	}
+	defer rows.Close()
+	o.Aliases = []model.OperatorAliasInfo{}
+	for rows.Next() {
+		var a model.OperatorAliasInfo
+		if err := rows.Scan(&a.Raw, &a.Method, &a.Score, &a.Flights); err != nil {
+			return o, fmt.Errorf("failed to scan row: %w", err)
+		}
+		o.Aliases = append(o.Aliases, a)
+	}
+	if err := rows.Err(); err != nil {
+		return o, fmt.Errorf("row iteration error: %w", err)
+	}
+	return o, nil
+}
+
+// GetOperatorBreakdown возвращает полеты оператора за период по регионам и типам БВС
+func (r *Repository) GetOperatorBreakdown(ctx context.Context, id int, p model.Period) ([]model.OperatorRegion, map[string]int, error) {
+	regions := []model.OperatorRegion{}
+	rows, err := r.db.Query(ctx, operatorFlightsCTE+`
+		SELECT f.region, COALESCE(ds.name_ru, ds.name, ''), COUNT(*)
+		FROM op_flights f
+		LEFT JOIN district_shapes ds ON ds.gid = f.region
+		WHERE f.operator_id = $4
+		GROUP BY f.region, ds.name_ru, ds.name
+		ORDER BY COUNT(*) DESC, f.region
+	`, p.From, p.To, 0, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query operator regions: %w", err)
+	}
+	for rows.Next() {
+		var reg model.OperatorRegion
+		if err := rows.Scan(&reg.RegionID, &reg.RegionName, &reg.Flights); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		regions = append(regions, reg)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	types := make(map[string]int)
+	rows, err = r.db.Query(ctx, operatorFlightsCTE+`
+		SELECT COALESCE(NULLIF(TRIM(typ), ''), 'unknown'), COUNT(*)
+		FROM op_flights
+		WHERE operator_id = $4
+		GROUP BY 1
+	`, p.From, p.To, 0, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query operator types: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var typ string
+		var n int
+		if err := rows.Scan(&typ, &n); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		types[typ] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return regions, types, nil
+}
+
+// GetOperatorFlights возвращает полеты оператора за период, новые первыми
+func (r *Repository) GetOperatorFlights(ctx context.Context, id int, f model.OperatorFilter) ([]model.OperatorFlight, error) {
+	query := operatorFlightsCTE + `
+		SELECT
+			f.message_id, f.sid, f.dof, to_char(f.atd, 'HH24:MI'), COALESCE(to_char(f.ata, 'HH24:MI'), ''),
+			f.region, COALESCE(ds.name_ru, ds.name, ''),
+			COALESCE(f.opr, ''), COALESCE(f.reg, ''), COALESCE(f.typ, ''), f.min_alt, f.max_alt, f.distance_km
+		FROM op_flights f
+		LEFT JOIN district_shapes ds ON ds.gid = f.region
+		WHERE f.operator_id = $4
+		ORDER BY f.dof DESC, f.atd DESC, f.message_id
+		LIMIT $5 OFFSET $6
+	`
+	rows, err := r.db.Query(ctx, query, f.Period.From, f.Period.To, f.RegionID, id, f.Limit, f.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operator flights: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.OperatorFlight{}
+	for rows.Next() {
+		var fl model.OperatorFlight
+		if err := rows.Scan(
+			&fl.MessageID, &fl.SID, &fl.DOF, &fl.ATD, &fl.ATA,
+			&fl.RegionID, &fl.RegionName,
+			&fl.OPR, &fl.REG, &fl.TYP, &fl.MinAlt, &fl.MaxAlt, &fl.DistanceKm,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, fl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
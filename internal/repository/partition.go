@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// GetDirtyPartitions возвращает регионы/годы/месяцы, помеченные триггером на messages для пересчета
+func (r *Repository) GetDirtyPartitions(ctx context.Context) ([]model.MetricsPartition, error) {
+	query := `
+		SELECT region_code, year, month, reason, marked_at, file_ids
+		FROM metrics_dirty_partitions
+		ORDER BY year, region_code, month
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dirty partitions: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.MetricsPartition{}
+	for rows.Next() {
+		var p model.MetricsPartition
+		if err := rows.Scan(&p.RegionID, &p.Year, &p.Month, &p.Reason, &p.MarkedAt, &p.FileIDs); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// ClearDirtyPartitions снимает отметку с пересчитанных партиций. Партиции, помеченные
+// повторно во время пересчета (marked_at позже прочитанного), остаются в очереди.
+func (r *Repository) ClearDirtyPartitions(ctx context.Context, parts []model.MetricsPartition) error {
+	if len(parts) == 0 {
+		return nil
+	}
+	regions := make([]int, len(parts))
+	years := make([]int, len(parts))
+	months := make([]int, len(parts))
+	marked := make([]time.Time, len(parts))
+	for i, p := range parts {
+		regions[i], years[i], months[i], marked[i] = p.RegionID, p.Year, p.Month, p.MarkedAt
+	}
+	query := `
+		DELETE FROM metrics_dirty_partitions d
+		USING unnest($1::int[], $2::int[], $3::int[], $4::timestamp[]) AS c(region_code, year, month, marked_at)
+		WHERE d.region_code = c.region_code AND d.year = c.year AND d.month = c.month
+			AND d.marked_at <= c.marked_at
+	`
+	if _, err := r.db.Exec(ctx, query, regions, years, months, marked); err != nil {
+		return fmt.Errorf("failed to clear dirty partitions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/paulmach/orb/encoding/wkb"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// scopedCTE отбирает полеты периода для каждой запрошенной области: scope_id = 0 — вся РФ,
+// $9 (AreaScopeID) — произвольная область ($5 — WKB, $6 — режим crossing, $7 — зона),
+// иначе код региона. Один полет может попасть в несколько областей. atd_at/ata_at — время
+// вылета и посадки в зоне области: опорной ($8) для всей РФ, местной для региона, $7 для
+// произвольной области. В режиме crossing полет попадает в область и пролетом: зоной ZONA
+// (выпуклой оболочкой flight_coordinates) или прямой вылет–посадка, как при проверке зон.
+// Источник полетов подставляется вместо %[1]s: messages_local или messages_local_all.
+const scopedCTE = `
+	WITH scoped AS (
+		SELECT 0 AS scope_id, m.sid, m.atd_ref AS atd_at, m.ata_ref AS ata_at, m.ata,
+			m.dep_coordinate, m.arr_coordinate, m.light_condition
+		FROM %[1]s m
+		WHERE $3::bool AND m.atd_ref::date BETWEEN $1::date AND $2::date
+		UNION ALL
+		SELECT m.region AS scope_id, m.sid, m.atd_local, m.ata_local, m.ata,
+			m.dep_coordinate, m.arr_coordinate, m.light_condition
+		FROM %[1]s m
+		WHERE m.region = ANY($4::int[]) AND m.atd_local::date BETWEEN $1::date AND $2::date
+		UNION ALL
+		SELECT $9::int AS scope_id, m.sid,
+			(m.atd_ref AT TIME ZONE $8::text) AT TIME ZONE $7::text,
+			(m.ata_ref AT TIME ZONE $8::text) AT TIME ZONE $7::text,
+			m.ata, m.dep_coordinate, m.arr_coordinate, m.light_condition
+		FROM %[1]s m
+		WHERE $5::bytea IS NOT NULL
+			AND ((m.atd_ref AT TIME ZONE $8::text) AT TIME ZONE $7::text)::date BETWEEN $1::date AND $2::date
+			AND (ST_Intersects(m.dep_coordinate, ST_GeomFromWKB($5::bytea, 4326)::geography)
+				OR ($6::bool AND (
+					EXISTS (
+						SELECT 1 FROM flight_coordinates fc WHERE fc.sid = m.sid
+						HAVING ST_Intersects(
+							ST_ConvexHull(ST_Collect(fc.coordinate::geometry)),
+							ST_GeomFromWKB($5::bytea, 4326)))
+					OR (m.arr_coordinate IS NOT NULL AND ST_Intersects(
+						ST_MakeLine(m.dep_coordinate::geometry, m.arr_coordinate::geometry),
+						ST_GeomFromWKB($5::bytea, 4326))))))
+	)
+`
+
+// GetPeriodMetrics считает полный набор метрик за произвольный период для регионов фильтра.
+// Результат — по коду региона (0 — вся РФ); MonthlyGrowth — по номеру месяца с нуля.
+func (r *Repository) GetPeriodMetrics(ctx context.Context, f model.MetricsFilter) (map[int]*model.Metrics, error) {
+	res := make(map[int]*model.Metrics, len(f.RegionIDs))
+	scopes := []int{}
+	regions := []int{}
+	all := false
+	for _, id := range f.RegionIDs {
+		if _, ok := res[id]; ok {
+			continue
+		}
+		res[id] = &model.Metrics{RegionId: id, MonthlyGrowth: map[int]float64{}, ZeroFlightDays: []time.Time{}}
+		scopes = append(scopes, id)
+		if id == 0 {
+			all = true
+		} else {
+			regions = append(regions, id)
+		}
+	}
+	var area []byte
+	crossing, tz := false, model.ReferenceTimeZone
+	if f.Area != nil {
+		res[model.AreaScopeID] = &model.Metrics{RegionId: model.AreaScopeID, MonthlyGrowth: map[int]float64{}, ZeroFlightDays: []time.Time{}}
+		scopes = append(scopes, model.AreaScopeID)
+		var err error
+		if area, err = wkb.Marshal(f.Area.Geometry); err != nil {
+			return nil, fmt.Errorf("failed to encode area: %w", err)
+		}
+		crossing, tz = f.Area.Mode == model.AreaCrossing, f.Area.TimeZone
+	}
+	if len(scopes) == 0 {
+		return res, nil
+	}
+	args := []interface{}{f.From, f.To, all, regions, area, crossing, tz, model.ReferenceTimeZone, model.AreaScopeID}
+	// по умолчанию неправдоподобные полеты исключены представлением messages_local
+	cte := fmt.Sprintf(scopedCTE, "messages_local")
+	if f.IncludeImplausible {
+		cte = fmt.Sprintf(scopedCTE, "messages_local_all")
+	}
+
+	query := func(name, tail string, extra []interface{}, scan func(rows pgx.Rows) error) error {
+		rows, err := r.db.Query(ctx, cte+tail, append(slices.Clone(args), extra...)...)
+		if err != nil {
+			return fmt.Errorf("failed to query %s: %w", name, err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			if err := scan(rows); err != nil {
+				return fmt.Errorf("failed to scan %s: %w", name, err)
+			}
+		}
+		return rows.Err()
+	}
+
+	err := query("total flight", `
+		SELECT
+			scope_id,
+			COUNT(DISTINCT sid),
+			COALESCE(AVG(EXTRACT(EPOCH FROM (ata_at - atd_at)) / 60), 0)
+		FROM scoped
+		WHERE ata IS NOT NULL AND arr_coordinate IS NOT NULL
+		GROUP BY scope_id
+	`, nil, func(rows pgx.Rows) error {
+		var id int
+		var total int
+		var avg float32
+		if err := rows.Scan(&id, &total, &avg); err != nil {
+			return err
+		}
+		res[id].TotalFlight, res[id].AvgDurationMinutes = total, avg
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// площадь РФ — регионы федеральных округов, как в рейтинге: без Сумской обл.
+	// и дубликата Севастополя из шейп-файла
+	err = query("flight density", `
+		SELECT 0, SUM(area_km2) FROM district_shapes
+		WHERE $3::bool AND federal_district_id IS NOT NULL HAVING $3::bool
+		UNION ALL
+		SELECT gid, area_km2 FROM district_shapes WHERE gid = ANY($4::int[])
+		UNION ALL
+		SELECT $9::int, ST_Area(ST_GeomFromWKB($5::bytea, 4326)::geography) / 1e6 WHERE $5::bytea IS NOT NULL
+	`, nil, func(rows pgx.Rows) error {
+		var id int
+		var area *float64
+		if err := rows.Scan(&id, &area); err != nil {
+			return err
+		}
+		if area != nil && *area > 0 {
+			res[id].FlightDensity = float64(res[id].TotalFlight) / (*area / 1000)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = query("peak load", `
+		SELECT scope_id, MAX(hourly_count)
+		FROM (
+			SELECT scope_id, DATE_TRUNC('hour', atd_at) AS hour, COUNT(*) AS hourly_count
+			FROM scoped
+			WHERE ata IS NOT NULL
+			GROUP BY scope_id, DATE_TRUNC('hour', atd_at)
+		) hourly_load
+		GROUP BY scope_id
+	`, nil, func(rows pgx.Rows) error {
+		var id, peak int
+		if err := rows.Scan(&id, &peak); err != nil {
+			return err
+		}
+		res[id].PeakLoad = peak
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = query("flight times", `
+		SELECT
+			scope_id,
+			SUM(CASE WHEN EXTRACT(HOUR FROM atd_at) BETWEEN 6 AND 11 THEN 1 ELSE 0 END),
+			SUM(CASE WHEN EXTRACT(HOUR FROM atd_at) BETWEEN 12 AND 17 THEN 1 ELSE 0 END),
+			SUM(CASE WHEN EXTRACT(HOUR FROM atd_at) BETWEEN 18 AND 23 THEN 1 ELSE 0 END),
+			SUM(CASE WHEN EXTRACT(HOUR FROM atd_at) BETWEEN 0 AND 5 THEN 1 ELSE 0 END)
+		FROM scoped
+		WHERE ata IS NOT NULL
+		GROUP BY scope_id
+	`, nil, func(rows pgx.Rows) error {
+		var id int
+		m := &model.Metrics{}
+		if err := rows.Scan(&id, &m.MorningFlights, &m.DayFlights, &m.EveningFlights, &m.NightFlights); err != nil {
+			return err
+		}
+		res[id].MorningFlights, res[id].DayFlights = m.MorningFlights, m.DayFlights
+		res[id].EveningFlights, res[id].NightFlights = m.EveningFlights, m.NightFlights
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = query("light conditions", `
+		SELECT
+			scope_id,
+			COUNT(*) FILTER (WHERE light_condition = 'day'),
+			COUNT(*) FILTER (WHERE light_condition = 'twilight'),
+			COUNT(*) FILTER (WHERE light_condition = 'night')
+		FROM scoped
+		WHERE ata IS NOT NULL
+		GROUP BY scope_id
+	`, nil, func(rows pgx.Rows) error {
+		var id, day, twilight, dark int
+		if err := rows.Scan(&id, &day, &twilight, &dark); err != nil {
+			return err
+		}
+		res[id].SetLightConditions(day, twilight, dark)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = query("daily flight metrics", `
+		, daily_flights AS (
+			SELECT
+				scope_id,
+				ata_at::date AS flight_date,
+				COUNT(sid) AS daily_flight_count
+			FROM scoped
+			WHERE ata IS NOT NULL
+			GROUP BY scope_id, ata_at::date
+		)
+		SELECT
+			scope_id,
+			AVG(daily_flight_count),
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY daily_flight_count)
+		FROM daily_flights
+		GROUP BY scope_id
+	`, nil, func(rows pgx.Rows) error {
+		var id int
+		var avg, median float64
+		if err := rows.Scan(&id, &avg, &median); err != nil {
+			return err
+		}
+		res[id].AvgDailyFlights, res[id].MedianDailyFlights = avg, median
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// рост к предыдущему месяцу считается так же, как в GetMonthlyGrowth
+	prev := make(map[int]int)
+	err = query("monthly growth", `
+		SELECT scope_id, EXTRACT(MONTH FROM DATE_TRUNC('month', atd_at))::int, COUNT(sid)
+		FROM scoped
+		WHERE ata IS NOT NULL
+		GROUP BY scope_id, DATE_TRUNC('month', atd_at)
+		ORDER BY scope_id, DATE_TRUNC('month', atd_at)
+	`, nil, func(rows pgx.Rows) error {
+		var id, month, count int
+		if err := rows.Scan(&id, &month, &count); err != nil {
+			return err
+		}
+		growth := 0.0
+		if p, ok := prev[id]; ok && p > 0 {
+			growth = float64(count-p) / float64(p) * 100
+		}
+		res[id].MonthlyGrowth[month-1] = growth
+		prev[id] = count
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = query("zero flight days", `
+		SELECT s.scope_id, COALESCE(ARRAY_AGG(d.day::date ORDER BY d.day) FILTER (WHERE NOT EXISTS (
+			SELECT 1 FROM scoped m
+			WHERE m.scope_id = s.scope_id AND m.atd_at::date = d.day::date AND m.ata IS NOT NULL
+		)), '{}')
+		FROM unnest($10::int[]) AS s(scope_id)
+		CROSS JOIN generate_series($1::date, $2::date, INTERVAL '1 day') AS d(day)
+		GROUP BY s.scope_id
+	`, []interface{}{scopes}, func(rows pgx.Rows) error {
+		var id int
+		var days []time.Time
+		if err := rows.Scan(&id, &days); err != nil {
+			return err
+		}
+		res[id].ZeroFlightDays = days
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = query("total distance", `
+		, all_coordinates AS (
+			SELECT scope_id, sid, dep_coordinate AS coordinate, 0 AS coord_order
+			FROM scoped
+			WHERE ata IS NOT NULL AND arr_coordinate IS NOT NULL
+			UNION ALL
+			SELECT m.scope_id, m.sid, fc.coordinate, fc.id AS coord_order
+			FROM scoped m
+			JOIN flight_coordinates fc ON fc.sid = m.sid
+			WHERE m.ata IS NOT NULL AND m.arr_coordinate IS NOT NULL
+			UNION ALL
+			SELECT scope_id, sid, arr_coordinate, 999999999 AS coord_order
+			FROM scoped
+			WHERE ata IS NOT NULL AND arr_coordinate IS NOT NULL
+		),
+		coordinate_pairs AS (
+			SELECT
+				scope_id,
+				coordinate AS start_coord,
+				LEAD(coordinate) OVER (PARTITION BY scope_id, sid ORDER BY coord_order) AS end_coord
+			FROM all_coordinates
+		)
+		SELECT scope_id, COALESCE(SUM(ST_Distance(geography(start_coord), geography(end_coord)) / 1000), 0)
+		FROM coordinate_pairs
+		WHERE end_coord IS NOT NULL
+		GROUP BY scope_id
+	`, nil, func(rows pgx.Rows) error {
+		var id int
+		var km float64
+		if err := rows.Scan(&id, &km); err != nil {
+			return err
+		}
+		res[id].TotalDistance = km
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/paulmach/orb"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// GetUncheckedFlights возвращает до limit полетов, ожидающих проверки правдоподобности
+func (r *Repository) GetUncheckedFlights(ctx context.Context, limit int) ([]model.FlightKinematics, error) {
+	query := `
+		SELECT
+			id, to_char(atd, 'HH24:MI'), COALESCE(to_char(ata, 'HH24:MI'), ''),
+			ST_X(dep_coordinate::geometry), ST_Y(dep_coordinate::geometry),
+			COALESCE(arr_coords_normalize, '') <> '' AND arr_coordinate IS NOT NULL,
+			COALESCE(ST_X(arr_coordinate::geometry), 0), COALESCE(ST_Y(arr_coordinate::geometry), 0)
+		FROM messages
+		WHERE NOT plausibility_checked AND dep_coordinate IS NOT NULL
+		ORDER BY id
+		LIMIT $1
+	`
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unchecked flights: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.FlightKinematics{}
+	for rows.Next() {
+		var k model.FlightKinematics
+		var hasArr bool
+		var arr orb.Point
+		if err := rows.Scan(
+			&k.ID, &k.ATD, &k.ATA,
+			&k.DepLatLon[0], &k.DepLatLon[1],
+			&hasArr, &arr[0], &arr[1],
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if hasArr {
+			k.ArrLatLon = &arr
+		}
+		res = append(res, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// SetPlausibility сохраняет результат проверки правдоподобности по id полета
+func (r *Repository) SetPlausibility(ctx context.Context, res []model.Plausibility) error {
+	if len(res) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(res))
+	reasons := make([]string, 0, len(res))
+	speeds := make([]*float64, 0, len(res))
+	for _, p := range res {
+		ids = append(ids, p.ID)
+		// массив причин передается строкой-литералом: unnest не работает с массивами разной длины
+		reasons = append(reasons, textArrayLiteral(p.Reasons))
+		speeds = append(speeds, p.ImpliedSpeedKmh)
+	}
+	query := `
+		UPDATE messages m SET
+			implausible_reasons = c.reasons::text[],
+			implied_speed_kmh = c.speed,
+			plausibility_checked = true
+		FROM unnest($1::int[], $2::text[], $3::float8[]) AS c(id, reasons, speed)
+		WHERE m.id = c.id
+	`
+	if _, err := r.db.Exec(ctx, query, ids, reasons, speeds); err != nil {
+		return fmt.Errorf("failed to set plausibility: %w", err)
+	}
+	return nil
+}
+
+// textArrayLiteral собирает литерал text[] из кодов причин (коды — латиница без спецсимволов)
+func textArrayLiteral(values []string) string {
+	return "{" + strings.Join(values, ",") + "}"
+}
+
+// implausibleCTE — полеты периода ($1..$2, местное время региона) с отметками правдоподобности
+const implausibleCTE = `
+	WITH flights AS (
+		SELECT
+			m.id AS message_id, m.sid, m.dof, m.atd, m.ata, m.file_id,
+			COALESCE(m.region, 0) AS region_id, COALESCE(ds.name_ru, ds.name, '') AS region_name,
+			COALESCE(NULLIF(TRIM(m.opr), ''), '') AS operator,
+			m.implausible_reasons, m.implied_speed_kmh, m.plausibility_checked
+		FROM messages_local_all m
+		LEFT JOIN district_shapes ds ON ds.gid = m.region
+		WHERE m.atd_local::date BETWEEN $1::date AND $2::date
+			AND ($3::int = 0 OR m.region = $3::int)
+	)
+`
+
+// GetQualityReport считает, сколько полетов периода исключено из метрик по каждой причине,
+// и возвращает страницу исключенных полетов
+func (r *Repository) GetQualityReport(ctx context.Context, f model.QualityFilter) (model.QualityReport, error) {
+	res := model.QualityReport{
+		Period:   f.Period,
+		RegionID: f.RegionID,
+		ByReason: map[string]int{},
+		Items:    []model.ImplausibleFlight{},
+	}
+	args := []interface{}{f.Period.From, f.Period.To, f.RegionID}
+
+	err := r.db.QueryRow(ctx, implausibleCTE+`
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE cardinality(implausible_reasons) > 0),
+			COUNT(*) FILTER (WHERE NOT plausibility_checked)
+		FROM flights
+	`, args...).Scan(&res.Flights, &res.Implausible, &res.Pending)
+	if err != nil {
+		return res, fmt.Errorf("failed to query quality totals: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, implausibleCTE+`
+		SELECT reason, COUNT(*)
+		FROM flights, unnest(implausible_reasons) AS reason
+		GROUP BY reason
+	`, args...)
+	if err != nil {
+		return res, fmt.Errorf("failed to query quality reasons: %w", err)
+	}
+	for rows.Next() {
+		var reason string
+		var n int
+		if err := rows.Scan(&reason, &n); err != nil {
+			rows.Close()
+			return res, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res.ByReason[reason] = n
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return res, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	rows, err = r.db.Query(ctx, implausibleCTE+`
+		SELECT
+			message_id, sid, dof, to_char(atd, 'HH24:MI'), COALESCE(to_char(ata, 'HH24:MI'), ''),
+			region_id, region_name, operator, file_id, implausible_reasons, implied_speed_kmh
+		FROM flights
+		WHERE cardinality(implausible_reasons) > 0
+			AND ($4 = '' OR $4 = ANY(implausible_reasons))
+		ORDER BY dof DESC, atd DESC, message_id
+		LIMIT $5 OFFSET $6
+	`, append(args, f.Reason, f.Limit, f.Offset)...)
+	if err != nil {
+		return res, fmt.Errorf("failed to query implausible flights: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fl model.ImplausibleFlight
+		if err := rows.Scan(
+			&fl.MessageID, &fl.SID, &fl.DOF, &fl.ATD, &fl.ATA,
+			&fl.RegionID, &fl.RegionName, &fl.Operator, &fl.FileID, &fl.Reasons, &fl.ImpliedSpeedKmh,
+		); err != nil {
+			return res, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res.Items = append(res.Items, fl)
+	}
+	if err := rows.Err(); err != nil {
+		return res, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
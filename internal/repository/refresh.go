@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// CreateRefreshRun сохраняет запись о начатом пересчете метрик
+func (r *Repository) CreateRefreshRun(ctx context.Context, kind string) (model.RefreshRun, error) {
+	run := model.RefreshRun{Kind: kind, Status: model.RefreshStatusRunning, Failures: []model.RefreshFailure{}}
+	query := `
+		INSERT INTO metrics_refresh_runs(kind, status)
+		VALUES ($1, $2)
+		RETURNING id, started_at
+	`
+	if err := r.db.QueryRow(ctx, query, kind, run.Status).Scan(&run.ID, &run.StartedAt); err != nil {
+		return run, fmt.Errorf("failed to create refresh run: %w", err)
+	}
+	return run, nil
+}
+
+// FinishRefreshRun сохраняет итог пересчета: статус, длительность и список ошибок
+func (r *Repository) FinishRefreshRun(ctx context.Context, run model.RefreshRun) error {
+	failures, err := json.Marshal(run.Failures)
+	if err != nil {
+		return fmt.Errorf("failed to marshal failures: %w", err)
+	}
+	query := `
+		UPDATE metrics_refresh_runs
+		SET status = $2, finished_at = $3, duration_ms = $4,
+			total_tasks = $5, failed_tasks = $6, failures = $7, file_ids = $8
+		WHERE id = $1
+	`
+	fileIDs := run.FileIDs
+	if fileIDs == nil {
+		fileIDs = []int{}
+	}
+	_, err = r.db.Exec(ctx, query, run.ID, run.Status, run.FinishedAt, run.DurationMs, run.Total, run.Failed, failures, fileIDs)
+	if err != nil {
+		return fmt.Errorf("failed to finish refresh run: %w", err)
+	}
+	return nil
+}
+
+// GetRefreshRuns возвращает последние запуски пересчета, начиная с самого нового
+func (r *Repository) GetRefreshRuns(ctx context.Context, limit int) ([]model.RefreshRun, error) {
+	query := `
+		SELECT id, kind, status, started_at, finished_at, duration_ms,
+			total_tasks, failed_tasks, COALESCE(failures, '[]'::jsonb), file_ids
+		FROM metrics_refresh_runs
+		ORDER BY started_at DESC, id DESC
+		LIMIT $1
+	`
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query refresh runs: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.RefreshRun{}
+	for rows.Next() {
+		var run model.RefreshRun
+		var failures []byte
+		err := rows.Scan(&run.ID, &run.Kind, &run.Status, &run.StartedAt, &run.FinishedAt, &run.DurationMs,
+			&run.Total, &run.Failed, &failures, &run.FileIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err := json.Unmarshal(failures, &run.Failures); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal failures: %w", err)
+		}
+		res = append(res, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+const snapshotColumns = `
+	s.run_id, r.kind, r.file_ids, s.created_at,
+	s.region_code, COALESCE(s.region_name, ''),
+	s.total_flight, s.avg_duration_minutes,
+	s.total_distance_km, s.peak_load,
+	s.avg_daily_flights, s.median_daily_flights,
+	s.monthly_growth, COALESCE(s.flight_density, 0),
+	COALESCE(s.morning_flights, 0), COALESCE(s.day_flights, 0),
+	COALESCE(s.evening_flights, 0), COALESCE(s.night_flights, 0),
+	s.zero_flight_days, s.date,
+	s.time_zone,
+	COALESCE(s.daylight_flights, 0), COALESCE(s.twilight_flights, 0),
+	COALESCE(s.dark_flights, 0), COALESCE(s.night_share, 0)
+`
+
+func scanSnapshot(row pgx.Row) (model.MetricsSnapshot, error) {
+	var snap model.MetricsSnapshot
+	var growth []byte
+	m := &snap.Metrics
+	err := row.Scan(
+		&snap.RunID, &snap.RunKind, &snap.FileIDs, &snap.CreatedAt,
+		&m.RegionId, &m.RegionName,
+		&m.TotalFlight, &m.AvgDurationMinutes,
+		&m.TotalDistance, &m.PeakLoad,
+		&m.AvgDailyFlights, &m.MedianDailyFlights,
+		&growth, &m.FlightDensity,
+		&m.MorningFlights, &m.DayFlights,
+		&m.EveningFlights, &m.NightFlights,
+		&m.ZeroFlightDays, &m.Year,
+		&m.TimeZone,
+		&m.DaylightFlights, &m.TwilightFlights,
+		&m.DarkFlights, &m.NightShare,
+	)
+	if err != nil {
+		return snap, err
+	}
+	if len(growth) > 0 {
+		if err := json.Unmarshal(growth, &m.MonthlyGrowth); err != nil {
+			return snap, fmt.Errorf("failed to unmarshal monthly growth: %w", err)
+		}
+	}
+	return snap, nil
+}
+
+// GetMetricsHistory возвращает все версии метрик региона за год, начиная с самой новой
+func (r *Repository) GetMetricsHistory(ctx context.Context, regionID int, year int) ([]model.MetricsSnapshot, error) {
+	query := `SELECT ` + snapshotColumns + `
+		FROM metric_snapshots s
+		JOIN metrics_refresh_runs r ON r.id = s.run_id
+		WHERE s.region_code = $1 AND s.date = $2
+		ORDER BY s.run_id DESC
+	`
+	rows, err := r.db.Query(ctx, query, regionID, year)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metrics history: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.MetricsSnapshot{}
+	for rows.Next() {
+		snap, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, snap)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// GetMetricsSnapshot возвращает метрики региона за год на момент пересчета runID —
+// последнюю версию, сохраненную этим или более ранним пересчетом
+func (r *Repository) GetMetricsSnapshot(ctx context.Context, regionID int, year int, runID int) (model.MetricsSnapshot, error) {
+	query := `SELECT ` + snapshotColumns + `
+		FROM metric_snapshots s
+		JOIN metrics_refresh_runs r ON r.id = s.run_id
+		WHERE s.region_code = $1 AND s.date = $2 AND s.run_id <= $3
+		ORDER BY s.run_id DESC
+		LIMIT 1
+	`
+	snap, err := scanSnapshot(r.db.QueryRow(ctx, query, regionID, year, runID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return snap, model.ErrNotFound
+	}
+	if err != nil {
+		return snap, fmt.Errorf("failed to get metrics snapshot: %w", err)
+	}
+	return snap, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// siteOrder — допустимые порядки ранжирования мест запуска
+var siteOrder = map[string]string{
+	"flights":     "s.flights DESC, s.operators DESC",
+	"operators":   "s.operators DESC, s.flights DESC",
+	"active_days": "s.active_days DESC, s.flights DESC",
+}
+
+// DetectLaunchSites пересчитывает каталог мест запуска и возвращает их число
+func (r *Repository) DetectLaunchSites(ctx context.Context, radius float64, minFlights int) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var n int
+	if err = tx.QueryRow(ctx, `SELECT detect_launch_sites($1, $2)`, radius, minFlights).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to detect launch sites: %w", err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// GetLaunchSites возвращает места запуска по фильтру в порядке ранжирования
+func (r *Repository) GetLaunchSites(ctx context.Context, f model.LaunchSiteFilter) ([]model.LaunchSite, error) {
+	order, ok := siteOrder[f.Order]
+	if !ok {
+		order = siteOrder["flights"]
+	}
+	query := fmt.Sprintf(`
+		SELECT
+			s.id, COALESCE(s.region, 0), COALESCE(ds.name_ru, ds.name, ''),
+			ST_Y(s.center), ST_X(s.center),
+			s.flights, s.operators, s.top_operators,
+			s.first_flight, s.last_flight, s.active_days, s.hours,
+			ST_Area(s.geom::geography) / 1e6, s.detected_at
+		FROM launch_sites s
+		LEFT JOIN district_shapes ds ON ds.gid = s.region
+		WHERE ($1::int = 0 OR s.region = $1::int)
+			AND s.flights >= $2
+		ORDER BY %s, s.id
+		LIMIT $3 OFFSET $4
+	`, order)
+	rows, err := r.db.Query(ctx, query, f.RegionID, f.MinFlights, f.Limit, f.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query launch sites: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.LaunchSite{}
+	for rows.Next() {
+		var s model.LaunchSite
+		if err := rows.Scan(
+			&s.ID, &s.RegionID, &s.RegionName, &s.Lat, &s.Lon,
+			&s.Flights, &s.Operators, &s.TopOperators,
+			&s.FirstFlight, &s.LastFlight, &s.ActiveDays, &s.Hours,
+			&s.AreaKm2, &s.DetectedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		s.SetPeakHour()
+		res = append(res, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// GetLaunchSitesGeoJSON возвращает FeatureCollection контуров мест запуска
+func (r *Repository) GetLaunchSitesGeoJSON(ctx context.Context, f model.LaunchSiteFilter) ([]byte, error) {
+	query := `
+		WITH features AS (
+			SELECT jsonb_build_object(
+				'type', 'Feature',
+				'id', s.id,
+				'geometry', ST_AsGeoJSON(s.geom, 6)::jsonb,
+				'properties', jsonb_build_object(
+					'id', s.id,
+					'region_id', s.region,
+					'region_name', COALESCE(ds.name_ru, ds.name),
+					'flights', s.flights,
+					'operators', s.operators,
+					'top_operators', s.top_operators,
+					'first_flight', s.first_flight,
+					'last_flight', s.last_flight,
+					'active_days', s.active_days,
+					'hours', s.hours
+				)
+			) AS feature
+			FROM launch_sites s
+			LEFT JOIN district_shapes ds ON ds.gid = s.region
+			WHERE ($1::int = 0 OR s.region = $1::int)
+				AND s.flights >= $2
+		)
+		SELECT jsonb_build_object('type', 'FeatureCollection', 'features', COALESCE(jsonb_agg(feature), '[]'::jsonb))
+		FROM features
+	`
+	var raw json.RawMessage
+	if err := r.db.QueryRow(ctx, query, f.RegionID, f.MinFlights).Scan(&raw); err != nil {
+		return nil, fmt.Errorf("failed to build launch sites: %w", err)
+	}
+	return raw, nil
+}
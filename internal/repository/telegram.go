@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// telegramFlight — сохраненный полет, с которым сопоставляются DEP/ARR
+type telegramFlight struct {
+	id  int
+	dof time.Time
+	atd string // чч:мм
+	reg string
+}
+
+// telegramMatches проверяет, что DEP/ARR относится к полету: дата и регистрация, если указаны,
+// должны совпадать. ARR несет дату посадки (ADA), поэтому посадка раньше времени вылета
+// относится к полету предыдущих суток, приземлившемуся после полуночи.
+func telegramMatches(tg model.Telegram, f telegramFlight) bool {
+	if tg.REG != "" && f.reg != "" && tg.REG != f.reg {
+		return false
+	}
+	if tg.DOF == "" {
+		return true
+	}
+	day, err := time.Parse(time.DateOnly, tg.DOF)
+	if err != nil {
+		return false
+	}
+	if f.dof.Equal(day) {
+		return true
+	}
+	return tg.Type == model.TelegramARR && f.dof.Equal(day.AddDate(0, 0, -1)) && tg.Time < f.atd
+}
+
+// telegramUpdate — изменение полета по DEP/ARR: новое время вылета требует пересчета
+// освещенности, любое новое время — проверки правдоподобности
+func telegramUpdate(typ string) string {
+	if typ == model.TelegramARR {
+		return `UPDATE messages SET ata = $2, plausibility_checked = false WHERE id = $1`
+	}
+	return `UPDATE messages SET atd = $2, light_condition = NULL, plausibility_checked = false WHERE id = $1`
+}
+
+// getTelegramFlight блокирует полет с данным SID до конца транзакции
+func getTelegramFlight(ctx context.Context, tx pgx.Tx, sid string) (telegramFlight, bool, error) {
+	var f telegramFlight
+	err := tx.QueryRow(ctx, `
+		SELECT id, dof, to_char(atd, 'HH24:MI'), COALESCE(reg, '')
+		FROM messages WHERE sid = $1
+		FOR UPDATE
+	`, sid).Scan(&f.id, &f.dof, &f.atd, &f.reg)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return f, false, nil
+	}
+	if err != nil {
+		return f, false, fmt.Errorf("failed to get flight: %w", err)
+	}
+	return f, true, nil
+}
+
+// ApplyTelegram сопоставляет DEP/ARR с сохраненным SHR по SID/DOF/REG и обновляет ATD или ATA.
+// Если SHR еще не пришел, телеграмма сохраняется со статусом pending.
+func (r *Repository) ApplyTelegram(ctx context.Context, tg model.Telegram) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	f, found, err := getTelegramFlight(ctx, tx, tg.SID)
+	if err != nil {
+		return false, err
+	}
+	matched := found && telegramMatches(tg, f)
+
+	status := "pending"
+	var msgID interface{}
+	if matched {
+		status = "matched"
+		msgID = f.id
+		if _, err = tx.Exec(ctx, telegramUpdate(tg.Type), f.id, tg.Time); err != nil {
+			return false, fmt.Errorf("failed to apply telegram: %w", err)
+		}
+		// окно действия зон зависит от времени вылета и посадки
+		if _, err = tx.Exec(ctx, `SELECT check_zone_violations($1, NULL)`, f.id); err != nil {
+			return false, fmt.Errorf("failed to check zone violations: %w", err)
+		}
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO telegrams(type, sid, dof, reg, event_time, raw, status, message_id)
+		VALUES ($1, $2, NULLIF($3, '')::date, NULLIF($4, ''), $5, $6, $7, $8)
+	`, tg.Type, tg.SID, tg.DOF, tg.REG, tg.Time, tg.Raw, status, msgID)
+	if err != nil {
+		return false, fmt.Errorf("failed to save telegram: %w", err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return matched, nil
+}
+
+// ResolvePendingTelegrams применяет ранее полученные DEP/ARR к только что сохраненному SHR.
+// Из нескольких подходящих телеграмм одного типа время берется из последней полученной.
+func (r *Repository) ResolvePendingTelegrams(ctx context.Context, sid string) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	f, found, err := getTelegramFlight(ctx, tx, sid)
+	if err != nil || !found {
+		return 0, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, type, COALESCE(to_char(dof, 'YYYY-MM-DD'), ''), COALESCE(reg, ''), to_char(event_time, 'HH24:MI')
+		FROM telegrams
+		WHERE sid = $1 AND status = 'pending'
+		ORDER BY received_at DESC, id DESC
+	`, sid)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending telegrams: %w", err)
+	}
+	var ids []int
+	latest := make(map[string]model.Telegram)
+	for rows.Next() {
+		var id int
+		tg := model.Telegram{SID: sid}
+		if err := rows.Scan(&id, &tg.Type, &tg.DOF, &tg.REG, &tg.Time); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if !telegramMatches(tg, f) {
+			continue
+		}
+		ids = append(ids, id)
+		if _, ok := latest[tg.Type]; !ok {
+			latest[tg.Type] = tg
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("row iteration error: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	for _, typ := range []string{model.TelegramDEP, model.TelegramARR} {
+		tg, ok := latest[typ]
+		if !ok {
+			continue
+		}
+		if _, err = tx.Exec(ctx, telegramUpdate(typ), f.id, tg.Time); err != nil {
+			return 0, fmt.Errorf("failed to resolve pending %s: %w", typ, err)
+		}
+	}
+	if _, err = tx.Exec(ctx, `
+		UPDATE telegrams SET status = 'matched', message_id = $1 WHERE id = ANY($2)
+	`, f.id, ids); err != nil {
+		return 0, fmt.Errorf("failed to mark telegrams matched: %w", err)
+	}
+	if _, err = tx.Exec(ctx, checkZonesBySID, sid); err != nil {
+		return 0, fmt.Errorf("failed to check zone violations: %w", err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
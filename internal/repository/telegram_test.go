@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+func TestTelegramMatches(t *testing.T) {
+	flight := telegramFlight{id: 1, dof: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), atd: "22:30", reg: "RA-1234"}
+	tests := []struct {
+		name string
+		tg   model.Telegram
+		want bool
+	}{
+		{"DEP same day", model.Telegram{Type: model.TelegramDEP, DOF: "2025-02-01", Time: "22:35"}, true},
+		{"DEP without date", model.Telegram{Type: model.TelegramDEP, Time: "22:35"}, true},
+		{"DEP other day", model.Telegram{Type: model.TelegramDEP, DOF: "2025-02-02", Time: "22:35"}, false},
+		{"ARR same day", model.Telegram{Type: model.TelegramARR, DOF: "2025-02-01", Time: "23:40"}, true},
+		{"ARR after midnight", model.Telegram{Type: model.TelegramARR, DOF: "2025-02-02", Time: "00:15"}, true},
+		{"ARR next day later than departure", model.Telegram{Type: model.TelegramARR, DOF: "2025-02-02", Time: "23:00"}, false},
+		{"ARR two days later", model.Telegram{Type: model.TelegramARR, DOF: "2025-02-03", Time: "00:15"}, false},
+		{"DEP next day before departure time", model.Telegram{Type: model.TelegramDEP, DOF: "2025-02-02", Time: "00:15"}, false},
+		{"same registration", model.Telegram{Type: model.TelegramDEP, DOF: "2025-02-01", REG: "RA-1234", Time: "22:35"}, true},
+		{"other registration", model.Telegram{Type: model.TelegramDEP, DOF: "2025-02-01", REG: "RA-9999", Time: "22:35"}, false},
+		{"invalid date", model.Telegram{Type: model.TelegramARR, DOF: "02.02.2025", Time: "00:15"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := telegramMatches(tt.tg, flight); got != tt.want {
+				t.Errorf("telegramMatches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	t.Run("flight without registration", func(t *testing.T) {
+		f := flight
+		f.reg = ""
+		tg := model.Telegram{Type: model.TelegramARR, DOF: "2025-02-02", REG: "RA-9999", Time: "00:15"}
+		if !telegramMatches(tg, f) {
+			t.Error("telegramMatches = false, want true")
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/paulmach/orb/encoding/wkb"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// checkZonesBySID перепроверяет полет по SID против всех зон; вызывается в транзакции
+// сохранения полета после записи точек ZONA
+const checkZonesBySID = `SELECT check_zone_violations(id, NULL) FROM messages WHERE sid = $1`
+
+// SaveZones сохраняет зоны одной транзакцией и возвращает их id
+func (r *Repository) SaveZones(ctx context.Context, zones []model.RestrictedZone) ([]int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO restricted_zones(
+			name, kind, min_alt, max_alt, active_from, active_to,
+			valid_from, valid_to, source, properties, geom
+		)
+		VALUES ($1, $2, $3, $4, $5::time, $6::time, $7, $8, $9, $10, ST_SetSRID(ST_GeomFromWKB($11), 4326))
+		RETURNING id
+	`
+	ids := make([]int, 0, len(zones))
+	for _, z := range zones {
+		props, err := json.Marshal(z.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal zone properties: %w", err)
+		}
+		var id int
+		err = tx.QueryRow(ctx, query,
+			z.Name, z.Kind, z.MinAlt, z.MaxAlt, z.ActiveFrom, z.ActiveTo,
+			z.ValidFrom, z.ValidTo, z.Source, props, wkb.Value(z.Geometry),
+		).Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save zone %q: %w", z.Name, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, tx.Commit(ctx)
+}
+
+// CheckZoneViolations перепроверяет полет против зоны и возвращает число найденных нарушений;
+// messageID = 0 — все полеты, zoneID = 0 — все зоны
+func (r *Repository) CheckZoneViolations(ctx context.Context, messageID, zoneID int) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx, `SELECT check_zone_violations(NULLIF($1, 0), NULLIF($2, 0))`, messageID, zoneID).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check zone violations: %w", err)
+	}
+	return n, nil
+}
+
+// GetZones возвращает все зоны с геометрией в GeoJSON и числом полетов-нарушителей
+func (r *Repository) GetZones(ctx context.Context) ([]model.RestrictedZone, error) {
+	query := `
+		SELECT
+			z.id, z.name, z.kind, z.min_alt, z.max_alt,
+			to_char(z.active_from, 'HH24:MI'), to_char(z.active_to, 'HH24:MI'),
+			z.valid_from, z.valid_to, COALESCE(z.source, ''), z.properties,
+			ST_AsGeoJSON(z.geom, 6)::jsonb, z.created_at,
+			(SELECT COUNT(DISTINCT v.message_id) FROM zone_violations v WHERE v.zone_id = z.id)
+		FROM restricted_zones z
+		ORDER BY z.id
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query zones: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.RestrictedZone{}
+	for rows.Next() {
+		var z model.RestrictedZone
+		if err := rows.Scan(
+			&z.ID, &z.Name, &z.Kind, &z.MinAlt, &z.MaxAlt,
+			&z.ActiveFrom, &z.ActiveTo,
+			&z.ValidFrom, &z.ValidTo, &z.Source, &z.Properties,
+			&z.GeoJSON, &z.CreatedAt, &z.Violations,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, z)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// DeleteZone удаляет зону вместе с ее нарушениями
+func (r *Repository) DeleteZone(ctx context.Context, id int) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM restricted_zones WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete zone: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// violationsCTE — нарушения полетов с вылетом в периоде ($1..$2, местное время региона)
+const violationsCTE = `
+	WITH violations AS (
+		SELECT
+			m.id AS message_id, m.sid, m.dof, m.atd, m.ata, m.min_alt, m.max_alt,
+			COALESCE(m.region, 0) AS region_id, COALESCE(ds.name_ru, ds.name, '') AS region_name,
+			COALESCE(NULLIF(TRIM(m.opr), ''), '') AS operator,
+			z.id AS zone_id, z.name AS zone_name, z.kind AS zone_kind, v.kind
+		FROM zone_violations v
+		JOIN restricted_zones z ON z.id = v.zone_id
+		JOIN messages_local m ON m.id = v.message_id
+		LEFT JOIN district_shapes ds ON ds.gid = m.region
+		WHERE m.atd_local::date BETWEEN $1::date AND $2::date
+	)
+`
+
+// GetViolations возвращает нарушения по фильтру: одна строка на пару полет–зона
+func (r *Repository) GetViolations(ctx context.Context, f model.ViolationFilter) ([]model.Violation, error) {
+	query := violationsCTE + `
+		SELECT
+			message_id, sid, dof, to_char(atd, 'HH24:MI'), COALESCE(to_char(ata, 'HH24:MI'), ''),
+			region_id, region_name, operator, min_alt, max_alt,
+			zone_id, zone_name, zone_kind, array_agg(kind ORDER BY kind)
+		FROM violations
+		WHERE ($3::int = 0 OR region_id = $3::int)
+			AND ($4::int = 0 OR zone_id = $4::int)
+			AND ($5 = '' OR operator ILIKE '%' || $5 || '%')
+		GROUP BY message_id, sid, dof, atd, ata, region_id, region_name, operator, min_alt, max_alt,
+			zone_id, zone_name, zone_kind
+		ORDER BY dof DESC, atd DESC, message_id, zone_id
+		LIMIT $6 OFFSET $7
+	`
+	rows, err := r.db.Query(ctx, query,
+		f.Period.From, f.Period.To, f.RegionID, f.ZoneID, strings.TrimSpace(f.Operator), f.Limit, f.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query violations: %w", err)
+	}
+	defer rows.Close()
+
+	res := []model.Violation{}
+	for rows.Next() {
+		var v model.Violation
+		if err := rows.Scan(
+			&v.MessageID, &v.SID, &v.DOF, &v.ATD, &v.ATA,
+			&v.RegionID, &v.RegionName, &v.Operator, &v.MinAlt, &v.MaxAlt,
+			&v.ZoneID, &v.ZoneName, &v.ZoneKind, &v.Kinds,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res = append(res, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return res, nil
+}
+
+// GetViolationStats считает нарушения за период по регионам (group = "region") или операторам
+func (r *Repository) GetViolationStats(ctx context.Context, p model.Period, group string) (model.ViolationStats, error) {
+	res := model.ViolationStats{Period: p, Group: group, Items: []model.ViolationCount{}}
+	key, name := "region_id::text", "region_name"
+	if group == "operator" {
+		key, name = "operator", "operator"
+	}
+	query := violationsCTE + fmt.Sprintf(`
+		SELECT
+			%s, %s,
+			COUNT(DISTINCT message_id),
+			COUNT(DISTINCT message_id) FILTER (WHERE zone_kind = '%s'),
+			COUNT(DISTINCT (message_id, zone_id)),
+			COUNT(DISTINCT zone_id)
+		FROM violations
+		GROUP BY 1, 2
+		ORDER BY 3 DESC, 1
+	`, key, name, model.ZoneNoFly)
+	rows, err := r.db.Query(ctx, query, p.From, p.To)
+	if err != nil {
+		return res, fmt.Errorf("failed to query violation stats: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c model.ViolationCount
+		if err := rows.Scan(&c.Key, &c.Name, &c.Flights, &c.NoFly, &c.Violations, &c.Zones); err != nil {
+			return res, fmt.Errorf("failed to scan row: %w", err)
+		}
+		res.Items = append(res.Items, c)
+	}
+	if err := rows.Err(); err != nil {
+		return res, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	err = r.db.QueryRow(ctx, violationsCTE+`SELECT COUNT(DISTINCT message_id) FROM violations`, p.From, p.To).Scan(&res.Total)
+	if err != nil {
+		return res, fmt.Errorf("failed to count violations: %w", err)
+	}
+	return res, nil
+}
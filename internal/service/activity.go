@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// Activity строит матрицу час × день недели для региона (0 — вся РФ) за период
+// в местном времени региона; для всей РФ — в опорной зоне
+func (s *MetricsService) Activity(ctx context.Context, regionID int, p model.Period) (model.ActivityMatrix, error) {
+	name, tz, err := s.regionZone(ctx, regionID)
+	if err != nil {
+		return model.ActivityMatrix{}, err
+	}
+	res, err := s.repo.GetActivityMatrix(ctx, regionID, p.From, p.To)
+	if err != nil {
+		return res, err
+	}
+	res.RegionName, res.Period, res.TimeZone = name, p, tz
+
+	// сколько раз каждый день недели встречается в периоде
+	var days [7]int
+	for d := p.From; !d.After(p.To); d = d.AddDate(0, 0, 1) {
+		days[(int(d.Weekday())+6)%7]++
+	}
+	for dow := range res.Active {
+		if days[dow] == 0 {
+			continue
+		}
+		for hour, n := range res.Active[dow] {
+			res.ActiveAvg[dow][hour] = float64(n) / float64(days[dow])
+		}
+	}
+	return res, nil
+}
+
+// regionZone возвращает название и часовой пояс региона; для 0 — всю РФ и опорную зону
+func (s *MetricsService) regionZone(ctx context.Context, regionID int) (string, string, error) {
+	if regionID == 0 {
+		return "Российская Федерация", model.ReferenceTimeZone, nil
+	}
+	tz, err := s.repo.GetRegionTimeZone(ctx, regionID)
+	if err != nil {
+		return "", "", err
+	}
+	name := ""
+	for _, d := range s.repo.GetRegions(ctx) {
+		if *d.Gid == regionID {
+			name = *d.Name
+		}
+	}
+	return name, tz, nil
+}
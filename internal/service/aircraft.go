@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+var ErrInvalidAircraft = errors.New("invalid aircraft annotation")
+
+type AircraftService struct {
+	repo Repository
+}
+
+func NewAircraftService(repo Repository) *AircraftService {
+	return &AircraftService{repo: repo}
+}
+
+// syncAircraft заносит в реестр новые регистрационные номера
+func (s *MetricsService) syncAircraft(ctx context.Context) error {
+	n, err := s.repo.SyncAircraft(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		slog.Info("registered aircraft", "aircraft", n)
+	}
+	return nil
+}
+
+// CategoryBreakdown считает полеты региона (0 — вся РФ) за период по категориям БВС
+func (s *MetricsService) CategoryBreakdown(ctx context.Context, p model.Period, regionID int) (model.CategoryBreakdown, error) {
+	res := model.CategoryBreakdown{Period: p, RegionID: regionID}
+	cats, err := s.repo.GetCategoryStats(ctx, p, regionID)
+	if err != nil {
+		return res, err
+	}
+	for _, c := range cats {
+		res.Flights += c.Flights
+	}
+	for i := range cats {
+		if res.Flights > 0 {
+			cats[i].Share = float64(cats[i].Flights) / float64(res.Flights)
+		}
+	}
+	res.Categories = cats
+	return res, nil
+}
+
+// List возвращает борта с показателями за период
+func (s *AircraftService) List(ctx context.Context, f model.AircraftFilter) ([]model.AircraftStats, error) {
+	normalizePage(&f.Limit, &f.Offset)
+	return s.repo.GetAircraftStats(ctx, f, 0)
+}
+
+// Profile возвращает карточку борта за период: показатели, типы и операторов
+func (s *AircraftService) Profile(ctx context.Context, id int, p model.Period) (model.AircraftProfile, error) {
+	res := model.AircraftProfile{Period: p}
+	a, err := s.repo.GetAircraft(ctx, id)
+	if err != nil {
+		return res, err
+	}
+	stats, err := s.repo.GetAircraftStats(ctx, model.AircraftFilter{Period: p, Limit: 1}, id)
+	if err != nil {
+		return res, err
+	}
+	if len(stats) > 0 {
+		res.AircraftStats = stats[0]
+	} else {
+		res.Types = []string{}
+	}
+	res.Aircraft = a
+	if res.OperatorList, err = s.repo.GetAircraftOperators(ctx, id, p); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// Annotate проверяет и сохраняет разметку борта. Пустые категория и класс массы
+// снимают разметку.
+func (s *AircraftService) Annotate(ctx context.Context, id int, ann model.AircraftAnnotation) (model.Aircraft, error) {
+	ann.Model = strings.TrimSpace(ann.Model)
+	ann.Notes = strings.TrimSpace(ann.Notes)
+	ann.Category = strings.ToLower(strings.TrimSpace(ann.Category))
+	ann.WeightClass = strings.ToLower(strings.TrimSpace(ann.WeightClass))
+	if ann.Category != "" && !slices.Contains(model.AircraftCategories, ann.Category) {
+		return model.Aircraft{}, fmt.Errorf("%w: unknown category %q", ErrInvalidAircraft, ann.Category)
+	}
+	if ann.WeightClass != "" && !slices.Contains(model.WeightClasses, ann.WeightClass) {
+		return model.Aircraft{}, fmt.Errorf("%w: unknown weight class %q", ErrInvalidAircraft, ann.WeightClass)
+	}
+	return s.repo.AnnotateAircraft(ctx, id, ann)
+}
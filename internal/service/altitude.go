@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+var ErrInvalidCeiling = errors.New("invalid altitude ceiling")
+
+type AltitudeService struct {
+	repo Repository
+}
+
+func NewAltitudeService(repo Repository) *AltitudeService {
+	return &AltitudeService{repo: repo}
+}
+
+// Ceilings возвращает настроенные потолки высоты
+func (s *AltitudeService) Ceilings(ctx context.Context) ([]model.AltitudeCeiling, error) {
+	return s.repo.GetAltitudeCeilings(ctx)
+}
+
+// SaveCeiling создает или обновляет потолок и переназначает потолки всем полетам
+func (s *AltitudeService) SaveCeiling(ctx context.Context, c model.AltitudeCeiling) (model.AltitudeCeiling, error) {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return c, fmt.Errorf("%w: name is required", ErrInvalidCeiling)
+	}
+	if c.MaxAlt < 0 {
+		return c, fmt.Errorf("%w: max_alt must not be negative", ErrInvalidCeiling)
+	}
+	if c.RegionID != nil && *c.RegionID == 0 {
+		c.RegionID = nil
+	}
+	if c.ZoneKind != nil {
+		switch *c.ZoneKind {
+		case "":
+			c.ZoneKind = nil
+		case model.ZoneNoFly, model.ZoneRestricted:
+		default:
+			return c, fmt.Errorf("%w: unknown zone kind %q", ErrInvalidCeiling, *c.ZoneKind)
+		}
+	}
+	c, err := s.repo.SaveAltitudeCeiling(ctx, c)
+	if err != nil {
+		return c, err
+	}
+	return c, s.reapply(ctx)
+}
+
+// DeleteCeiling удаляет потолок и переназначает потолки всем полетам
+func (s *AltitudeService) DeleteCeiling(ctx context.Context, id int) error {
+	if err := s.repo.DeleteAltitudeCeiling(ctx, id); err != nil {
+		return err
+	}
+	return s.reapply(ctx)
+}
+
+func (s *AltitudeService) reapply(ctx context.Context) error {
+	n, err := s.repo.ApplyAltitudeCeilings(ctx)
+	if err != nil {
+		return err
+	}
+	slog.Info("applied altitude ceilings", "flights", n)
+	return nil
+}
+
+// Compliance считает долю полетов выше потолка в регионе (0 — вся РФ) за период
+func (s *AltitudeService) Compliance(ctx context.Context, regionID int, p model.Period) (model.AltitudeCompliance, error) {
+	res, err := s.repo.GetAltitudeCompliance(ctx, regionID, p.From, p.To)
+	res.Period = p
+	return res, err
+}
+
+// Profile возвращает профиль высоты полета по SID
+func (s *AltitudeService) Profile(ctx context.Context, sid string) (model.AltitudeProfile, error) {
+	return s.repo.GetAltitudeProfile(ctx, sid)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+	"github.com/paulmach/orb/geojson"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+var ErrInvalidArea = errors.New("invalid area")
+
+// maxAreaKm2 — наибольшая площадь произвольной области: для больших территорий
+// есть метрики регионов и всей РФ
+const maxAreaKm2 = 2_000_000
+
+type AreaService struct {
+	repo Repository
+}
+
+func NewAreaService(repo Repository) *AreaService {
+	return &AreaService{repo: repo}
+}
+
+// Areas возвращает сохраненные области
+func (s *AreaService) Areas(ctx context.Context) ([]model.CustomArea, error) {
+	return s.repo.GetAreas(ctx)
+}
+
+// SaveArea проверяет и сохраняет область под именем name
+func (s *AreaService) SaveArea(ctx context.Context, name string, data []byte) (model.CustomArea, error) {
+	a, err := parseArea(data)
+	if err != nil {
+		return a, err
+	}
+	if a.Name = strings.TrimSpace(name); a.Name == "" {
+		return a, fmt.Errorf("%w: name is required", ErrInvalidArea)
+	}
+	return s.repo.SaveArea(ctx, a)
+}
+
+// DeleteArea удаляет сохраненную область
+func (s *AreaService) DeleteArea(ctx context.Context, id int) error {
+	return s.repo.DeleteArea(ctx, id)
+}
+
+// AreaMetrics считает полный набор метрик за период для сохраненной области (id > 0)
+// или для области из GeoJSON. Часы и дни считаются в зоне региона, в котором лежит область.
+func (s *AreaService) AreaMetrics(ctx context.Context, id int, data []byte, mode string, p model.Period, includeImplausible bool) (model.AreaMetrics, error) {
+	res := model.AreaMetrics{AreaID: id, Period: p}
+	switch mode {
+	case "":
+		mode = model.AreaDeparture
+	case model.AreaDeparture, model.AreaCrossing:
+	default:
+		return res, fmt.Errorf("%w: unknown mode %q", ErrInvalidArea, mode)
+	}
+	res.Mode = mode
+
+	var a model.CustomArea
+	var err error
+	if id > 0 {
+		a, err = s.repo.GetArea(ctx, id)
+	} else {
+		a, err = parseArea(data)
+	}
+	if err != nil {
+		return res, err
+	}
+	res.Name, res.AreaKm2 = a.Name, a.AreaKm2
+
+	tz, err := s.repo.GetAreaTimeZone(ctx, a)
+	if err != nil {
+		return res, err
+	}
+	metrics, err := s.repo.GetPeriodMetrics(ctx, model.MetricsFilter{
+		From:               p.From,
+		To:                 p.To,
+		IncludeImplausible: includeImplausible,
+		Area:               &model.AreaScope{Geometry: a.Geometry, Mode: mode, TimeZone: tz},
+	})
+	if err != nil {
+		return res, err
+	}
+	res.Metrics = *metrics[model.AreaScopeID]
+	res.Metrics.RegionName = a.Name
+	res.Metrics.Year = p.From.Year()
+	res.Metrics.TimeZone = tz
+	return res, nil
+}
+
+// parseArea разбирает область из GeoJSON: геометрии, Feature или FeatureCollection
+// из одного объекта. Допускаются Polygon и MultiPolygon в градусах WGS 84.
+func parseArea(data []byte) (model.CustomArea, error) {
+	a := model.CustomArea{}
+	var geom orb.Geometry
+	if fc, err := geojson.UnmarshalFeatureCollection(data); err == nil && fc.Type == "FeatureCollection" {
+		if len(fc.Features) != 1 {
+			return a, fmt.Errorf("%w: expected one feature, got %d", ErrInvalidArea, len(fc.Features))
+		}
+		geom = fc.Features[0].Geometry
+	} else if f, err := geojson.UnmarshalFeature(data); err == nil && f.Type == "Feature" {
+		geom = f.Geometry
+	} else if g, err := geojson.UnmarshalGeometry(data); err == nil {
+		geom = g.Geometry()
+	} else {
+		return a, fmt.Errorf("%w: invalid GeoJSON", ErrInvalidArea)
+	}
+
+	switch geom.(type) {
+	case orb.Polygon, orb.MultiPolygon:
+	default:
+		return a, fmt.Errorf("%w: geometry must be Polygon or MultiPolygon, got %T", ErrInvalidArea, geom)
+	}
+	b := geom.Bound()
+	if b.Min.Lon() < -180 || b.Max.Lon() > 180 || b.Min.Lat() < -90 || b.Max.Lat() > 90 {
+		return a, fmt.Errorf("%w: coordinates must be longitude and latitude in degrees", ErrInvalidArea)
+	}
+	a.Geometry = geom
+	a.AreaKm2 = geo.Area(geom) / 1e6
+	if a.AreaKm2 <= 0 {
+		return a, fmt.Errorf("%w: area is empty", ErrInvalidArea)
+	}
+	if a.AreaKm2 > maxAreaKm2 {
+		return a, fmt.Errorf("%w: area exceeds %d km²", ErrInvalidArea, maxAreaKm2)
+	}
+	return a, nil
+}
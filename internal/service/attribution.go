@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+var ErrUnknownAttributionKind = errors.New("unknown attribution kind")
+
+// RegionAttribution сверяет регион из таблицы с регионом по точке вылета за период
+func (s *MetricsService) RegionAttribution(ctx context.Context, p model.Period) (model.RegionAttributionReport, error) {
+	return s.repo.GetRegionAttribution(ctx, p)
+}
+
+// AttributedFlights возвращает полеты периода одного вида расхождения: mismatch, nearest или unattributed
+func (s *MetricsService) AttributedFlights(ctx context.Context, f model.AttributionFilter) ([]model.AttributedFlight, error) {
+	switch f.Kind {
+	case "mismatch", model.AttributionNearest, "unattributed":
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAttributionKind, f.Kind)
+	}
+	normalizePage(&f.Limit, &f.Offset)
+	return s.repo.GetAttributedFlights(ctx, f)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// ParsePeriod разбирает период сравнения: год (2024), квартал (2024-Q1), месяц (2024-03)
+// или диапазон дат включительно (2024-01-01..2024-03-31)
+func ParsePeriod(s string) (model.Period, error) {
+	s = strings.TrimSpace(s)
+	p := model.Period{Label: s}
+	if from, to, ok := strings.Cut(s, ".."); ok {
+		var err error
+		if p.From, err = time.Parse(time.DateOnly, strings.TrimSpace(from)); err != nil {
+			return p, fmt.Errorf("invalid period start %q", from)
+		}
+		if p.To, err = time.Parse(time.DateOnly, strings.TrimSpace(to)); err != nil {
+			return p, fmt.Errorf("invalid period end %q", to)
+		}
+		if p.To.Before(p.From) {
+			return p, fmt.Errorf("period %q ends before it starts", s)
+		}
+		return p, nil
+	}
+	if year, quarter, ok := strings.Cut(strings.ToUpper(s), "-Q"); ok {
+		y, err := strconv.Atoi(year)
+		q, qerr := strconv.Atoi(quarter)
+		if err != nil || qerr != nil || q < 1 || q > 4 {
+			return p, fmt.Errorf("invalid quarter %q", s)
+		}
+		p.From = time.Date(y, time.Month(3*(q-1)+1), 1, 0, 0, 0, 0, time.UTC)
+		p.To = p.From.AddDate(0, 3, -1)
+		return p, nil
+	}
+	if t, err := time.Parse("2006-01", s); err == nil {
+		p.From, p.To = t, t.AddDate(0, 1, -1)
+		return p, nil
+	}
+	if y, err := strconv.Atoi(s); err == nil && y > 0 {
+		p.From = time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)
+		p.To = p.From.AddDate(1, 0, -1)
+		return p, nil
+	}
+	return p, fmt.Errorf("invalid period %q", s)
+}
+
+// PeriodMetrics считает метрики регионов (0 — вся РФ) за произвольный период.
+// includeImplausible — учитывать полеты, отмеченные как кинематически неправдоподобные.
+// Неизвестный код региона — model.ErrNotFound.
+func (s *MetricsService) PeriodMetrics(ctx context.Context, regionIDs []int, p model.Period, includeImplausible bool) (map[int]*model.Metrics, error) {
+	names := make(map[int]string)
+	for _, d := range s.repo.GetRegions(ctx) {
+		names[*d.Gid] = *d.Name
+	}
+	names[0] = "Российская Федерация"
+	for _, id := range regionIDs {
+		if _, ok := names[id]; !ok {
+			return nil, fmt.Errorf("region %d: %w", id, model.ErrNotFound)
+		}
+	}
+
+	res, err := s.repo.GetPeriodMetrics(ctx, model.MetricsFilter{
+		RegionIDs:          regionIDs,
+		From:               p.From,
+		To:                 p.To,
+		IncludeImplausible: includeImplausible,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for id, m := range res {
+		m.RegionName = names[id]
+		m.Year = p.From.Year()
+		m.TimeZone = model.ReferenceTimeZone
+		if id != 0 {
+			if m.TimeZone, err = s.repo.GetRegionTimeZone(ctx, id); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// Compare считает метрики регионов за два периода и изменения каждого показателя от A к B
+func (s *MetricsService) Compare(ctx context.Context, regionIDs []int, a, b model.Period, includeImplausible bool) (model.MetricsComparison, error) {
+	res := model.MetricsComparison{A: a, B: b, Regions: []model.RegionComparison{}}
+	ma, err := s.PeriodMetrics(ctx, regionIDs, a, includeImplausible)
+	if err != nil {
+		return res, err
+	}
+	mb, err := s.PeriodMetrics(ctx, regionIDs, b, includeImplausible)
+	if err != nil {
+		return res, err
+	}
+	seen := make(map[int]struct{})
+	for _, id := range regionIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		res.Regions = append(res.Regions, model.RegionComparison{
+			RegionID:   id,
+			RegionName: ma[id].RegionName,
+			A:          *ma[id],
+			B:          *mb[id],
+			Fields:     metricFieldDiffs(*ma[id], *mb[id]),
+		})
+	}
+	return res, nil
+}
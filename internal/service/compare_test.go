@@ -0,0 +1,49 @@
+package service
+
+import "testing"
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		in       string
+		from, to string // пусто — ошибка разбора
+	}{
+		{in: "2024", from: "2024-01-01", to: "2024-12-31"},
+		{in: " 2023 ", from: "2023-01-01", to: "2023-12-31"},
+		{in: "2024-Q1", from: "2024-01-01", to: "2024-03-31"},
+		{in: "2024-q4", from: "2024-10-01", to: "2024-12-31"},
+		{in: "2024-03", from: "2024-03-01", to: "2024-03-31"},
+		{in: "2024-02", from: "2024-02-01", to: "2024-02-29"},
+		{in: "2023-02", from: "2023-02-01", to: "2023-02-28"},
+		{in: "2024-01-15..2024-02-29", from: "2024-01-15", to: "2024-02-29"},
+		{in: "2024-03-01 .. 2024-03-01", from: "2024-03-01", to: "2024-03-01"},
+		{in: ""},
+		{in: "0"},
+		{in: "-2024"},
+		{in: "2024-Q0"},
+		{in: "2024-Q5"},
+		{in: "2024-QX"},
+		{in: "X-Q1"},
+		{in: "2024-00"},
+		{in: "2024-13"},
+		{in: "2024-03-31..2024-03-01"},
+		{in: "2023-02-29..2023-03-01"},
+		{in: "2024-01-01.."},
+		{in: "..2024-01-01"},
+	}
+	for _, tt := range tests {
+		p, err := ParsePeriod(tt.in)
+		if tt.from == "" {
+			if err == nil {
+				t.Errorf("ParsePeriod(%q) = %s..%s, want error", tt.in, p.From.Format("2006-01-02"), p.To.Format("2006-01-02"))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePeriod(%q) error: %v", tt.in, err)
+			continue
+		}
+		if from, to := p.From.Format("2006-01-02"), p.To.Format("2006-01-02"); from != tt.from || to != tt.to {
+			t.Errorf("ParsePeriod(%q) = %s..%s, want %s..%s", tt.in, from, to, tt.from, tt.to)
+		}
+	}
+}
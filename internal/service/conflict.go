@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/config"
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+var ErrConflictJobRunning = errors.New("conflict detection is already running")
+
+type ConflictService struct {
+	repo Repository
+	cfg  config.ConflictConfig
+
+	job job[model.FlightConflictJob]
+}
+
+func NewConflictService(repo Repository, cfg config.ConflictConfig) *ConflictService {
+	if cfg.Separation <= 0 {
+		cfg.Separation = 500
+	}
+	if cfg.DayTimeout <= 0 {
+		cfg.DayTimeout = 5 * time.Minute
+	}
+	return &ConflictService{repo: repo, cfg: cfg}
+}
+
+// Start запускает в фоне поиск конфликтов по всем суткам периода
+func (s *ConflictService) Start(p model.Period) error {
+	return s.job.start(ErrConflictJobRunning, func() {
+		if _, err := s.detect(context.Background(), p); err != nil {
+			slog.Error("conflict detection failed", "period", p.Label, "error", err)
+		}
+	})
+}
+
+// Detect синхронно ищет конфликты по всем суткам периода и возвращает их число
+func (s *ConflictService) Detect(ctx context.Context, p model.Period) (n int, err error) {
+	s.job.run(func() {
+		n, err = s.detect(ctx, p)
+	})
+	return n, err
+}
+
+// Job возвращает состояние последнего пакетного поиска
+func (s *ConflictService) Job() model.FlightConflictJob {
+	return s.job.get()
+}
+
+func (s *ConflictService) detect(ctx context.Context, p model.Period) (int, error) {
+	started := time.Now()
+	total := int(p.To.Sub(p.From).Hours()/24) + 1
+	s.job.set(func(j *model.FlightConflictJob) {
+		*j = model.FlightConflictJob{Running: true, Period: &p, Total: total, StartedAt: &started}
+	})
+
+	var errs []error
+	conflicts := 0
+	for day := p.From; !day.After(p.To); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		dayCtx, cancel := context.WithTimeout(ctx, s.cfg.DayTimeout)
+		n, err := s.repo.DetectConflicts(dayCtx, day, s.cfg.Separation)
+		cancel()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		conflicts += n
+		s.job.set(func(j *model.FlightConflictJob) {
+			j.Done++
+			j.Conflicts = conflicts
+		})
+	}
+
+	err := errors.Join(errs...)
+	s.job.set(func(j *model.FlightConflictJob) {
+		j.Running = false
+		j.DurationMs = time.Since(started).Milliseconds()
+		if err != nil {
+			j.Error = err.Error()
+		}
+	})
+	slog.Info("conflict detection finished", "period", p.Label, "days", total, "conflicts", conflicts,
+		"duration", time.Since(started), "failed", len(errs))
+	if err != nil {
+		return conflicts, fmt.Errorf("conflict detection: %w", err)
+	}
+	return conflicts, nil
+}
+
+// Conflicts возвращает найденные конфликты по фильтру
+func (s *ConflictService) Conflicts(ctx context.Context, f model.FlightConflictFilter) ([]model.FlightConflict, error) {
+	normalizePage(&f.Limit, &f.Offset)
+	return s.repo.GetFlightConflicts(ctx, f)
+}
+
+// ConflictStats считает конфликты за период по регионам или операторам
+func (s *ConflictService) ConflictStats(ctx context.Context, p model.Period, group string) (model.FlightConflictStats, error) {
+	if group != "operator" {
+		group = "region"
+	}
+	return s.repo.GetConflictStats(ctx, p, group)
+}
+
+// ConflictDays возвращает проверенные сутки периода
+func (s *ConflictService) ConflictDays(ctx context.Context, p model.Period) ([]model.FlightConflictDay, error) {
+	return s.repo.GetConflictDays(ctx, p.From, p.To)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
@@ -24,11 +25,12 @@ var (
 )
 
 type ParserService struct {
-	repo Repository
+	repo      Repository
+	operators *OperatorResolver
 }
 
-func NewParserService(repo Repository) *ParserService {
-	return &ParserService{repo: repo}
+func NewParserService(repo Repository, operators *OperatorResolver) *ParserService {
+	return &ParserService{repo: repo, operators: operators}
 }
 
 func (p *ParserService) cleanString(s string) string {
@@ -39,23 +41,39 @@ func (p *ParserService) cleanString(s string) string {
 	return strings.TrimSpace(s)
 }
 
-func (p *ParserService) ProcessXLSX(ctx context.Context, f *excelize.File, authorID, filename string, fileID int) (int, int, error) {
-	validCount := 0
-	errorCount := 0
-	reportLines := []string{}
+// parsedRow — результат разбора одной строки XLSX до сохранения в БД
+type parsedRow struct {
+	sheet     string
+	row       int
+	msg       model.ParsedMessage
+	errs      []string // причины, по которым строка не будет сохранена
+	warnings  []string // ошибки разбора SHR, не мешающие сохранению
+	duplicate bool     // повтор SID+DOF+ATD внутри файла
+}
+
+func (r parsedRow) valid() bool {
+	return len(r.errs) == 0 && !r.duplicate
+}
+
+// parseXLSX разбирает все листы файла без сохранения в БД.
+// Возвращает строки и количество листов, которые не удалось прочитать.
+func (p *ParserService) parseXLSX(f *excelize.File, filename string) ([]parsedRow, int) {
+	var res []parsedRow
+	sheetErrors := 0
 	seen := make(map[string]struct{})
 
 	for _, sheet := range f.GetSheetList() { // проходим по всем листам
 		rows, err := f.GetRows(sheet)
 		if err != nil {
 			log.Printf("Error reading rows from sheet %s in file %s: %v", sheet, filename, err)
-			errorCount += 1
+			sheetErrors++
 			continue
 		}
-		fmt.Println(sheet)
 		for i, row := range rows {
+			pr := parsedRow{sheet: sheet, row: i + 1}
 			if len(row) < 2 {
-				errorCount++
+				pr.errs = append(pr.errs, "row has less than 2 columns")
+				res = append(res, pr)
 				continue
 			}
 
@@ -67,15 +85,13 @@ func (p *ParserService) ProcessXLSX(ctx context.Context, f *excelize.File, autho
 			}
 
 			if region == "" || shrRaw == "" {
-				errorCount++
+				pr.errs = append(pr.errs, "empty region or SHR")
+				res = append(res, pr)
 				continue
 			}
 
-			if i < 5 {
-				reportLines = append(reportLines, fmt.Sprintf("Sheet %s Row %d debug - Region: '%s', SHR: '%s'", sheet, i+1, region, shrRaw))
-			}
-
-			msg, _, _ := p.parseSHR(shrRaw, region)
+			msg, _, errs := p.parseSHR(shrRaw, region)
+			pr.warnings = errs
 
 			if iarrRaw != "" {
 				ata := p.parseATAFromIARR(iarrRaw)
@@ -83,31 +99,265 @@ func (p *ParserService) ProcessXLSX(ctx context.Context, f *excelize.File, autho
 					msg.ATA = ata
 				}
 			}
+			p.validateKinematics(&msg)
+			pr.msg = msg
 
 			key := msg.SID + msg.DOF + msg.ATD
 			if _, exists := seen[key]; exists {
-				reportLines = append(reportLines, fmt.Sprintf("Sheet %s Row %d duplicate: %s", sheet, i+1, key))
-				errorCount++
+				pr.duplicate = true
+				res = append(res, pr)
 				continue
 			}
 			seen[key] = struct{}{}
 
-			if msg.SID == "" || msg.DepCoords == "" || msg.ATA == "" {
-				errorCount++
-				continue
+			if msg.SID == "" {
+				pr.errs = append(pr.errs, "missing SID")
 			}
-
-			err = p.repo.SaveMessage(ctx, &msg, fileID)
-			if err != nil {
-				slog.Error("error saving message:", err.Error())
-				errorCount++
-				continue
+			if msg.DepCoords == "" {
+				pr.errs = append(pr.errs, "missing DEP coordinates")
 			}
-			validCount++
+			if msg.ATA == "" {
+				pr.errs = append(pr.errs, "missing ATA")
+			}
+			res = append(res, pr)
+		}
+	}
+	return res, sheetErrors
+}
+
+// ParseConflictPolicy проверяет политику разрешения конфликтов, пустое значение означает skip
+func ParseConflictPolicy(policy string) (string, error) {
+	switch policy {
+	case "":
+		return model.ConflictSkip, nil
+	case model.ConflictSkip, model.ConflictOverwrite, model.ConflictKeepNewest:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy: %s", policy)
+}
+
+// ProcessXLSX разбирает файл и сохраняет полеты. Полет с уже сохраненным SID сравнивается
+// с сохраненным по полям: без расхождений он считается дубликатом, с расхождениями —
+// конфликтом, который разрешается согласно policy и попадает в отчет файла. modifiedAt — время
+// изменения файла для политики keep-newest, nil — неизвестно.
+func (p *ParserService) ProcessXLSX(ctx context.Context, f *excelize.File, authorID, filename string, fileID int, policy string, modifiedAt *time.Time) (model.ProcessReport, error) {
+	rows, errorCount := p.parseXLSX(f, filename)
+	report := model.ProcessReport{ErrorCount: errorCount, Conflicts: []model.Conflict{}}
+
+	for _, row := range rows {
+		if !row.valid() {
+			report.ErrorCount++
+			continue
+		}
+		outcome, conflict, err := p.storeMessage(ctx, &row.msg, fileID, policy, modifiedAt)
+		if conflict != nil {
+			conflict.Sheet, conflict.Row = row.sheet, row.row
+			report.ConflictCount++
+			report.Conflicts = append(report.Conflicts, *conflict)
+		}
+		switch {
+		case err != nil:
+			slog.Error("error storing message", "sid", row.msg.SID, "error", err)
+			report.ErrorCount++
+		case outcome == storeDuplicate:
+			report.DuplicateCount++
+		case outcome == storeSaved || outcome == storeOverwritten:
+			report.ValidCount++
+		}
+	}
+
+	if err := p.repo.SaveConflicts(ctx, fileID, report.Conflicts); err != nil {
+		slog.Error("error saving conflicts", "file_id", fileID, "error", err)
+	}
+	return report, nil
+}
+
+// Итоги storeMessage
+const (
+	storeSaved       = "saved"       // Новый полет сохранен
+	storeDuplicate   = "duplicate"   // Полет уже сохранен с теми же полями
+	storeKept        = "kept"        // Конфликт, сохраненный полет оставлен
+	storeOverwritten = "overwritten" // Конфликт, сохраненный полет заменен входящим
+)
+
+// storeMessage сохраняет полет из файла fileID (0 — телеграмма). Полет с уже сохраненным SID
+// сравнивается с сохраненным: без расхождений это дубликат, с расхождениями — конфликт,
+// который разрешается согласно policy и возвращается для отчета. sourceTime — время
+// источника (изменения файла или приема телеграммы), сохраняется вместе с полетом
+// для политики keep-newest; nil — неизвестно.
+func (p *ParserService) storeMessage(ctx context.Context, mes *model.ParsedMessage, fileID int, policy string, sourceTime *time.Time) (string, *model.Conflict, error) {
+	stored, err := p.repo.GetStoredMessage(ctx, mes.SID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to check stored message: %w", err)
+	}
+	if stored == nil {
+		err = p.repo.SaveMessage(ctx, mes, fileID, sourceTime)
+		if err == nil {
+			return storeSaved, nil, nil
+		}
+		if !errors.Is(err, model.ErrMessageExists) {
+			return "", nil, fmt.Errorf("failed to save message: %w", err)
+		}
+		// SID сохранила параллельная загрузка между проверкой и вставкой (crawler -j):
+		// полет сверяется с сохраненным, как если бы тот был найден сразу
+		if stored, err = p.repo.GetStoredMessage(ctx, mes.SID); err != nil {
+			return "", nil, fmt.Errorf("failed to check stored message: %w", err)
+		}
+		if stored == nil {
+			return "", nil, model.ErrMessageExists
+		}
+	}
+
+	diffs := p.diffMessage(stored, mes)
+	if len(diffs) == 0 {
+		return storeDuplicate, nil, nil
+	}
+	conflict := &model.Conflict{
+		SID:            mes.SID,
+		ExistingFileID: stored.FileID,
+		Fields:         diffs,
+		Policy:         policy,
+		Resolution:     storeKept,
+	}
+	if policy != model.ConflictOverwrite && !(policy == model.ConflictKeepNewest && incomingNewer(stored, sourceTime)) {
+		return storeKept, conflict, nil
+	}
+	if err = p.repo.OverwriteMessage(ctx, mes, fileID, sourceTime); err != nil {
+		return storeKept, conflict, fmt.Errorf("failed to overwrite message: %w", err)
+	}
+	conflict.Resolution = storeOverwritten
+	return storeOverwritten, conflict, nil
+}
+
+// incomingNewer сообщает, что источник входящего полета новее источника сохраненного.
+// Если время неизвестно хотя бы с одной стороны, свежесть не определить и сохраненный полет остается.
+func incomingNewer(stored *model.StoredMessage, sourceTime *time.Time) bool {
+	return stored.SourceTime != nil && sourceTime != nil && sourceTime.After(*stored.SourceTime)
+}
+
+// RegisterFile регистрирует файл в files со статусом processing, чтобы загрузка была видна в интерфейсе.
+// Повторная загрузка того же содержимого возвращает id первой и model.ErrFileExists:
+// такой файл не обрабатывается, а отчет первой загрузки не меняется.
+func (p *ParserService) RegisterFile(ctx context.Context, mf model.File) (int, error) {
+	mf.Status = "processing"
+	return p.repo.SaveFileInfo(ctx, mf, 0, 0)
+}
+
+// IngestFile загружает полеты зарегистрированного файла и сохраняет итоговый статус и счетчики.
+// Используется и HTTP-загрузкой, и CLI.
+func (p *ParserService) IngestFile(ctx context.Context, f *excelize.File, mf model.File, fileID int) (model.ProcessReport, error) {
+	policy, err := ParseConflictPolicy(mf.ConflictPolicy)
+	if err != nil {
+		return model.ProcessReport{}, err
+	}
+	mf.ConflictPolicy = policy
+
+	report, err := p.ProcessXLSX(ctx, f, mf.AuthorID, mf.Filename, fileID, policy, mf.ModifiedAt)
+	if err != nil {
+		mf.Status = "error"
+		if saveErr := p.repo.UpdateFileInfo(ctx, fileID, mf, 0, 0); saveErr != nil {
+			slog.Error("error saving file status", "file_id", fileID, "error", saveErr)
+		}
+		return report, fmt.Errorf("failed to process %s: %w", mf.Filename, err)
+	}
+	// операторы новых написаний OPR/ определяются сразу, а не при пересчете метрик,
+	// который может быть отключен (crawler -metrics=false)
+	if report.ValidCount > 0 {
+		if err = p.operators.resolve(ctx); err != nil {
+			slog.Error("error resolving operators", "file_id", fileID, "error", err)
 		}
 	}
+	mf.Status = "parsed"
+	mf.DuplicateCount = report.DuplicateCount
+	mf.ConflictCount = report.ConflictCount
+	if err = p.repo.UpdateFileInfo(ctx, fileID, mf, report.ValidCount, report.ErrorCount); err != nil {
+		return report, fmt.Errorf("failed to save file info: %w", err)
+	}
+	return report, nil
+}
+
+// diffMessage сравнивает сохраненный и входящий полет по всем сохраняемым полям
+func (p *ParserService) diffMessage(stored *model.StoredMessage, mes *model.ParsedMessage) []model.FieldDiff {
+	var diffs []model.FieldDiff
+	compare := func(field, old, new string) {
+		if strings.TrimSpace(old) != strings.TrimSpace(new) {
+			diffs = append(diffs, model.FieldDiff{Field: field, Stored: old, Incoming: new})
+		}
+	}
+	compare("dof", stored.DOF, mes.DOF)
+	compare("atd", stored.ATD, mes.ATD)
+	compare("ata", stored.ATA, mes.ATA)
+	compare("dep_coords", stored.DepCoords, mes.DepCoords)
+	compare("arr_coords", stored.ArrCoords, mes.ArrCoords)
+	compare("opr", stored.OPR, mes.OPR)
+	compare("reg", stored.REG, mes.REG)
+	compare("typ", stored.TYP, mes.TYP)
+	compare("rmk", stored.RMK, mes.RMK)
+	compare("min_alt", strconv.Itoa(stored.MinAlt), strconv.Itoa(mes.MinAlt))
+	compare("max_alt", strconv.Itoa(stored.MaxAlt), strconv.Itoa(mes.MaxAlt))
+	return diffs
+}
 
-	return validCount, errorCount, nil
+// ValidateXLSX выполняет полный разбор файла без сохранения и сообщает,
+// что произойдет при загрузке: количество валидных строк, ошибки по строкам,
+// дубликаты внутри файла и уже присутствующие в messages полеты
+func (p *ParserService) ValidateXLSX(ctx context.Context, f *excelize.File, filename string, previewSize int) (model.ValidationReport, error) {
+	rows, sheetErrors := p.parseXLSX(f, filename)
+	report := model.ValidationReport{
+		Filename:       filename,
+		TotalRows:      len(rows),
+		ErrorCount:     sheetErrors,
+		RowErrors:      []model.RowError{},
+		FileDuplicates: []model.RowRef{},
+		ExistingInDB:   []model.RowRef{},
+		Preview:        []model.ParsedMessage{},
+	}
+
+	var valid []parsedRow
+	for _, row := range rows {
+		switch {
+		case row.duplicate:
+			report.ErrorCount++
+			report.FileDuplicates = append(report.FileDuplicates, model.RowRef{Sheet: row.sheet, Row: row.row, SID: row.msg.SID})
+		case !row.valid():
+			report.ErrorCount++
+			report.RowErrors = append(report.RowErrors, model.RowError{
+				Sheet:    row.sheet,
+				Row:      row.row,
+				SID:      row.msg.SID,
+				Errors:   row.errs,
+				Warnings: row.warnings,
+			})
+		default:
+			valid = append(valid, row)
+		}
+	}
+	report.ValidCount = len(valid)
+
+	sids := make([]string, 0, len(valid))
+	for _, row := range valid {
+		sids = append(sids, row.msg.SID)
+	}
+	existing, err := p.repo.GetExistingSIDs(ctx, sids)
+	if err != nil {
+		return report, fmt.Errorf("failed to check existing flights: %w", err)
+	}
+	exists := make(map[string]struct{}, len(existing))
+	for _, sid := range existing {
+		exists[sid] = struct{}{}
+	}
+	for _, row := range valid {
+		if _, ok := exists[row.msg.SID]; ok {
+			report.ExistingInDB = append(report.ExistingInDB, model.RowRef{Sheet: row.sheet, Row: row.row, SID: row.msg.SID})
+			continue
+		}
+		report.NewCount++
+	}
+
+	for i := 0; i < len(valid) && i < previewSize; i++ {
+		report.Preview = append(report.Preview, valid[i].msg)
+	}
+	return report, nil
 }
 
 func (p *ParserService) parseSHR(raw string, region string) (model.ParsedMessage, []string, []string) {
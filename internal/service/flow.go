@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// Flows возвращает матрицу отправления–назначения между регионами за период
+func (s *MetricsService) Flows(ctx context.Context, f model.FlowFilter) (model.FlowMatrix, error) {
+	return s.repo.GetRegionFlows(ctx, f)
+}
+
+// FlowLines возвращает межрегиональные потоки за период как GeoJSON-слой линий
+func (s *MetricsService) FlowLines(ctx context.Context, f model.FlowFilter) ([]byte, error) {
+	return s.repo.GetFlowLinesGeoJSON(ctx, f)
+}
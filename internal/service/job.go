@@ -0,0 +1,61 @@
+package service
+
+import "sync"
+
+// Размер страницы списков по умолчанию и наибольший допустимый
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// normalizePage заменяет недопустимый размер страницы значением по умолчанию,
+// а отрицательное смещение — нулем
+func normalizePage(limit, offset *int) {
+	if *limit <= 0 || *limit > maxPageLimit {
+		*limit = defaultPageLimit
+	}
+	if *offset < 0 {
+		*offset = 0
+	}
+}
+
+// job — фоновое задание, которое выполняется не более чем в одном экземпляре,
+// и состояние его последнего запуска
+type job[T any] struct {
+	mu      sync.Mutex // удерживается на время задания
+	stateMu sync.RWMutex
+	state   T
+}
+
+// start запускает run в фоне. Если задание уже идет, возвращает busy.
+func (j *job[T]) start(busy error, run func()) error {
+	if !j.mu.TryLock() {
+		return busy
+	}
+	go func() {
+		defer j.mu.Unlock()
+		run()
+	}()
+	return nil
+}
+
+// run выполняет задание синхронно, дожидаясь окончания идущего
+func (j *job[T]) run(run func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	run()
+}
+
+// get возвращает состояние последнего запуска
+func (j *job[T]) get() T {
+	j.stateMu.RLock()
+	defer j.stateMu.RUnlock()
+	return j.state
+}
+
+// set изменяет состояние запуска
+func (j *job[T]) set(update func(state *T)) {
+	j.stateMu.Lock()
+	defer j.stateMu.Unlock()
+	update(&j.state)
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/solar"
+)
+
+// lightBatch — размер пачки полетов при расчете освещенности
+const lightBatch = 5000
+
+// classifyLight определяет освещенность вылета для еще не классифицированных полетов.
+// Запись отметок помечает партиции триггером, поэтому вызывается до выборки партиций.
+func (s *MetricsService) classifyLight(ctx context.Context) error {
+	total := 0
+	for {
+		deps, err := s.repo.GetUnclassifiedDepartures(ctx, lightBatch)
+		if err != nil {
+			return err
+		}
+		if len(deps) == 0 {
+			break
+		}
+		conditions := make(map[int]string, len(deps))
+		for _, d := range deps {
+			conditions[d.ID] = solar.Classify(d.Time, d.Lat, d.Lon)
+		}
+		if err := s.repo.SetLightConditions(ctx, conditions); err != nil {
+			return fmt.Errorf("failed to classify light conditions: %w", err)
+		}
+		total += len(deps)
+		if len(deps) < lightBatch {
+			break
+		}
+	}
+	if total > 0 {
+		slog.Info("classified light conditions", "flights", total)
+	}
+	return nil
+}
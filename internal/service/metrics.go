@@ -2,61 +2,405 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"slices"
 	"sync"
+	"time"
 
+	"github.com/Xapsiel/bpla_dashboard/internal/config"
 	"github.com/Xapsiel/bpla_dashboard/internal/model"
 )
 
+var ErrRefreshRunning = errors.New("metrics refresh is already running")
+
 type MetricsService struct {
-	repo    Repository
-	metrics chan *model.Metrics
+	repo      Repository
+	cfg       config.MetricsConfig
+	operators *OperatorResolver
+	// пересчеты выполняются последовательно; состояние не нужно —
+	// запуски сохраняются в metrics_refresh_runs
+	job job[struct{}]
+}
+
+func NewMetricsService(repo Repository, cfg config.MetricsConfig, operators *OperatorResolver) *MetricsService {
+	if cfg.Workers < 1 {
+		cfg.Workers = 4
+	}
+	return &MetricsService{repo: repo, cfg: cfg, operators: operators}
+}
+
+// refreshTask — пересчет метрик региона за год; RegionID = 0 — вся РФ
+type refreshTask struct {
+	RegionID   int
+	RegionName string
+	Year       int
+}
+
+type regionYear struct{ region, year int }
+
+// Update — полный пересчет метрик по всем регионам и годам (явное действие администратора).
+// Снимает отметки с партиций, помеченных до начала пересчета и пересчитанных без ошибок.
+func (s *MetricsService) Update(ctx context.Context) (run model.RefreshRun, err error) {
+	s.job.run(func() {
+		run, err = s.update(ctx)
+	})
+	return run, err
+}
+
+// Refresh пересчитывает метрики только по регионам и годам, в которых менялись
+// полеты (загрузки, удаления, правки), и общероссийские метрики за эти годы
+func (s *MetricsService) Refresh(ctx context.Context) (run model.RefreshRun, err error) {
+	s.job.run(func() {
+		run, err = s.refresh(ctx)
+	})
+	return run, err
+}
+
+// Start запускает пересчет в фоне. Если пересчет уже идет, возвращает ErrRefreshRunning.
+func (s *MetricsService) Start(kind string) error {
+	if kind != model.RefreshFull && kind != model.RefreshIncremental {
+		return fmt.Errorf("unknown refresh kind %q", kind)
+	}
+	return s.job.start(ErrRefreshRunning, func() {
+		ctx := context.Background()
+		var err error
+		if kind == model.RefreshFull {
+			_, err = s.update(ctx)
+		} else {
+			_, err = s.refresh(ctx)
+		}
+		if err != nil {
+			slog.Error("metrics refresh failed", "kind", kind, "error", err)
+		}
+	})
+}
+
+// RefreshRuns возвращает последние запуски пересчета
+func (s *MetricsService) RefreshRuns(ctx context.Context, limit int) ([]model.RefreshRun, error) {
+	return s.repo.GetRefreshRuns(ctx, limit)
+}
+
+// DirtyPartitions возвращает партиции, ожидающие пересчета
+func (s *MetricsService) DirtyPartitions(ctx context.Context) ([]model.MetricsPartition, error) {
+	return s.repo.GetDirtyPartitions(ctx)
+}
+
+// History возвращает все версии метрик региона за год
+func (s *MetricsService) History(ctx context.Context, regionID, year int) ([]model.MetricsSnapshot, error) {
+	return s.repo.GetMetricsHistory(ctx, regionID, year)
+}
+
+// AsOf возвращает метрики региона за год на момент пересчета runID
+func (s *MetricsService) AsOf(ctx context.Context, regionID, year, runID int) (model.MetricsSnapshot, error) {
+	return s.repo.GetMetricsSnapshot(ctx, regionID, year, runID)
+}
+
+// Diff сравнивает метрики региона за год на момент пересчетов fromRun и toRun
+func (s *MetricsService) Diff(ctx context.Context, regionID, year, fromRun, toRun int) (model.MetricsDiff, error) {
+	diff := model.MetricsDiff{RegionID: regionID, Year: year, Fields: []model.MetricFieldDiff{}}
+	var err error
+	if diff.From, err = s.repo.GetMetricsSnapshot(ctx, regionID, year, fromRun); err != nil {
+		return diff, err
+	}
+	if diff.To, err = s.repo.GetMetricsSnapshot(ctx, regionID, year, toRun); err != nil {
+		return diff, err
+	}
+	for _, f := range metricFieldDiffs(diff.From.Metrics, diff.To.Metrics) {
+		if f.Delta != 0 {
+			diff.Fields = append(diff.Fields, f)
+		}
+	}
+	from, to := diff.From.Metrics, diff.To.Metrics
+	diff.ZeroDaysAdded = daysMissing(to.ZeroFlightDays, from.ZeroFlightDays)
+	diff.ZeroDaysRemoved = daysMissing(from.ZeroFlightDays, to.ZeroFlightDays)
+	return diff, nil
+}
+
+// metricFieldDiffs сравнивает все числовые показатели. Дни без полетов сравниваются по количеству,
+// рост по месяцам — по каждому месяцу (ключи MonthlyGrowth — номер месяца с нуля).
+func metricFieldDiffs(from, to model.Metrics) []model.MetricFieldDiff {
+	res := make([]model.MetricFieldDiff, 0, 24)
+	add := func(field string, a, b float64) {
+		d := model.MetricFieldDiff{Field: field, From: a, To: b, Delta: b - a}
+		if a != 0 {
+			pct := (b - a) / math.Abs(a) * 100
+			d.DeltaPct = &pct
+		}
+		res = append(res, d)
+	}
+	add("total_flight", float64(from.TotalFlight), float64(to.TotalFlight))
+	add("avg_duration_minutes", float64(from.AvgDurationMinutes), float64(to.AvgDurationMinutes))
+	add("total_distance_km", from.TotalDistance, to.TotalDistance)
+	add("peak_load", float64(from.PeakLoad), float64(to.PeakLoad))
+	add("avg_daily_flights", from.AvgDailyFlights, to.AvgDailyFlights)
+	add("median_daily_flights", from.MedianDailyFlights, to.MedianDailyFlights)
+	add("flight_density", from.FlightDensity, to.FlightDensity)
+	add("morning_flights", float64(from.MorningFlights), float64(to.MorningFlights))
+	add("day_flights", float64(from.DayFlights), float64(to.DayFlights))
+	add("evening_flights", float64(from.EveningFlights), float64(to.EveningFlights))
+	add("night_flights", float64(from.NightFlights), float64(to.NightFlights))
+	add("daylight_flights", float64(from.DaylightFlights), float64(to.DaylightFlights))
+	add("twilight_flights", float64(from.TwilightFlights), float64(to.TwilightFlights))
+	add("dark_flights", float64(from.DarkFlights), float64(to.DarkFlights))
+	add("night_share", from.NightShare, to.NightShare)
+	add("zero_flight_days", float64(len(from.ZeroFlightDays)), float64(len(to.ZeroFlightDays)))
+	for month := 0; month < 12; month++ {
+		add(fmt.Sprintf("monthly_growth.%d", month), from.MonthlyGrowth[month], to.MonthlyGrowth[month])
+	}
+	return res
+}
+
+// daysMissing возвращает дни из a, отсутствующие в b
+func daysMissing(a, b []time.Time) []time.Time {
+	in := make(map[time.Time]struct{}, len(b))
+	for _, d := range b {
+		in[d] = struct{}{}
+	}
+	res := []time.Time{}
+	for _, d := range a {
+		if _, ok := in[d]; !ok {
+			res = append(res, d)
+		}
+	}
+	return res
 }
 
-func NewMetricsService(repo Repository) *MetricsService {
-	return &MetricsService{repo: repo, metrics: make(chan *model.Metrics, 5)}
+// prepare дополняет новые и измененные полеты перед пересчетом: освещенность,
+// правдоподобность, операторы и реестр бортов
+func (s *MetricsService) prepare(ctx context.Context) error {
+	if err := s.classifyLight(ctx); err != nil {
+		return err
+	}
+	if err := s.checkPlausibility(ctx); err != nil {
+		return err
+	}
+	if err := s.operators.resolve(ctx); err != nil {
+		return err
+	}
+	return s.syncAircraft(ctx)
 }
 
-func (s *MetricsService) Update(ctx context.Context) error {
-	reg := s.repo.GetRegions(ctx)
+func (s *MetricsService) update(ctx context.Context) (model.RefreshRun, error) {
+	if err := s.prepare(ctx); err != nil {
+		return model.RefreshRun{}, err
+	}
+	parts, err := s.repo.GetDirtyPartitions(ctx)
+	if err != nil {
+		return model.RefreshRun{}, err
+	}
 	years := s.repo.GetFlightYears(ctx)
-	wg := &sync.WaitGroup{}
-	wg.Add(2)
-	go func(wg *sync.WaitGroup) {
-		defer wg.Done()
-		for _, region := range reg {
-			for _, year := range years {
-				s.getMetrics(ctx, region, year)
-			}
+	var tasks []refreshTask
+	for _, year := range years {
+		tasks = append(tasks, refreshTask{RegionName: "Российская Федерация", Year: year})
+	}
+	for _, region := range s.repo.GetRegions(ctx) {
+		for _, year := range years {
+			tasks = append(tasks, refreshTask{RegionID: *region.Gid, RegionName: *region.Name, Year: year})
+		}
+	}
+	run, err := s.run(ctx, model.RefreshFull, tasks, partitionFiles(parts))
+	s.clearPartitions(ctx, parts, run.Failures)
+	return run, err
+}
+
+func (s *MetricsService) refresh(ctx context.Context) (model.RefreshRun, error) {
+	if err := s.prepare(ctx); err != nil {
+		return model.RefreshRun{}, err
+	}
+	parts, err := s.repo.GetDirtyPartitions(ctx)
+	if err != nil {
+		return model.RefreshRun{}, err
+	}
+	if len(parts) == 0 {
+		return model.RefreshRun{Kind: model.RefreshIncremental, Status: model.RefreshStatusSuccess, Failures: []model.RefreshFailure{}}, nil
+	}
+	names := make(map[int]string)
+	for _, d := range s.repo.GetRegions(ctx) {
+		names[*d.Gid] = *d.Name
+	}
+
+	// метрики хранятся по годам: несколько месяцев одного региона дают одну задачу
+	seen := make(map[regionYear]struct{})
+	var tasks []refreshTask
+	add := func(region int, name string, year int) {
+		if _, ok := seen[regionYear{region, year}]; ok {
+			return
+		}
+		seen[regionYear{region, year}] = struct{}{}
+		tasks = append(tasks, refreshTask{RegionID: region, RegionName: name, Year: year})
+	}
+	for _, p := range parts {
+		// партиции размечены по дате в UTC, а метрики — по местному времени:
+		// полет в первые или последние сутки года может относиться к соседнему году
+		years := []int{p.Year}
+		switch p.Month {
+		case 1:
+			years = append(years, p.Year-1)
+		case 12:
+			years = append(years, p.Year+1)
 		}
-	}(wg)
-	go func(wg *sync.WaitGroup) {
-		defer wg.Done()
 		for _, year := range years {
-			s.getMetricsAllRussia(ctx, year)
+			add(0, "Российская Федерация", year)
+			if name, ok := names[p.RegionID]; ok {
+				add(p.RegionID, name, year)
+			}
 		}
-	}(wg)
-	go func(wg *sync.WaitGroup) {
-		err := s.repo.UpdateMetrics(context.Background(), s.metrics)
-		if err != nil {
-			slog.Error(fmt.Sprintf("error update metrics: %v", err))
+	}
+	run, err := s.run(ctx, model.RefreshIncremental, tasks, partitionFiles(parts))
+	s.clearPartitions(ctx, parts, run.Failures)
+	return run, err
+}
+
+// partitionFiles возвращает файлы, изменения из которых попали в партиции
+func partitionFiles(parts []model.MetricsPartition) []int {
+	seen := make(map[int]struct{})
+	res := []int{}
+	for _, p := range parts {
+		for _, id := range p.FileIDs {
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				res = append(res, id)
+			}
 		}
-	}(wg)
+	}
+	slices.Sort(res)
+	return res
+}
+
+// clearPartitions снимает отметки с партиций, у которых пересчитаны и регион, и вся РФ за год
+func (s *MetricsService) clearPartitions(ctx context.Context, parts []model.MetricsPartition, failures []model.RefreshFailure) {
+	failed := make(map[regionYear]struct{}, len(failures))
+	for _, f := range failures {
+		failed[regionYear{f.RegionID, f.Year}] = struct{}{}
+	}
+	done := make([]model.MetricsPartition, 0, len(parts))
+	for _, p := range parts {
+		if _, ok := failed[regionYear{0, p.Year}]; ok {
+			continue
+		}
+		if _, ok := failed[regionYear{p.RegionID, p.Year}]; ok {
+			continue
+		}
+		done = append(done, p)
+	}
+	if err := s.repo.ClearDirtyPartitions(context.WithoutCancel(ctx), done); err != nil {
+		slog.Error("failed to clear dirty partitions", "error", err)
+	}
+}
+
+// run выполняет задачи пулом из cfg.Workers воркеров с общим таймаутом cfg.Timeout
+// и таймаутом задачи cfg.TaskTimeout. Итог сохраняется в metrics_refresh_runs.
+func (s *MetricsService) run(ctx context.Context, kind string, tasks []refreshTask, fileIDs []int) (model.RefreshRun, error) {
+	run, err := s.repo.CreateRefreshRun(ctx, kind)
+	if err != nil {
+		return run, err
+	}
+	run.Total = len(tasks)
+	run.FileIDs = fileIDs
+	start := time.Now()
+
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	queue := make(chan refreshTask)
+	for i := 0; i < min(s.cfg.Workers, len(tasks)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range queue {
+				if err := s.runTask(ctx, run.ID, t); err != nil {
+					mu.Lock()
+					run.Failures = append(run.Failures, model.RefreshFailure{
+						RegionID: t.RegionID, RegionName: t.RegionName, Year: t.Year, Error: err.Error(),
+					})
+					errs = append(errs, fmt.Errorf("region %d year %d: %w", t.RegionID, t.Year, err))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, t := range tasks {
+		queue <- t
+	}
+	close(queue)
 	wg.Wait()
-	return nil
 
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(start).Milliseconds()
+	run.Failed = len(run.Failures)
+	switch {
+	case ctx.Err() != nil:
+		run.Status = model.RefreshStatusCanceled
+		errs = append(errs, ctx.Err())
+	case run.Failed == 0:
+		run.Status = model.RefreshStatusSuccess
+	case run.Failed == run.Total:
+		run.Status = model.RefreshStatusFailed
+	default:
+		run.Status = model.RefreshStatusPartial
+	}
+	if err := s.repo.FinishRefreshRun(context.WithoutCancel(ctx), run); err != nil {
+		errs = append(errs, err)
+	}
+	slog.Info("metrics refreshed", "kind", kind, "status", run.Status, "tasks", run.Total, "failed", run.Failed, "duration", finished.Sub(start))
+	return run, errors.Join(errs...)
+}
+
+// runTask считает и сохраняет метрики одного региона за год. Метрики с ошибкой
+// не сохраняются, чтобы не затирать прежние значения частичными.
+func (s *MetricsService) runTask(ctx context.Context, runID int, t refreshTask) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.cfg.TaskTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.TaskTimeout)
+		defer cancel()
+	}
+	var (
+		m   *model.Metrics
+		err error
+	)
+	if t.RegionID == 0 {
+		m, err = s.getMetricsAllRussia(ctx, t.Year)
+	} else {
+		gid, name := t.RegionID, t.RegionName
+		m, err = s.getMetrics(ctx, model.District{Gid: &gid, Name: &name}, t.Year)
+	}
+	if err != nil {
+		return err
+	}
+	return s.repo.UpsertMetrics(ctx, runID, m)
 }
 
-func (s *MetricsService) getMetrics(ctx context.Context, region model.District, year int) {
+// getMetrics считает метрики региона за год. Ошибки отдельных показателей объединяются.
+func (s *MetricsService) getMetrics(ctx context.Context, region model.District, year int) (*model.Metrics, error) {
+	var errs []error
 	metrics := &model.Metrics{
 		RegionId:   *region.Gid,
 		RegionName: *region.Name,
 		Year:       year,
 	}
+	tz, err := s.repo.GetRegionTimeZone(ctx, *region.Gid)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("get time zone: %w", err))
+	}
+	metrics.TimeZone = tz
 	peakLoad, err := s.repo.GetPeakLoad(ctx, *region.Gid, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get peak load: %v", err))
+		errs = append(errs, fmt.Errorf("get peak load: %w", err))
 	} else {
 		if peakLoad != nil {
 			metrics.PeakLoad = peakLoad[0].PeakLoad
@@ -64,7 +408,7 @@ func (s *MetricsService) getMetrics(ctx context.Context, region model.District,
 	}
 	total_flight_and_avg_dur, err := s.repo.TotalFlightAndAVGDuration(ctx, *region.Gid, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get total flight and avg duration: %v", err))
+		errs = append(errs, fmt.Errorf("get total flight and avg duration: %w", err))
 	} else {
 		if total_flight_and_avg_dur != nil {
 			metrics.TotalFlight = total_flight_and_avg_dur[0].TotalFlight
@@ -73,7 +417,7 @@ func (s *MetricsService) getMetrics(ctx context.Context, region model.District,
 	}
 	monthlyGrowth, err := s.repo.GetMonthlyGrowth(ctx, *region.Gid, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get monthly growth: %v", err))
+		errs = append(errs, fmt.Errorf("get monthly growth: %w", err))
 	} else {
 		if monthlyGrowth != nil {
 			metrics.MonthlyGrowth = monthlyGrowth[0].MonthlyGrowth
@@ -81,7 +425,7 @@ func (s *MetricsService) getMetrics(ctx context.Context, region model.District,
 	}
 	flightTimes, err := s.repo.GetFlightTimes(ctx, *region.Gid, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get flight times: %v", err))
+		errs = append(errs, fmt.Errorf("get flight times: %w", err))
 	} else {
 		if flightTimes != nil {
 			metrics.MorningFlights = flightTimes[0].MorningFlights
@@ -91,9 +435,15 @@ func (s *MetricsService) getMetrics(ctx context.Context, region model.District,
 			metrics.NightFlights = flightTimes[0].NightFlights
 		}
 	}
+	day, twilight, dark, err := s.repo.GetLightConditions(ctx, *region.Gid, year)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("get light conditions: %w", err))
+	} else {
+		metrics.SetLightConditions(day, twilight, dark)
+	}
 	flightDensity, err := s.repo.GetFlightDensity(ctx, *region.Gid, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get flight density: %v", err))
+		errs = append(errs, fmt.Errorf("get flight density: %w", err))
 	} else {
 		if flightDensity != nil {
 
@@ -102,7 +452,7 @@ func (s *MetricsService) getMetrics(ctx context.Context, region model.District,
 	}
 	dailyFlight, err := s.repo.GetDailyFlightMetrics(ctx, *region.Gid, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get daily flight metrics: %v", err))
+		errs = append(errs, fmt.Errorf("get daily flight metrics: %w", err))
 	} else {
 		if dailyFlight != nil {
 
@@ -113,7 +463,7 @@ func (s *MetricsService) getMetrics(ctx context.Context, region model.District,
 
 	zeroFlight, err := s.repo.GetZeroFlightDays(ctx, *region.Gid, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get zero flight days: %v", err))
+		errs = append(errs, fmt.Errorf("get zero flight days: %w", err))
 	} else {
 		if zeroFlight != nil {
 
@@ -122,7 +472,7 @@ func (s *MetricsService) getMetrics(ctx context.Context, region model.District,
 	}
 	total_distance, err := s.repo.GetTotalDistance(ctx, *region.Gid, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get total distance: %v", err))
+		errs = append(errs, fmt.Errorf("get total distance: %w", err))
 	} else {
 		if total_distance != nil {
 
@@ -130,36 +480,38 @@ func (s *MetricsService) getMetrics(ctx context.Context, region model.District,
 		}
 
 	}
-	s.metrics <- metrics
+	return metrics, errors.Join(errs...)
 }
 
-func (s *MetricsService) getMetricsAllRussia(ctx context.Context, year int) {
+func (s *MetricsService) getMetricsAllRussia(ctx context.Context, year int) (*model.Metrics, error) {
+	var errs []error
 	metrics := &model.Metrics{
 		RegionName: "Российская Федерация",
 		Year:       year,
+		TimeZone:   model.ReferenceTimeZone,
 	}
 	peakLoad, err := s.repo.GetPeakLoadAllRussia(ctx, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get peak load: %v", err))
+		errs = append(errs, fmt.Errorf("get peak load: %w", err))
 	} else {
 		metrics.PeakLoad = peakLoad
 	}
 	total_flight, avg_dur, err := s.repo.TotalFlightAndAVGDurationAllRussia(ctx, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get total flight and avg duration: %v", err))
+		errs = append(errs, fmt.Errorf("get total flight and avg duration: %w", err))
 	} else {
 		metrics.TotalFlight = total_flight
 		metrics.AvgDurationMinutes = avg_dur
 	}
 	monthlyGrowth, err := s.repo.GetRussiaMonthlyGrowth(ctx, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get monthly growth: %v", err))
+		errs = append(errs, fmt.Errorf("get monthly growth: %w", err))
 	} else {
 		metrics.MonthlyGrowth = monthlyGrowth
 	}
 	m, d, e, n, err := s.repo.GetFlightTimesAllRussia(ctx, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get flight times: %v", err))
+		errs = append(errs, fmt.Errorf("get flight times: %w", err))
 	} else {
 		metrics.MorningFlights = m
 
@@ -167,16 +519,22 @@ func (s *MetricsService) getMetricsAllRussia(ctx context.Context, year int) {
 		metrics.EveningFlights = e
 		metrics.NightFlights = n
 	}
+	day, twilight, dark, err := s.repo.GetLightConditions(ctx, 0, year)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("get light conditions: %w", err))
+	} else {
+		metrics.SetLightConditions(day, twilight, dark)
+	}
 	flightDensity, err := s.repo.GetFlightDensityAllRussia(ctx, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get flight density: %v", err))
+		errs = append(errs, fmt.Errorf("get flight density: %w", err))
 	} else {
 
 		metrics.FlightDensity = flightDensity
 	}
 	avgDailyFlights, medianDailyFlights, err := s.repo.GetDailyFlightMetricsAllRussia(ctx, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get daily flight metrics: %v", err))
+		errs = append(errs, fmt.Errorf("get daily flight metrics: %w", err))
 	} else {
 
 		metrics.AvgDailyFlights = avgDailyFlights
@@ -185,17 +543,17 @@ func (s *MetricsService) getMetricsAllRussia(ctx context.Context, year int) {
 
 	zeroFlight, err := s.repo.GetZeroFlightDaysAllRussia(ctx, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get zero flight days: %v", err))
+		errs = append(errs, fmt.Errorf("get zero flight days: %w", err))
 	} else {
 
 		metrics.ZeroFlightDays = zeroFlight
 	}
 	total_distance, err := s.repo.GetTotalDistanceAllRussia(ctx, year)
 	if err != nil {
-		slog.Error(fmt.Sprintf("error get total distance: %v", err))
+		errs = append(errs, fmt.Errorf("get total distance: %w", err))
 	} else {
 		metrics.TotalDistance = total_distance
 	}
 
-	s.metrics <- metrics
+	return metrics, errors.Join(errs...)
 }
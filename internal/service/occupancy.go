@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// Occupancy считает по дням периода, сколько полетов региона (0 — вся РФ) находилось
+// в воздухе одновременно: максимум, момент максимума и ступенчатую кривую в местном времени
+func (s *MetricsService) Occupancy(ctx context.Context, regionID int, p model.Period) (model.Occupancy, error) {
+	name, tz, err := s.regionZone(ctx, regionID)
+	if err != nil {
+		return model.Occupancy{}, err
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return model.Occupancy{}, fmt.Errorf("failed to load time zone %q: %w", tz, err)
+	}
+	intervals, err := s.repo.GetFlightIntervals(ctx, regionID, p.From, p.To)
+	if err != nil {
+		return model.Occupancy{}, err
+	}
+
+	res := model.Occupancy{RegionID: regionID, RegionName: name, Period: p, TimeZone: tz}
+	res.Days = occupancyDays(intervals, p.From, p.To)
+
+	// время из базы — местное без зоны, привязываем его к зоне региона
+	wall := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+	}
+	for i := range res.Days {
+		day := &res.Days[i]
+		day.PeakAt = wall(day.PeakAt)
+		for j := range day.Curve {
+			day.Curve[j].Time = wall(day.Curve[j].Time)
+		}
+		if day.MaxConcurrent > res.MaxConcurrent {
+			res.MaxConcurrent = day.MaxConcurrent
+			res.PeakAt = &day.PeakAt
+		}
+	}
+	return res, nil
+}
+
+// occupancyDays проходит по событиям вылета и посадки в порядке времени и делит результат
+// на сутки [from, to]. Посадка в тот же момент, что и вылет другого борта, учитывается
+// первой, а полеты нулевой длительности не учитываются вовсе.
+func occupancyDays(intervals []model.FlightInterval, from, to time.Time) []model.DayOccupancy {
+	type event struct {
+		at    time.Time
+		delta int
+	}
+	events := make([]event, 0, 2*len(intervals))
+	for _, iv := range intervals {
+		if !iv.End.After(iv.Start) {
+			continue
+		}
+		events = append(events, event{iv.Start, 1}, event{iv.End, -1})
+	}
+	slices.SortFunc(events, func(a, b event) int {
+		if c := a.at.Compare(b.at); c != 0 {
+			return c
+		}
+		return a.delta - b.delta
+	})
+
+	days := []model.DayOccupancy{}
+	count, i := 0, 0
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		next := d.AddDate(0, 0, 1)
+		for ; i < len(events) && !events[i].at.After(d); i++ {
+			count += events[i].delta
+		}
+		day := model.DayOccupancy{
+			Date:          d.Format(time.DateOnly),
+			Flights:       count,
+			MaxConcurrent: count,
+			PeakAt:        d,
+			Curve:         []model.OccupancyPoint{{Time: d, Count: count}},
+		}
+		for ; i < len(events) && events[i].at.Before(next); i++ {
+			e := events[i]
+			count += e.delta
+			if e.delta > 0 {
+				day.Flights++
+			}
+			if last := &day.Curve[len(day.Curve)-1]; last.Time.Equal(e.at) {
+				last.Count = count
+			} else {
+				day.Curve = append(day.Curve, model.OccupancyPoint{Time: e.at, Count: count})
+			}
+			if count > day.MaxConcurrent {
+				day.MaxConcurrent = count
+				day.PeakAt = e.at
+			}
+		}
+		days = append(days, day)
+	}
+	return days
+}
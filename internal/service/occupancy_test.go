@@ -0,0 +1,105 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+func TestOccupancyDays(t *testing.T) {
+	at := func(s string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	iv := func(start, end string) model.FlightInterval {
+		return model.FlightInterval{Start: at(start), End: at(end)}
+	}
+	// сутки в виде «дата flights/max peak кривая» для наглядного сравнения
+	type day struct {
+		date    string
+		flights int
+		max     int
+		peak    string
+		curve   []string
+	}
+
+	tests := []struct {
+		name      string
+		intervals []model.FlightInterval
+		from, to  string
+		want      []day
+	}{
+		{
+			name:      "no flights",
+			intervals: nil,
+			from:      "2024-05-01 00:00", to: "2024-05-01 00:00",
+			want: []day{{"2024-05-01", 0, 0, "00:00", []string{"00:00=0"}}},
+		},
+		{
+			name:      "overlapping flights",
+			intervals: []model.FlightInterval{iv("2024-05-01 11:00", "2024-05-01 13:00"), iv("2024-05-01 10:00", "2024-05-01 12:00")},
+			from:      "2024-05-01 00:00", to: "2024-05-01 00:00",
+			want: []day{{"2024-05-01", 2, 2, "11:00", []string{"00:00=0", "10:00=1", "11:00=2", "12:00=1", "13:00=0"}}},
+		},
+		{
+			name:      "landing at the moment of another departure is counted first",
+			intervals: []model.FlightInterval{iv("2024-05-01 10:00", "2024-05-01 11:00"), iv("2024-05-01 11:00", "2024-05-01 12:00")},
+			from:      "2024-05-01 00:00", to: "2024-05-01 00:00",
+			want: []day{{"2024-05-01", 2, 1, "10:00", []string{"00:00=0", "10:00=1", "11:00=1", "12:00=0"}}},
+		},
+		{
+			name:      "zero duration flight is ignored",
+			intervals: []model.FlightInterval{iv("2024-05-01 10:00", "2024-05-01 10:00")},
+			from:      "2024-05-01 00:00", to: "2024-05-01 00:00",
+			want: []day{{"2024-05-01", 0, 0, "00:00", []string{"00:00=0"}}},
+		},
+		{
+			name:      "flight over midnight counts in both days",
+			intervals: []model.FlightInterval{iv("2024-05-01 23:00", "2024-05-02 01:00")},
+			from:      "2024-05-01 00:00", to: "2024-05-02 00:00",
+			want: []day{
+				{"2024-05-01", 1, 1, "23:00", []string{"00:00=0", "23:00=1"}},
+				{"2024-05-02", 1, 1, "00:00", []string{"00:00=1", "01:00=0"}},
+			},
+		},
+		{
+			name:      "flight started before period",
+			intervals: []model.FlightInterval{iv("2024-04-30 22:00", "2024-05-01 02:00"), iv("2024-05-01 01:00", "2024-05-01 03:00")},
+			from:      "2024-05-01 00:00", to: "2024-05-01 00:00",
+			want: []day{{"2024-05-01", 2, 2, "01:00", []string{"00:00=1", "01:00=2", "02:00=1", "03:00=0"}}},
+		},
+		{
+			name:      "departure at midnight belongs to next day",
+			intervals: []model.FlightInterval{iv("2024-05-02 00:00", "2024-05-02 02:00")},
+			from:      "2024-05-01 00:00", to: "2024-05-02 00:00",
+			want: []day{
+				{"2024-05-01", 0, 0, "00:00", []string{"00:00=0"}},
+				{"2024-05-02", 1, 1, "00:00", []string{"00:00=1", "02:00=0"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days := occupancyDays(tt.intervals, at(tt.from), at(tt.to))
+			got := make([]day, 0, len(days))
+			for _, d := range days {
+				curve := make([]string, 0, len(d.Curve))
+				for _, p := range d.Curve {
+					curve = append(curve, fmt.Sprintf("%s=%d", p.Time.Format("15:04"), p.Count))
+				}
+				got = append(got, day{d.Date, d.Flights, d.MaxConcurrent, d.PeakAt.Format("15:04"), curve})
+			}
+			if !slices.EqualFunc(got, tt.want, func(a, b day) bool {
+				return a.date == b.date && a.flights == b.flights && a.max == b.max && a.peak == b.peak && slices.Equal(a.curve, b.curve)
+			}) {
+				t.Errorf("occupancyDays =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+	"github.com/Xapsiel/bpla_dashboard/internal/operator"
+)
+
+// operatorBatch — размер пачки написаний OPR/ при сведении операторов
+const operatorBatch = 1000
+
+// ErrInvalidOperatorEdit — некорректный запрос на объединение или разделение операторов
+var ErrInvalidOperatorEdit = errors.New("invalid operator edit")
+
+type OperatorService struct {
+	repo Repository
+}
+
+func NewOperatorService(repo Repository) *OperatorService {
+	return &OperatorService{repo: repo}
+}
+
+// OperatorResolver сводит новые написания OPR/ к операторам. Один экземпляр разделяют
+// загрузка файлов, телеграммы и пересчет метрик, чтобы две загрузки одновременно
+// не завели одного и того же нового оператора.
+type OperatorResolver struct {
+	repo Repository
+	mu   sync.Mutex
+}
+
+func NewOperatorResolver(repo Repository) *OperatorResolver {
+	return &OperatorResolver{repo: repo}
+}
+
+// resolve относит новые написания OPR/ к операторам: точно по ключу, по телефону
+// или нечетко по близости ключей; нераспознанные заводятся новыми операторами.
+// Вызывается после загрузки файла или телеграмм и перед пересчетом метрик.
+func (o *OperatorResolver) resolve(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var idx *operator.Index
+	total := 0
+	for {
+		raws, err := o.repo.GetUnresolvedOperators(ctx, operatorBatch)
+		if err != nil {
+			return err
+		}
+		if len(raws) == 0 {
+			break
+		}
+		if idx == nil {
+			keys, err := o.repo.GetOperatorKeys(ctx)
+			if err != nil {
+				return err
+			}
+			candidates := make([]operator.Candidate, 0, len(keys))
+			for _, k := range keys {
+				candidates = append(candidates, operator.Candidate{ID: k.ID, Key: k.Key, Phones: k.Phones})
+			}
+			idx = operator.NewIndex(candidates)
+		}
+		// операторы, заведенные в этой пачке, попадают в индекс под отрицательными ID
+		// и сохраняются по ключу, чтобы следующие написания сошлись с ними
+		pending := make(map[int]string)
+		aliases := make([]model.OperatorAlias, 0, len(raws))
+		for _, raw := range raws {
+			p := operator.Parse(raw)
+			a := model.OperatorAlias{
+				Raw:           raw,
+				Key:           p.Key,
+				Name:          p.Name,
+				Phones:        p.Phones,
+				Organizations: p.Organizations,
+			}
+			if p.Key != "" {
+				m := idx.Find(p)
+				switch {
+				case m.ID > 0:
+					a.OperatorID, a.Method, a.Score = m.ID, m.Method, m.Score
+				case m.ID < 0:
+					a.Key, a.Method, a.Score = pending[m.ID], m.Method, m.Score
+				default:
+					a.Method, a.Score = operator.MethodNew, 1
+					id := -len(pending) - 1
+					pending[id] = p.Key
+					idx.Add(operator.Candidate{ID: id, Key: p.Key, Phones: p.Phones})
+				}
+			}
+			aliases = append(aliases, a)
+		}
+		if err := o.repo.SaveOperatorAliases(ctx, aliases); err != nil {
+			return err
+		}
+		total += len(raws)
+		// ID новых операторов известны только после сохранения
+		idx = nil
+		if len(raws) < operatorBatch {
+			break
+		}
+	}
+	if total > 0 {
+		slog.Info("resolved operators", "aliases", total)
+	}
+	return nil
+}
+
+// Operators возвращает операторов с показателями за период
+func (s *OperatorService) Operators(ctx context.Context, f model.OperatorFilter) ([]model.OperatorStats, error) {
+	normalizePage(&f.Limit, &f.Offset)
+	return s.repo.GetOperatorStats(ctx, f, 0)
+}
+
+// Profile возвращает карточку оператора за период: показатели, регионы, типы БВС,
+// известные регистрационные номера и написания OPR/
+func (s *OperatorService) Profile(ctx context.Context, id int, p model.Period) (model.OperatorProfile, error) {
+	res := model.OperatorProfile{Period: p}
+	op, err := s.repo.GetOperator(ctx, id)
+	if err != nil {
+		return res, err
+	}
+	stats, err := s.repo.GetOperatorStats(ctx, model.OperatorFilter{Period: p, Limit: 1}, id)
+	if err != nil {
+		return res, err
+	}
+	if len(stats) > 0 {
+		res.OperatorStats = stats[0]
+	} else {
+		res.Hours = make([]int, 24)
+	}
+	res.Operator = op
+	if res.RegionList, res.Types, err = s.repo.GetOperatorBreakdown(ctx, id, p); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// Flights возвращает полеты оператора за период
+func (s *OperatorService) Flights(ctx context.Context, id int, f model.OperatorFilter) ([]model.OperatorFlight, error) {
+	if _, err := s.repo.GetOperator(ctx, id); err != nil {
+		return nil, err
+	}
+	normalizePage(&f.Limit, &f.Offset)
+	return s.repo.GetOperatorFlights(ctx, id, f)
+}
+
+// Merge вливает операторов-источников в целевого оператора
+func (s *OperatorService) Merge(ctx context.Context, m model.OperatorMerge) error {
+	if m.Target <= 0 || len(m.Sources) == 0 {
+		return ErrInvalidOperatorEdit
+	}
+	slices.Sort(m.Sources)
+	m.Sources = slices.Compact(m.Sources)
+	if slices.Contains(m.Sources, m.Target) {
+		return ErrInvalidOperatorEdit
+	}
+	return s.repo.MergeOperators(ctx, m)
+}
+
+// Split выделяет из оператора id нового оператора с именем sp.Name и написаниями sp.Aliases.
+// Телефоны и организации обоих операторов пересчитываются по их написаниям.
+func (s *OperatorService) Split(ctx context.Context, id int, sp model.OperatorSplit) (int, error) {
+	op, err := s.repo.GetOperator(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	name := operator.Parse(sp.Name)
+	if name.Key == "" || len(sp.Aliases) == 0 || len(sp.Aliases) >= len(op.Aliases) {
+		return 0, ErrInvalidOperatorEdit
+	}
+	split := model.OperatorAlias{Key: name.Key, Name: name.Name, Phones: []string{}, Organizations: []string{}}
+	rest := model.OperatorAlias{Phones: []string{}, Organizations: []string{}}
+	for _, a := range op.Aliases {
+		target := &rest
+		if slices.Contains(sp.Aliases, a.Raw) {
+			target = &split
+		}
+		p := operator.Parse(a.Raw)
+		for _, ph := range p.Phones {
+			if !slices.Contains(target.Phones, ph) {
+				target.Phones = append(target.Phones, ph)
+			}
+		}
+		for _, o := range p.Organizations {
+			if !slices.Contains(target.Organizations, o) {
+				target.Organizations = append(target.Organizations, o)
+			}
+		}
+	}
+	return s.repo.SplitOperator(ctx, id, sp.Aliases, split, rest)
+}
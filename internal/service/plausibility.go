@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paulmach/orb/geo"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+var ErrUnknownReason = errors.New("unknown implausibility reason")
+
+const (
+	// maxSpeedKmh — скорость по прямой вылет–посадка, выше которой полет БВС считается невозможным
+	maxSpeedKmh = 200.0
+	// maxOvernight — наибольшая правдоподобная длительность полета через полночь (ATA раньше ATD)
+	maxOvernight = 12 * time.Hour
+	// plausibilityBatch — размер пачки полетов при перепроверке
+	plausibilityBatch = 5000
+)
+
+// checkKinematics проверяет согласованность времени и маршрута полета: посадку раньше вылета
+// без правдоподобного перехода через полночь, нулевую длительность и скорость по прямой
+// выше maxSpeedKmh. Возвращает коды причин (пусто — полет правдоподобен) и скорость,
+// если ее можно вычислить.
+func checkKinematics(k model.FlightKinematics) ([]string, *float64) {
+	reasons := []string{}
+	atd, okD := clockMinutes(k.ATD)
+	ata, okA := clockMinutes(k.ATA)
+	if !okD || !okA {
+		return reasons, nil
+	}
+	duration := time.Duration(ata-atd) * time.Minute
+	switch {
+	case ata == atd:
+		return append(reasons, model.ReasonZeroDuration), nil
+	case ata < atd:
+		// посадка на следующие сутки
+		duration += 24 * time.Hour
+		if duration > maxOvernight {
+			reasons = append(reasons, model.ReasonATABeforeATD)
+		}
+	}
+	if k.ArrLatLon == nil {
+		return reasons, nil
+	}
+	km := geo.DistanceHaversine(k.DepLatLon, *k.ArrLatLon) / 1000
+	speed := km / duration.Hours()
+	if speed > maxSpeedKmh {
+		reasons = append(reasons, model.ReasonImpossibleSpeed)
+	}
+	return reasons, &speed
+}
+
+// clockMinutes переводит время чч:мм (допускаются ччмм и чч:мм:сс) в минуты от начала суток
+func clockMinutes(s string) (int, bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ":", "")
+	if len(s) != 4 && len(s) != 6 {
+		return 0, false
+	}
+	// Atoi допускает знак, поэтому цифры проверяются явно
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	h, _ := strconv.Atoi(s[:2])
+	m, _ := strconv.Atoi(s[2:4])
+	if h > 23 || m > 59 || len(s) == 6 && s[4:] > "59" {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+// validateKinematics заполняет отметки правдоподобности разобранного полета.
+// Вызывается после того, как известны ATD и ATA.
+func (p *ParserService) validateKinematics(msg *model.ParsedMessage) {
+	k := model.FlightKinematics{DepLatLon: msg.DepLatLon, ATD: msg.ATD, ATA: msg.ATA}
+	if msg.ArrCoords != "" {
+		arr := msg.ArrLatLon
+		k.ArrLatLon = &arr
+	}
+	msg.ImplausibleReasons, msg.ImpliedSpeedKmh = checkKinematics(k)
+}
+
+// checkPlausibility перепроверяет полеты, время которых изменилось после разбора (DEP/ARR)
+// или которые сохранены до появления проверки. Вызывается до выборки партиций.
+func (s *MetricsService) checkPlausibility(ctx context.Context) error {
+	total, flagged := 0, 0
+	for {
+		flights, err := s.repo.GetUncheckedFlights(ctx, plausibilityBatch)
+		if err != nil {
+			return err
+		}
+		if len(flights) == 0 {
+			break
+		}
+		res := make([]model.Plausibility, 0, len(flights))
+		for _, f := range flights {
+			reasons, speed := checkKinematics(f)
+			if len(reasons) > 0 {
+				flagged++
+			}
+			res = append(res, model.Plausibility{ID: f.ID, Reasons: reasons, ImpliedSpeedKmh: speed})
+		}
+		if err := s.repo.SetPlausibility(ctx, res); err != nil {
+			return fmt.Errorf("failed to check plausibility: %w", err)
+		}
+		total += len(flights)
+		if len(flights) < plausibilityBatch {
+			break
+		}
+	}
+	if total > 0 {
+		slog.Info("checked flight plausibility", "flights", total, "implausible", flagged)
+	}
+	return nil
+}
+
+// QualityReport возвращает сводку и список полетов, исключенных из метрик как неправдоподобные
+func (s *MetricsService) QualityReport(ctx context.Context, f model.QualityFilter) (model.QualityReport, error) {
+	switch f.Reason {
+	case "", model.ReasonATABeforeATD, model.ReasonZeroDuration, model.ReasonImpossibleSpeed:
+	default:
+		return model.QualityReport{}, fmt.Errorf("%w: %s", ErrUnknownReason, f.Reason)
+	}
+	normalizePage(&f.Limit, &f.Offset)
+	return s.repo.GetQualityReport(ctx, f)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/paulmach/orb"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+func TestClockMinutes(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		{"00:00", 0, true},
+		{"09:30", 570, true},
+		{"0930", 570, true},
+		{"23:59:59", 1439, true},
+		{" 12:05 ", 725, true},
+		{"", 0, false},
+		{"9:30", 0, false},
+		{"24:00", 0, false},
+		{"12:60", 0, false},
+		{"12:00:60", 0, false},
+		{"-1:00", 0, false},
+		{"+100", 0, false},
+		{"12ab", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := clockMinutes(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("clockMinutes(%q) = %d, %v, want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCheckKinematics(t *testing.T) {
+	moscow := orb.Point{37.6173, 55.7558}
+	// Москва — Санкт-Петербург около 634 км по прямой
+	spb := orb.Point{30.3351, 59.9343}
+	// около 11 км к востоку от Москвы
+	near := orb.Point{37.7973, 55.7558}
+	point := func(p orb.Point) *orb.Point { return &p }
+
+	tests := []struct {
+		name    string
+		k       model.FlightKinematics
+		reasons []string
+		speed   float64 // 0 — скорость не вычисляется
+	}{
+		{
+			name:    "no landing time",
+			k:       model.FlightKinematics{DepLatLon: moscow, ATD: "10:00", ArrLatLon: point(spb)},
+			reasons: []string{},
+		},
+		{
+			name:    "no landing point",
+			k:       model.FlightKinematics{DepLatLon: moscow, ATD: "10:00", ATA: "11:00"},
+			reasons: []string{},
+		},
+		{
+			name:    "plausible flight",
+			k:       model.FlightKinematics{DepLatLon: moscow, ATD: "10:00", ATA: "10:30", ArrLatLon: point(near)},
+			reasons: []string{},
+			speed:   22.5,
+		},
+		{
+			name:    "zero duration",
+			k:       model.FlightKinematics{DepLatLon: moscow, ATD: "10:00", ATA: "10:00", ArrLatLon: point(near)},
+			reasons: []string{model.ReasonZeroDuration},
+		},
+		{
+			name:    "overnight flight within limit",
+			k:       model.FlightKinematics{DepLatLon: moscow, ATD: "23:30", ATA: "00:30", ArrLatLon: point(near)},
+			reasons: []string{},
+			speed:   11.25,
+		},
+		{
+			name:    "landing before departure",
+			k:       model.FlightKinematics{DepLatLon: moscow, ATD: "10:00", ATA: "09:00"},
+			reasons: []string{model.ReasonATABeforeATD},
+		},
+		{
+			name:    "impossible speed",
+			k:       model.FlightKinematics{DepLatLon: moscow, ATD: "10:00", ATA: "12:00", ArrLatLon: point(spb)},
+			reasons: []string{model.ReasonImpossibleSpeed},
+			speed:   317,
+		},
+		{
+			name:    "long flight at possible speed",
+			k:       model.FlightKinematics{DepLatLon: moscow, ATD: "08:00", ATA: "12:00", ArrLatLon: point(spb)},
+			reasons: []string{},
+			speed:   158.5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reasons, speed := checkKinematics(tt.k)
+			if !slices.Equal(reasons, tt.reasons) {
+				t.Errorf("reasons = %v, want %v", reasons, tt.reasons)
+			}
+			switch {
+			case tt.speed == 0 && speed != nil:
+				t.Errorf("speed = %v, want nil", *speed)
+			case tt.speed != 0 && speed == nil:
+				t.Errorf("speed = nil, want about %v", tt.speed)
+			case tt.speed != 0 && math.Abs(*speed-tt.speed) > tt.speed*0.02:
+				t.Errorf("speed = %v, want about %v", *speed, tt.speed)
+			}
+		})
+	}
+}
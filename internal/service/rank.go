@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"math"
+	"slices"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+type rankField struct {
+	name  string
+	value func(m *model.Metrics) float64
+}
+
+// rankFields — показатели рейтинга регионов
+var rankFields = []rankField{
+	{"total_flight", func(m *model.Metrics) float64 { return float64(m.TotalFlight) }},
+	{"flight_density", func(m *model.Metrics) float64 { return m.FlightDensity }},
+	{"avg_duration_minutes", func(m *model.Metrics) float64 { return float64(m.AvgDurationMinutes) }},
+	{"peak_load", func(m *model.Metrics) float64 { return float64(m.PeakLoad) }},
+	{"total_distance_km", func(m *model.Metrics) float64 { return m.TotalDistance }},
+	{"avg_daily_flights", func(m *model.Metrics) float64 { return m.AvgDailyFlights }},
+	{"dark_flights", func(m *model.Metrics) float64 { return float64(m.DarkFlights) }},
+	{"night_share", func(m *model.Metrics) float64 { return m.NightShare }},
+}
+
+// IsRankField сообщает, участвует ли показатель в рейтинге
+func IsRankField(field string) bool {
+	return slices.ContainsFunc(rankFields, func(f rankField) bool { return f.name == field })
+}
+
+// Rank считает для каждого региона федеральных округов ранг, перцентиль и z-оценку
+// по каждому показателю за период. Регионы сортируются по рангу показателя sortBy.
+func (s *MetricsService) Rank(ctx context.Context, p model.Period, sortBy string) (model.MetricsRanking, error) {
+	res := model.MetricsRanking{Period: p, Regions: []model.RegionRank{}}
+	districts, err := s.repo.GetFederalDistricts(ctx)
+	if err != nil {
+		return res, err
+	}
+	res.Districts = districts
+
+	districtOf := make(map[int]string)
+	var ids []int
+	for _, fd := range districts {
+		for _, id := range fd.RegionIDs {
+			districtOf[id] = fd.Code
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return res, nil
+	}
+	metrics, err := s.PeriodMetrics(ctx, ids, p, false)
+	if err != nil {
+		return res, err
+	}
+
+	res.Regions = rankRegions(ids, districtOf, metrics, sortBy)
+	return res, nil
+}
+
+// rankRegions ранжирует регионы ids по показателям metrics. Равные значения получают
+// одинаковый ранг (1 + число регионов с большим значением), перцентиль — доля остальных
+// регионов с меньшим значением; при нулевом разбросе z-оценка равна нулю.
+func rankRegions(ids []int, districtOf map[int]string, metrics map[int]*model.Metrics, sortBy string) []model.RegionRank {
+	regions := make([]model.RegionRank, len(ids))
+	for i, id := range ids {
+		regions[i] = model.RegionRank{
+			RegionID:        id,
+			RegionName:      metrics[id].RegionName,
+			FederalDistrict: districtOf[id],
+			Ranks:           make([]model.MetricRank, len(rankFields)),
+		}
+	}
+	for j, f := range rankFields {
+		values := make([]float64, len(ids))
+		for i, id := range ids {
+			values[i] = f.value(metrics[id])
+		}
+		mean, std := meanStd(values)
+		for i, id := range ids {
+			r := model.MetricRank{Field: f.name, Value: values[i], Rank: 1, Of: len(ids)}
+			lower := 0
+			for k, v := range values {
+				if v > values[i] {
+					r.Rank++
+					if districtOf[ids[k]] == districtOf[id] {
+						r.DistrictRank++
+					}
+				} else if v < values[i] {
+					lower++
+				}
+				if districtOf[ids[k]] == districtOf[id] {
+					r.DistrictOf++
+				}
+			}
+			r.DistrictRank++
+			if len(ids) > 1 {
+				r.Percentile = float64(lower) / float64(len(ids)-1) * 100
+			}
+			if std > 0 {
+				r.ZScore = (values[i] - mean) / std
+			}
+			regions[i].Ranks[j] = r
+		}
+	}
+
+	sortIdx := slices.IndexFunc(rankFields, func(f rankField) bool { return f.name == sortBy })
+	if sortIdx < 0 {
+		sortIdx = 0
+	}
+	slices.SortStableFunc(regions, func(a, b model.RegionRank) int {
+		return a.Ranks[sortIdx].Rank - b.Ranks[sortIdx].Rank
+	})
+	return regions
+}
+
+// meanStd возвращает среднее и стандартное отклонение генеральной совокупности
+func meanStd(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
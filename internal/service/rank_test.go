@@ -0,0 +1,156 @@
+package service
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+func TestRankRegions(t *testing.T) {
+	type want struct {
+		rank, of, districtRank, districtOf int
+		percentile, zScore                 float64
+	}
+	tests := []struct {
+		name       string
+		ids        []int
+		districtOf map[int]string
+		flights    []int     // TotalFlight по ids
+		density    []float64 // FlightDensity по ids
+		sortBy     string
+		order      []int        // порядок регионов в результате
+		want       map[int]want // ранг total_flight по региону
+	}{
+		{
+			name:       "ties share the rank",
+			ids:        []int{1, 2, 3, 4},
+			districtOf: map[int]string{1: "ЦФО", 2: "ЦФО", 3: "СЗФО", 4: "СЗФО"},
+			flights:    []int{10, 20, 20, 5},
+			density:    []float64{0, 0, 0, 0},
+			sortBy:     "total_flight",
+			order:      []int{2, 3, 1, 4},
+			want: map[int]want{
+				1: {rank: 3, of: 4, districtRank: 2, districtOf: 2, percentile: 100.0 / 3, zScore: -3.75 / math.Sqrt(42.1875)},
+				2: {rank: 1, of: 4, districtRank: 1, districtOf: 2, percentile: 200.0 / 3, zScore: 6.25 / math.Sqrt(42.1875)},
+				3: {rank: 1, of: 4, districtRank: 1, districtOf: 2, percentile: 200.0 / 3, zScore: 6.25 / math.Sqrt(42.1875)},
+				4: {rank: 4, of: 4, districtRank: 2, districtOf: 2, percentile: 0, zScore: -8.75 / math.Sqrt(42.1875)},
+			},
+		},
+		{
+			name:       "sorted by another field",
+			ids:        []int{1, 2, 3, 4},
+			districtOf: map[int]string{1: "ЦФО", 2: "ЦФО", 3: "ЦФО", 4: "СЗФО"},
+			flights:    []int{1, 2, 3, 4},
+			density:    []float64{3, 1, 2, 4},
+			sortBy:     "flight_density",
+			order:      []int{4, 1, 3, 2},
+			want: map[int]want{
+				1: {rank: 4, of: 4, districtRank: 3, districtOf: 3, percentile: 0, zScore: -1.5 / math.Sqrt(1.25)},
+				4: {rank: 1, of: 4, districtRank: 1, districtOf: 1, percentile: 100, zScore: 1.5 / math.Sqrt(1.25)},
+			},
+		},
+		{
+			name:       "unknown sort field falls back to the first",
+			ids:        []int{7, 8},
+			districtOf: map[int]string{7: "ДФО", 8: "ДФО"},
+			flights:    []int{1, 3},
+			density:    []float64{5, 0},
+			sortBy:     "unknown",
+			order:      []int{8, 7},
+			want: map[int]want{
+				7: {rank: 2, of: 2, districtRank: 2, districtOf: 2, percentile: 0, zScore: -1},
+				8: {rank: 1, of: 2, districtRank: 1, districtOf: 2, percentile: 100, zScore: 1},
+			},
+		},
+		{
+			name:       "single region",
+			ids:        []int{5},
+			districtOf: map[int]string{5: "УФО"},
+			flights:    []int{42},
+			density:    []float64{1.5},
+			sortBy:     "total_flight",
+			order:      []int{5},
+			want: map[int]want{
+				5: {rank: 1, of: 1, districtRank: 1, districtOf: 1},
+			},
+		},
+		{
+			name:       "zero variance",
+			ids:        []int{1, 2, 3},
+			districtOf: map[int]string{1: "ЮФО", 2: "ЮФО", 3: "ПФО"},
+			flights:    []int{7, 7, 7},
+			density:    []float64{0, 0, 0},
+			sortBy:     "total_flight",
+			order:      []int{1, 2, 3},
+			want: map[int]want{
+				1: {rank: 1, of: 3, districtRank: 1, districtOf: 2},
+				2: {rank: 1, of: 3, districtRank: 1, districtOf: 2},
+				3: {rank: 1, of: 3, districtRank: 1, districtOf: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := make(map[int]*model.Metrics)
+			for i, id := range tt.ids {
+				metrics[id] = &model.Metrics{RegionId: id, TotalFlight: tt.flights[i], FlightDensity: tt.density[i]}
+			}
+			regions := rankRegions(tt.ids, tt.districtOf, metrics, tt.sortBy)
+
+			var order []int
+			for _, r := range regions {
+				order = append(order, r.RegionID)
+				if len(r.Ranks) != len(rankFields) {
+					t.Fatalf("region %d: %d ranks, want %d", r.RegionID, len(r.Ranks), len(rankFields))
+				}
+				if r.FederalDistrict != tt.districtOf[r.RegionID] {
+					t.Errorf("region %d: district %q, want %q", r.RegionID, r.FederalDistrict, tt.districtOf[r.RegionID])
+				}
+				for _, mr := range r.Ranks {
+					if math.IsNaN(mr.Percentile) || math.IsNaN(mr.ZScore) || math.IsInf(mr.ZScore, 0) {
+						t.Errorf("region %d %s: percentile %v, z-score %v", r.RegionID, mr.Field, mr.Percentile, mr.ZScore)
+					}
+				}
+				w, ok := tt.want[r.RegionID]
+				if !ok {
+					continue
+				}
+				got := r.Ranks[0]
+				if got.Field != "total_flight" || got.Value != float64(metrics[r.RegionID].TotalFlight) {
+					t.Errorf("region %d: first rank %s = %v", r.RegionID, got.Field, got.Value)
+				}
+				if got.Rank != w.rank || got.Of != w.of || got.DistrictRank != w.districtRank || got.DistrictOf != w.districtOf {
+					t.Errorf("region %d: rank %d/%d, district %d/%d, want %d/%d, %d/%d", r.RegionID,
+						got.Rank, got.Of, got.DistrictRank, got.DistrictOf, w.rank, w.of, w.districtRank, w.districtOf)
+				}
+				if math.Abs(got.Percentile-w.percentile) > 1e-9 || math.Abs(got.ZScore-w.zScore) > 1e-9 {
+					t.Errorf("region %d: percentile %v, z-score %v, want %v, %v", r.RegionID,
+						got.Percentile, got.ZScore, w.percentile, w.zScore)
+				}
+			}
+			if !slices.Equal(order, tt.order) {
+				t.Errorf("order %v, want %v", order, tt.order)
+			}
+		})
+	}
+}
+
+func TestMeanStd(t *testing.T) {
+	tests := []struct {
+		in        []float64
+		mean, std float64
+	}{
+		{nil, 0, 0},
+		{[]float64{3}, 3, 0},
+		{[]float64{2, 2, 2}, 2, 0},
+		{[]float64{2, 4, 4, 4, 5, 5, 7, 9}, 5, 2},
+	}
+	for _, tt := range tests {
+		mean, std := meanStd(tt.in)
+		if math.Abs(mean-tt.mean) > 1e-9 || math.Abs(std-tt.std) > 1e-9 {
+			t.Errorf("meanStd(%v) = %v, %v, want %v, %v", tt.in, mean, std, tt.mean, tt.std)
+		}
+	}
+}
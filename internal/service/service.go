@@ -9,7 +9,17 @@ import (
 )
 
 type Repository interface {
-	SaveMessage(ctx context.Context, mes *model.ParsedMessage, fileID int) error
+	SaveMessage(ctx context.Context, mes *model.ParsedMessage, fileID int, sourceTime *time.Time) error
+	GetExistingSIDs(ctx context.Context, sids []string) ([]string, error)
+	GetStoredMessage(ctx context.Context, sid string) (*model.StoredMessage, error)
+	OverwriteMessage(ctx context.Context, mes *model.ParsedMessage, fileID int, sourceTime *time.Time) error
+	SaveConflicts(ctx context.Context, fileID int, conflicts []model.Conflict) error
+	SaveFileInfo(ctx context.Context, mf model.File, validCount int, errorCount int) (int, error)
+	UpdateFileInfo(ctx context.Context, id int, mf model.File, validCount int, errorCount int) error
+	ApplyTelegram(ctx context.Context, tg model.Telegram) (bool, error)
+	ResolvePendingTelegrams(ctx context.Context, sid string) (int, error)
+	GetDirtyPartitions(ctx context.Context) ([]model.MetricsPartition, error)
+	ClearDirtyPartitions(ctx context.Context, parts []model.MetricsPartition) error
 
 	TotalFlightAndAVGDuration(ctx context.Context, regID int, year int) ([]struct {
 		RegionCode         int
@@ -65,22 +75,104 @@ type Repository interface {
 	GetFlightTimesAllRussia(ctx context.Context, year int) (int, int, int, int, error)
 	GetZeroFlightDaysAllRussia(ctx context.Context, year int) ([]time.Time, error)
 	GetTotalDistanceAllRussia(ctx context.Context, year int) (float64, error)
+	GetLightConditions(ctx context.Context, regID int, year int) (int, int, int, error)
+	GetUnclassifiedDepartures(ctx context.Context, limit int) ([]model.FlightDeparture, error)
+	SetLightConditions(ctx context.Context, conditions map[int]string) error
+	GetUncheckedFlights(ctx context.Context, limit int) ([]model.FlightKinematics, error)
+	SetPlausibility(ctx context.Context, res []model.Plausibility) error
+	GetQualityReport(ctx context.Context, f model.QualityFilter) (model.QualityReport, error)
+	GetRegionAttribution(ctx context.Context, p model.Period) (model.RegionAttributionReport, error)
+	GetAttributedFlights(ctx context.Context, f model.AttributionFilter) ([]model.AttributedFlight, error)
+
+	GetPeriodMetrics(ctx context.Context, f model.MetricsFilter) (map[int]*model.Metrics, error)
+
+	SaveZones(ctx context.Context, zones []model.RestrictedZone) ([]int, error)
+	CheckZoneViolations(ctx context.Context, messageID, zoneID int) (int, error)
+	GetZones(ctx context.Context) ([]model.RestrictedZone, error)
+	DeleteZone(ctx context.Context, id int) error
+	GetViolations(ctx context.Context, f model.ViolationFilter) ([]model.Violation, error)
+	GetViolationStats(ctx context.Context, p model.Period, group string) (model.ViolationStats, error)
+
+	DetectConflicts(ctx context.Context, day time.Time, separation float64) (int, error)
+	GetConflictDays(ctx context.Context, from, to time.Time) ([]model.FlightConflictDay, error)
+	GetFlightConflicts(ctx context.Context, f model.FlightConflictFilter) ([]model.FlightConflict, error)
+	GetConflictStats(ctx context.Context, p model.Period, group string) (model.FlightConflictStats, error)
+	DetectLaunchSites(ctx context.Context, radius float64, minFlights int) (int, error)
+	GetLaunchSites(ctx context.Context, f model.LaunchSiteFilter) ([]model.LaunchSite, error)
+	GetLaunchSitesGeoJSON(ctx context.Context, f model.LaunchSiteFilter) ([]byte, error)
+	GetUnresolvedOperators(ctx context.Context, limit int) ([]string, error)
+	SaveOperatorAliases(ctx context.Context, aliases []model.OperatorAlias) error
+	GetOperatorKeys(ctx context.Context) ([]model.OperatorKey, error)
+	MergeOperators(ctx context.Context, m model.OperatorMerge) error
+	SplitOperator(ctx context.Context, id int, raws []string, split, rest model.OperatorAlias) (int, error)
+	GetOperatorStats(ctx context.Context, f model.OperatorFilter, operatorID int) ([]model.OperatorStats, error)
+	GetOperator(ctx context.Context, id int) (model.Operator, error)
+	GetOperatorBreakdown(ctx context.Context, id int, p model.Period) ([]model.OperatorRegion, map[string]int, error)
+	GetOperatorFlights(ctx context.Context, id int, f model.OperatorFilter) ([]model.OperatorFlight, error)
+	SyncAircraft(ctx context.Context) (int, error)
+	GetAircraftStats(ctx context.Context, f model.AircraftFilter, aircraftID int) ([]model.AircraftStats, error)
+	GetAircraft(ctx context.Context, id int) (model.Aircraft, error)
+	GetAircraftOperators(ctx context.Context, id int, p model.Period) ([]model.AircraftOperator, error)
+	AnnotateAircraft(ctx context.Context, id int, ann model.AircraftAnnotation) (model.Aircraft, error)
+	GetCategoryStats(ctx context.Context, p model.Period, regionID int) ([]model.CategoryStats, error)
+	SaveArea(ctx context.Context, a model.CustomArea) (model.CustomArea, error)
+	GetAreas(ctx context.Context) ([]model.CustomArea, error)
+	GetArea(ctx context.Context, id int) (model.CustomArea, error)
+	DeleteArea(ctx context.Context, id int) error
+	GetAreaTimeZone(ctx context.Context, a model.CustomArea) (string, error)
+
+	ApplyAltitudeCeilings(ctx context.Context) (int, error)
+	GetAltitudeCeilings(ctx context.Context) ([]model.AltitudeCeiling, error)
+	SaveAltitudeCeiling(ctx context.Context, c model.AltitudeCeiling) (model.AltitudeCeiling, error)
+	DeleteAltitudeCeiling(ctx context.Context, id int) error
+	GetAltitudeCompliance(ctx context.Context, regionID int, from, to time.Time) (model.AltitudeCompliance, error)
+	GetAltitudeProfile(ctx context.Context, sid string) (model.AltitudeProfile, error)
 
 	GetRegions(ctx context.Context) []model.District
+	GetFederalDistricts(ctx context.Context) ([]model.FederalDistrict, error)
+	GetRegionTimeZone(ctx context.Context, regionID int) (string, error)
+	GetActivityMatrix(ctx context.Context, regionID int, from, to time.Time) (model.ActivityMatrix, error)
+	GetRegionFlows(ctx context.Context, f model.FlowFilter) (model.FlowMatrix, error)
+	GetFlowLinesGeoJSON(ctx context.Context, f model.FlowFilter) ([]byte, error)
+	GetFlightIntervals(ctx context.Context, regionID int, from, to time.Time) ([]model.FlightInterval, error)
 	GetFlightYears(ctx context.Context) []int
-	UpdateMetrics(ctx context.Context, metrics chan *model.Metrics) error
+	UpsertMetrics(ctx context.Context, runID int, m *model.Metrics) error
+	GetMetricsHistory(ctx context.Context, regionID int, year int) ([]model.MetricsSnapshot, error)
+	GetMetricsSnapshot(ctx context.Context, regionID int, year int, runID int) (model.MetricsSnapshot, error)
+	CreateRefreshRun(ctx context.Context, kind string) (model.RefreshRun, error)
+	FinishRefreshRun(ctx context.Context, run model.RefreshRun) error
+	GetRefreshRuns(ctx context.Context, limit int) ([]model.RefreshRun, error)
 }
 
 type Service struct {
 	*UserService
 	*MetricsService
 	*ParserService
+	*TelegramService
+	*ZoneService
+	*ConflictService
+	*AltitudeService
+	*SiteService
+	*OperatorService
+	*AircraftService
+	*AreaService
 }
 
-func New(repo Repository, cfg config.OidcConfig) Service {
+func New(repo Repository, cfg config.OidcConfig, metricsCfg config.MetricsConfig, conflictCfg config.ConflictConfig, siteCfg config.SiteConfig) Service {
+	operators := NewOperatorResolver(repo)
+	parser := NewParserService(repo, operators)
+	metrics := NewMetricsService(repo, metricsCfg, operators)
 	return Service{
-		UserService:    NewUserService(repo, cfg),
-		ParserService:  NewParserService(repo),
-		MetricsService: NewMetricsService(repo),
+		UserService:     NewUserService(repo, cfg),
+		ParserService:   parser,
+		MetricsService:  metrics,
+		TelegramService: NewTelegramService(repo, parser, metrics),
+		ZoneService:     NewZoneService(repo),
+		ConflictService: NewConflictService(repo, conflictCfg),
+		AltitudeService: NewAltitudeService(repo),
+		SiteService:     NewSiteService(repo, siteCfg),
+		OperatorService: NewOperatorService(repo),
+		AircraftService: NewAircraftService(repo),
+		AreaService:     NewAreaService(repo),
 	}
 }
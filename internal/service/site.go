@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/config"
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+var (
+	ErrSiteJobRunning = errors.New("launch site detection is already running")
+	ErrInvalidSiteArg = errors.New("invalid launch site parameters")
+)
+
+type SiteService struct {
+	repo Repository
+	cfg  config.SiteConfig
+
+	job job[model.LaunchSiteJob]
+}
+
+func NewSiteService(repo Repository, cfg config.SiteConfig) *SiteService {
+	if cfg.Radius <= 0 {
+		cfg.Radius = 300
+	}
+	if cfg.MinFlights <= 0 {
+		cfg.MinFlights = 5
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Minute
+	}
+	return &SiteService{repo: repo, cfg: cfg}
+}
+
+// Start запускает в фоне пересчет каталога мест запуска. Нулевые radius и minFlights
+// заменяются значениями из конфигурации.
+func (s *SiteService) Start(radius float64, minFlights int) error {
+	if radius == 0 {
+		radius = s.cfg.Radius
+	}
+	if minFlights == 0 {
+		minFlights = s.cfg.MinFlights
+	}
+	if radius < 0 || radius > 10000 || minFlights < 2 {
+		return fmt.Errorf("%w: radius %.0f m, min flights %d", ErrInvalidSiteArg, radius, minFlights)
+	}
+	return s.job.start(ErrSiteJobRunning, func() {
+		if _, err := s.detect(context.Background(), radius, minFlights); err != nil {
+			slog.Error("launch site detection failed", "radius", radius, "min_flights", minFlights, "error", err)
+		}
+	})
+}
+
+// Job возвращает состояние последней кластеризации
+func (s *SiteService) Job() model.LaunchSiteJob {
+	return s.job.get()
+}
+
+func (s *SiteService) detect(ctx context.Context, radius float64, minFlights int) (int, error) {
+	started := time.Now()
+	s.job.set(func(j *model.LaunchSiteJob) {
+		*j = model.LaunchSiteJob{Running: true, Radius: radius, MinFlights: minFlights, StartedAt: &started}
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	n, err := s.repo.DetectLaunchSites(ctx, radius, minFlights)
+
+	s.job.set(func(j *model.LaunchSiteJob) {
+		j.Running = false
+		j.Sites = n
+		j.DurationMs = time.Since(started).Milliseconds()
+		if err != nil {
+			j.Error = err.Error()
+		}
+	})
+	slog.Info("launch site detection finished", "radius", radius, "min_flights", minFlights, "sites", n,
+		"duration", time.Since(started), "error", err)
+	return n, err
+}
+
+// Sites возвращает рейтинг мест запуска
+func (s *SiteService) Sites(ctx context.Context, f model.LaunchSiteFilter) ([]model.LaunchSite, error) {
+	normalizePage(&f.Limit, &f.Offset)
+	return s.repo.GetLaunchSites(ctx, f)
+}
+
+// SitesGeoJSON возвращает слой контуров мест запуска
+func (s *SiteService) SitesGeoJSON(ctx context.Context, f model.LaunchSiteFilter) ([]byte, error) {
+	return s.repo.GetLaunchSitesGeoJSON(ctx, f)
+}
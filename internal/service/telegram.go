@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+// aerodrome + время в полях 13/17 ICAO-телеграмм (ZZZZ0705, UUEE1250)
+var aerodromeTimeRe = regexp.MustCompile(`^[A-Z]{4}(\d{4})$`)
+
+type TelegramService struct {
+	repo    Repository
+	parser  *ParserService
+	metrics *MetricsService
+}
+
+func NewTelegramService(repo Repository, parser *ParserService, metrics *MetricsService) *TelegramService {
+	return &TelegramService{repo: repo, parser: parser, metrics: metrics}
+}
+
+// SplitTelegrams делит поток текста на отдельные телеграммы.
+// Новая телеграмма начинается со строки "(" или "-TITLE", пустая строка и NNNN завершают текущую.
+func SplitTelegrams(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var res []string
+	var buf []string
+	flush := func() {
+		if len(buf) == 0 {
+			return
+		}
+		t := strings.Join(strings.Fields(strings.Join(buf, "")), " ")
+		if t != "" {
+			res = append(res, t)
+		}
+		buf = buf[:0]
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		upper := strings.ToUpper(line)
+		switch {
+		case line == "" || upper == "NNNN":
+			flush()
+			continue
+		case strings.HasPrefix(line, "(") || strings.HasPrefix(upper, "-TITLE"):
+			flush()
+		}
+		buf = append(buf, line)
+	}
+	flush()
+	return res
+}
+
+// Ingest разбирает и сохраняет телеграммы SHR/DEP/ARR, после чего
+// в фоне пересчитывает метрики по партициям, помеченным триггером на messages.
+// SHR с уже сохраненным SID проходит ту же проверку, что и строки файла: конфликт
+// разрешается согласно policy, временем источника для keep-newest считается время приема.
+func (t *TelegramService) Ingest(ctx context.Context, raws []string, policy string) model.IngestReport {
+	report := model.IngestReport{Received: len(raws), Results: make([]model.TelegramResult, 0, len(raws))}
+	touched := false
+	received := time.Now().UTC()
+	var conflicts []model.Conflict
+
+	for i, raw := range raws {
+		res := model.TelegramResult{Index: i}
+		tg, err := t.parseTelegram(raw)
+		if err != nil {
+			res.Status = model.TelegramStatusFailed
+			res.Error = err.Error()
+			report.Failed++
+			report.Results = append(report.Results, res)
+			continue
+		}
+		res.Type = tg.Type
+		res.SID = tg.SID
+
+		switch tg.Type {
+		case model.TelegramSHR:
+			var outcome string
+			outcome, res.Conflict, err = t.parser.storeMessage(ctx, tg.Message, 0, policy, &received)
+			if res.Conflict != nil {
+				conflicts = append(conflicts, *res.Conflict)
+				report.Conflicts++
+			}
+			if err != nil {
+				break
+			}
+			switch outcome {
+			case storeDuplicate:
+				res.Status = model.TelegramStatusDuplicate
+				report.Duplicates++
+				report.Results = append(report.Results, res)
+				continue
+			case storeKept:
+				res.Status = model.TelegramStatusConflict
+				report.Results = append(report.Results, res)
+				continue
+			case storeOverwritten:
+				res.Status = model.TelegramStatusConflict
+			default:
+				res.Status = model.TelegramStatusSaved
+			}
+			report.Saved++
+			if _, err = t.repo.ResolvePendingTelegrams(ctx, tg.SID); err != nil {
+				slog.Error("failed to resolve pending telegrams", "sid", tg.SID, "error", err)
+				err = nil
+			}
+			touched = true
+		default:
+			var matched bool
+			matched, err = t.repo.ApplyTelegram(ctx, tg)
+			if err != nil {
+				break
+			}
+			if matched {
+				res.Status = model.TelegramStatusMatched
+				report.Matched++
+				touched = true
+			} else {
+				res.Status = model.TelegramStatusPending
+				report.Pending++
+			}
+		}
+		if err != nil {
+			slog.Error("failed to ingest telegram", "type", tg.Type, "sid", tg.SID, "error", err)
+			res.Status = model.TelegramStatusFailed
+			res.Error = err.Error()
+			report.Failed++
+		}
+		report.Results = append(report.Results, res)
+	}
+
+	if err := t.repo.SaveConflicts(ctx, 0, conflicts); err != nil {
+		slog.Error("failed to save telegram conflicts", "error", err)
+	}
+	if touched {
+		if err := t.parser.operators.resolve(ctx); err != nil {
+			slog.Error("failed to resolve telegram operators", "error", err)
+		}
+		go t.refresh()
+	}
+	return report
+}
+
+func (t *TelegramService) refresh() {
+	if _, err := t.metrics.Refresh(context.Background()); err != nil {
+		slog.Error("failed to refresh metrics after telegrams", "error", err)
+	}
+}
+
+func (t *TelegramService) parseTelegram(raw string) (model.Telegram, error) {
+	raw = strings.TrimSpace(raw)
+	upper := strings.ToUpper(raw)
+	body := strings.TrimLeft(upper, `("' `)
+	tg := model.Telegram{Raw: raw}
+
+	switch {
+	case strings.Contains(upper, "TITLE IDEP"):
+		tg.Type = model.TelegramDEP
+		fields := t.parseTitledFields(raw)
+		tg.SID = fields["SID"]
+		tg.REG = fields["REG"]
+		tg.DOF = fields["ADD"]
+		tg.Time = fields["ATD"]
+	case strings.Contains(upper, "TITLE IARR"):
+		tg.Type = model.TelegramARR
+		fields := t.parseTitledFields(raw)
+		tg.SID = fields["SID"]
+		tg.REG = fields["REG"]
+		// ADA — дата посадки: после полуночи она на сутки позже даты полета,
+		// это учитывается при сопоставлении
+		tg.DOF = fields["ADA"]
+		tg.Time = fields["ATA"]
+	case strings.HasPrefix(body, "SHR"):
+		tg.Type = model.TelegramSHR
+		msg, _, errs := t.parser.parseSHR(raw, "")
+		if msg.SID == "" || msg.DepCoords == "" {
+			if len(errs) > 0 {
+				return tg, fmt.Errorf("invalid SHR: %s", strings.Join(errs, "; "))
+			}
+			return tg, errors.New("invalid SHR: missing SID or DEP")
+		}
+		t.parser.validateKinematics(&msg)
+		tg.SID = msg.SID
+		tg.DOF = msg.DOF
+		tg.REG = msg.REG
+		tg.Message = &msg
+		return tg, nil
+	case strings.HasPrefix(body, "DEP"), strings.HasPrefix(body, "ARR"):
+		t.parseICAO(strings.Trim(strings.ReplaceAll(upper, " ", ""), `()"`), &tg)
+	default:
+		return tg, errors.New("unknown telegram type")
+	}
+
+	if tg.SID == "" {
+		return tg, fmt.Errorf("%s without SID", tg.Type)
+	}
+	if tg.DOF != "" {
+		valid, dof := t.parser.validateDate(tg.DOF)
+		if !valid {
+			return tg, fmt.Errorf("invalid date: '%s'", tg.DOF)
+		}
+		tg.DOF = dof
+	}
+	valid, tm := t.parser.validateTime(tg.Time)
+	if !valid {
+		return tg, fmt.Errorf("invalid time: '%s'", tg.Time)
+	}
+	tg.Time = tm
+	return tg, nil
+}
+
+// parseTitledFields разбирает формат "-TITLE IDEP -SID 777 -ADD 250201 -ATD 0705".
+// Поле начинается с "-" в начале слова, поэтому дефис внутри значения (REG RA-1234)
+// не разрывает его.
+func (t *TelegramService) parseTitledFields(raw string) map[string]string {
+	fields := make(map[string]string)
+	key := ""
+	var value []string
+	flush := func() {
+		if key != "" {
+			fields[key] = strings.Join(value, " ")
+		}
+	}
+	for _, word := range strings.Fields(raw) {
+		if len(word) > 1 && word[0] == '-' {
+			flush()
+			key, value = strings.ToUpper(word[1:]), value[:0]
+			continue
+		}
+		if key != "" {
+			value = append(value, word)
+		}
+	}
+	flush()
+	return fields
+}
+
+// parseICAO разбирает (DEP-REG-ZZZZ0705-ZZZZ-DOF/250201 SID/777) и (ARR-REG-ZZZZ0705-ZZZZ1250-...)
+func (t *TelegramService) parseICAO(raw string, tg *model.Telegram) {
+	parts := strings.Split(raw, "-")
+	tg.Type = parts[0]
+	if len(parts) > 1 {
+		tg.REG = parts[1]
+	}
+	timeIdx := 2
+	if tg.Type == model.TelegramARR {
+		timeIdx = 3
+	}
+	if len(parts) > timeIdx {
+		if m := aerodromeTimeRe.FindStringSubmatch(parts[timeIdx]); m != nil {
+			tg.Time = m[1]
+		}
+	}
+	if len(parts) > timeIdx+1 {
+		kv := t.parser.parseField18(strings.Join(parts[timeIdx+1:], "-"))
+		tg.SID = kv["SID/"]
+		tg.DOF = kv["DOF/"]
+		if reg, ok := kv["REG/"]; ok {
+			tg.REG = reg
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+func TestParseTitledFields(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want map[string]string
+	}{
+		{
+			name: "departure",
+			raw:  "-TITLE IDEP -SID 7772251137 -ADD 250201 -ATD 0705",
+			want: map[string]string{"TITLE": "IDEP", "SID": "7772251137", "ADD": "250201", "ATD": "0705"},
+		},
+		{
+			name: "hyphen inside value",
+			raw:  "-TITLE IARR -SID 777 -REG RA-1234 -ADA 250202 -ATA 0015",
+			want: map[string]string{"TITLE": "IARR", "SID": "777", "REG": "RA-1234", "ADA": "250202", "ATA": "0015"},
+		},
+		{
+			name: "multi-word value with hyphens",
+			raw:  "-TITLE IDEP -SID 777 -OPR ООО Аэро-Сервис  тел. 8-912-345-67-89 -ATD 0705",
+			want: map[string]string{"TITLE": "IDEP", "SID": "777", "OPR": "ООО Аэро-Сервис тел. 8-912-345-67-89", "ATD": "0705"},
+		},
+		{
+			name: "lowercase key and empty value",
+			raw:  "-title IDEP -reg -sid 777",
+			want: map[string]string{"TITLE": "IDEP", "REG": "", "SID": "777"},
+		},
+		{
+			name: "text before first field and lone dash",
+			raw:  "ZCZC -TITLE IDEP - -SID 777",
+			want: map[string]string{"TITLE": "IDEP -", "SID": "777"},
+		},
+	}
+	ts := &TelegramService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ts.parseTitledFields(tt.raw); !maps.Equal(got, tt.want) {
+				t.Errorf("parseTitledFields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTelegramTitled(t *testing.T) {
+	ts := &TelegramService{parser: &ParserService{}}
+	tg, err := ts.parseTelegram("-TITLE IARR -SID 777 -REG RA-1234 -ADA 250202 -ATA 0015")
+	if err != nil {
+		t.Fatalf("parseTelegram error: %v", err)
+	}
+	want := model.Telegram{Type: model.TelegramARR, SID: "777", REG: "RA-1234", DOF: "2025-02-02", Time: "00:15"}
+	if tg.Type != want.Type || tg.SID != want.SID || tg.REG != want.REG || tg.DOF != want.DOF || tg.Time != want.Time {
+		t.Errorf("parseTelegram = %+v, want %+v", tg, want)
+	}
+}
@@ -0,0 +1,332 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+var ErrUnsupportedZoneFile = errors.New("unsupported zone file: expected .geojson, .json or .kml")
+
+type ZoneService struct {
+	repo Repository
+}
+
+func NewZoneService(repo Repository) *ZoneService {
+	return &ZoneService{repo: repo}
+}
+
+// ImportZones разбирает файл зон (GeoJSON или KML), сохраняет зоны и проверяет
+// по ним все сохраненные полеты
+func (s *ZoneService) ImportZones(ctx context.Context, filename string, data []byte) (model.ZoneImport, error) {
+	res := model.ZoneImport{Source: filename, Zones: []int{}}
+	var zones []model.RestrictedZone
+	var err error
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".geojson", ".json":
+		zones, err = parseGeoJSONZones(data)
+	case ".kml":
+		zones, err = parseKMLZones(data)
+	default:
+		return res, ErrUnsupportedZoneFile
+	}
+	if err != nil {
+		return res, err
+	}
+	if len(zones) == 0 {
+		return res, errors.New("no polygon zones in file")
+	}
+	for i := range zones {
+		zones[i].Source = filename
+	}
+
+	if res.Zones, err = s.repo.SaveZones(ctx, zones); err != nil {
+		return res, err
+	}
+	for _, id := range res.Zones {
+		n, err := s.repo.CheckZoneViolations(ctx, 0, id)
+		if err != nil {
+			return res, err
+		}
+		res.Violations += n
+	}
+	// потолки по типу зоны зависят от того, из каких зон выполнялся вылет
+	if _, err := s.repo.ApplyAltitudeCeilings(ctx); err != nil {
+		return res, err
+	}
+	slog.Info("imported restricted zones", "source", filename, "zones", len(res.Zones), "violations", res.Violations)
+	return res, nil
+}
+
+// Zones возвращает загруженные зоны
+func (s *ZoneService) Zones(ctx context.Context) ([]model.RestrictedZone, error) {
+	return s.repo.GetZones(ctx)
+}
+
+// DeleteZone удаляет зону вместе с найденными по ней нарушениями
+func (s *ZoneService) DeleteZone(ctx context.Context, id int) error {
+	if err := s.repo.DeleteZone(ctx, id); err != nil {
+		return err
+	}
+	_, err := s.repo.ApplyAltitudeCeilings(ctx)
+	return err
+}
+
+// Recheck перепроверяет все полеты против всех зон
+func (s *ZoneService) Recheck(ctx context.Context) (int, error) {
+	return s.repo.CheckZoneViolations(ctx, 0, 0)
+}
+
+// Violations возвращает нарушения зон по фильтру
+func (s *ZoneService) Violations(ctx context.Context, f model.ViolationFilter) ([]model.Violation, error) {
+	normalizePage(&f.Limit, &f.Offset)
+	return s.repo.GetViolations(ctx, f)
+}
+
+// ViolationStats считает нарушения за период по регионам или операторам
+func (s *ZoneService) ViolationStats(ctx context.Context, p model.Period, group string) (model.ViolationStats, error) {
+	if group != "operator" {
+		group = "region"
+	}
+	return s.repo.GetViolationStats(ctx, p, group)
+}
+
+func parseGeoJSONZones(data []byte) ([]model.RestrictedZone, error) {
+	fc, err := geojson.UnmarshalFeatureCollection(data)
+	if err != nil || fc.Type != "FeatureCollection" {
+		f, ferr := geojson.UnmarshalFeature(data)
+		if ferr != nil {
+			return nil, fmt.Errorf("invalid GeoJSON: %w", errors.Join(err, ferr))
+		}
+		fc = geojson.NewFeatureCollection().Append(f)
+	}
+	zones := make([]model.RestrictedZone, 0, len(fc.Features))
+	for i, f := range fc.Features {
+		z, err := newZone(f.Geometry, f.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+		zones = append(zones, z)
+	}
+	return zones, nil
+}
+
+type kmlPlacemark struct {
+	Name string `xml:"name"`
+	Data []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value"`
+	} `xml:"ExtendedData>Data"`
+	SimpleData []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"ExtendedData>SchemaData>SimpleData"`
+	Polygons      []kmlPolygon `xml:"Polygon"`
+	MultiPolygons []kmlPolygon `xml:"MultiGeometry>Polygon"`
+}
+
+type kmlPolygon struct {
+	Outer string   `xml:"outerBoundaryIs>LinearRing>coordinates"`
+	Inner []string `xml:"innerBoundaryIs>LinearRing>coordinates"`
+}
+
+// parseKMLZones читает Placemark с полигонами на любой глубине вложенности Document/Folder;
+// атрибуты зоны берутся из ExtendedData
+func parseKMLZones(data []byte) ([]model.RestrictedZone, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var zones []model.RestrictedZone
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid KML: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "Placemark" {
+			continue
+		}
+		var pm kmlPlacemark
+		if err := dec.DecodeElement(&pm, &start); err != nil {
+			return nil, fmt.Errorf("invalid KML placemark: %w", err)
+		}
+		props := map[string]any{}
+		if name := strings.TrimSpace(pm.Name); name != "" {
+			props["name"] = name
+		}
+		for _, d := range pm.Data {
+			props[d.Name] = strings.TrimSpace(d.Value)
+		}
+		for _, d := range pm.SimpleData {
+			props[d.Name] = strings.TrimSpace(d.Value)
+		}
+		var mp orb.MultiPolygon
+		for _, p := range append(pm.Polygons, pm.MultiPolygons...) {
+			poly, err := p.polygon()
+			if err != nil {
+				return nil, fmt.Errorf("placemark %q: %w", pm.Name, err)
+			}
+			mp = append(mp, poly)
+		}
+		if len(mp) == 0 {
+			continue
+		}
+		var geom orb.Geometry = mp
+		if len(mp) == 1 {
+			geom = mp[0]
+		}
+		z, err := newZone(geom, props)
+		if err != nil {
+			return nil, fmt.Errorf("placemark %q: %w", pm.Name, err)
+		}
+		zones = append(zones, z)
+	}
+	return zones, nil
+}
+
+func (p kmlPolygon) polygon() (orb.Polygon, error) {
+	outer, err := kmlRing(p.Outer)
+	if err != nil {
+		return nil, err
+	}
+	poly := orb.Polygon{outer}
+	for _, in := range p.Inner {
+		ring, err := kmlRing(in)
+		if err != nil {
+			return nil, err
+		}
+		poly = append(poly, ring)
+	}
+	return poly, nil
+}
+
+// kmlRing разбирает "lon,lat[,alt] lon,lat[,alt] ..."
+func kmlRing(coords string) (orb.Ring, error) {
+	var ring orb.Ring
+	for _, tuple := range strings.Fields(coords) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid coordinate %q", tuple)
+		}
+		lon, err1 := strconv.ParseFloat(parts[0], 64)
+		lat, err2 := strconv.ParseFloat(parts[1], 64)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("invalid coordinate %q", tuple)
+		}
+		ring = append(ring, orb.Point{lon, lat})
+	}
+	if len(ring) < 4 {
+		return nil, errors.New("ring must have at least 4 points")
+	}
+	if !ring.Closed() {
+		ring = append(ring, ring[0])
+	}
+	return ring, nil
+}
+
+// newZone собирает зону из геометрии и свойств: name, kind (no_fly/restricted),
+// min_alt/max_alt в метрах, active_from/active_to (ЧЧ:ММ UTC), valid_from/valid_to (RFC 3339 или дата)
+func newZone(geom orb.Geometry, props map[string]any) (model.RestrictedZone, error) {
+	z := model.RestrictedZone{Kind: model.ZoneRestricted, Properties: props, Geometry: geom}
+	switch geom.(type) {
+	case orb.Polygon, orb.MultiPolygon:
+	default:
+		return z, fmt.Errorf("geometry must be Polygon or MultiPolygon, got %T", geom)
+	}
+	str := func(keys ...string) string {
+		for _, k := range keys {
+			if v, ok := props[k]; ok && v != nil {
+				if s := strings.TrimSpace(fmt.Sprint(v)); s != "" {
+					return s
+				}
+			}
+		}
+		return ""
+	}
+
+	z.Name = str("name", "title", "Name")
+	if z.Name == "" {
+		z.Name = "Без названия"
+	}
+	switch kind := strings.ToLower(str("kind", "type", "zone_type")); kind {
+	case "", model.ZoneRestricted:
+	case model.ZoneNoFly, "no-fly", "nofly", "prohibited", "запретная":
+		z.Kind = model.ZoneNoFly
+	default:
+		return z, fmt.Errorf("unknown zone kind %q", kind)
+	}
+
+	for _, alt := range []struct {
+		key    string
+		target **int
+	}{{"min_alt", &z.MinAlt}, {"max_alt", &z.MaxAlt}} {
+		v := str(alt.key)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return z, fmt.Errorf("invalid %s: %q", alt.key, v)
+		}
+		n := int(f)
+		*alt.target = &n
+	}
+	if z.MinAlt != nil && z.MaxAlt != nil && *z.MinAlt > *z.MaxAlt {
+		return z, errors.New("min_alt is greater than max_alt")
+	}
+
+	for _, w := range []struct {
+		key    string
+		target **string
+	}{{"active_from", &z.ActiveFrom}, {"active_to", &z.ActiveTo}} {
+		v := str(w.key)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse("15:04", v)
+		if err != nil {
+			return z, fmt.Errorf("invalid %s: %q, expected HH:MM", w.key, v)
+		}
+		s := t.Format("15:04")
+		*w.target = &s
+	}
+	if (z.ActiveFrom == nil) != (z.ActiveTo == nil) {
+		return z, errors.New("active_from and active_to must be set together")
+	}
+
+	for _, d := range []struct {
+		key    string
+		target **time.Time
+	}{{"valid_from", &z.ValidFrom}, {"valid_to", &z.ValidTo}} {
+		v := str(d.key)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			if t, err = time.Parse(time.DateOnly, v); err != nil {
+				return z, fmt.Errorf("invalid %s: %q", d.key, v)
+			}
+			if d.key == "valid_to" {
+				t = t.Add(24*time.Hour - time.Second)
+			}
+		}
+		t = t.UTC()
+		*d.target = &t
+	}
+	return z, nil
+}
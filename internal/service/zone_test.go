@@ -0,0 +1,369 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paulmach/orb"
+
+	"github.com/Xapsiel/bpla_dashboard/internal/model"
+)
+
+var testSquare = orb.Polygon{{{37, 55}, {38, 55}, {38, 56}, {37, 56}, {37, 55}}}
+
+func TestNewZone(t *testing.T) {
+	tests := []struct {
+		name  string
+		geom  orb.Geometry
+		props map[string]any
+		want  string // "kind name alt window valid"; пусто — ошибка
+	}{
+		{
+			name: "defaults",
+			geom: testSquare,
+			want: "restricted Без названия -..- -..- -..-",
+		},
+		{
+			name:  "multipolygon and title alias",
+			geom:  orb.MultiPolygon{testSquare, testSquare},
+			props: map[string]any{"title": " Кремль ", "kind": "restricted"},
+			want:  "restricted Кремль -..- -..- -..-",
+		},
+		{
+			name:  "no_fly",
+			geom:  testSquare,
+			props: map[string]any{"name": "A", "kind": "no_fly"},
+			want:  "no_fly A -..- -..- -..-",
+		},
+		{
+			name:  "kind alias no-fly",
+			geom:  testSquare,
+			props: map[string]any{"name": "A", "type": "No-Fly"},
+			want:  "no_fly A -..- -..- -..-",
+		},
+		{
+			name:  "kind alias prohibited",
+			geom:  testSquare,
+			props: map[string]any{"name": "A", "zone_type": "PROHIBITED"},
+			want:  "no_fly A -..- -..- -..-",
+		},
+		{
+			name:  "kind alias russian",
+			geom:  testSquare,
+			props: map[string]any{"name": "A", "kind": "Запретная"},
+			want:  "no_fly A -..- -..- -..-",
+		},
+		{
+			name:  "unknown kind",
+			geom:  testSquare,
+			props: map[string]any{"kind": "danger"},
+		},
+		{
+			name:  "altitudes from numbers and strings",
+			geom:  testSquare,
+			props: map[string]any{"name": "A", "min_alt": 0.0, "max_alt": "150.7"},
+			want:  "restricted A 0..150 -..- -..-",
+		},
+		{
+			name:  "only max altitude",
+			geom:  testSquare,
+			props: map[string]any{"name": "A", "max_alt": 300},
+			want:  "restricted A -..300 -..- -..-",
+		},
+		{
+			name:  "min altitude above max",
+			geom:  testSquare,
+			props: map[string]any{"min_alt": 500, "max_alt": 100},
+		},
+		{
+			name:  "invalid altitude",
+			geom:  testSquare,
+			props: map[string]any{"max_alt": "high"},
+		},
+		{
+			name:  "active window",
+			geom:  testSquare,
+			props: map[string]any{"name": "A", "active_from": "22:00", "active_to": "06:30"},
+			want:  "restricted A -..- 22:00..06:30 -..-",
+		},
+		{
+			name:  "active_from without active_to",
+			geom:  testSquare,
+			props: map[string]any{"active_from": "08:00"},
+		},
+		{
+			name:  "active_to without active_from",
+			geom:  testSquare,
+			props: map[string]any{"active_to": "20:00", "active_from": " "},
+		},
+		{
+			name:  "invalid active time",
+			geom:  testSquare,
+			props: map[string]any{"active_from": "25:00", "active_to": "06:00"},
+		},
+		{
+			name:  "date-only validity covers the whole last day",
+			geom:  testSquare,
+			props: map[string]any{"name": "A", "valid_from": "2025-01-01", "valid_to": "2025-01-31"},
+			want:  "restricted A -..- -..- 2025-01-01T00:00:00Z..2025-01-31T23:59:59Z",
+		},
+		{
+			name:  "RFC 3339 validity converted to UTC",
+			geom:  testSquare,
+			props: map[string]any{"name": "A", "valid_from": "2025-01-01T03:00:00+03:00", "valid_to": "2025-01-31T12:00:00+03:00"},
+			want:  "restricted A -..- -..- 2025-01-01T00:00:00Z..2025-01-31T09:00:00Z",
+		},
+		{
+			name:  "invalid validity date",
+			geom:  testSquare,
+			props: map[string]any{"valid_to": "31.01.2025"},
+		},
+		{
+			name: "point geometry",
+			geom: orb.Point{37, 55},
+		},
+		{
+			name: "line geometry",
+			geom: orb.LineString{{37, 55}, {38, 56}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := tt.props
+			if props == nil {
+				props = map[string]any{}
+			}
+			z, err := newZone(tt.geom, props)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("newZone = %s, want error", describeZone(z))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newZone error: %v", err)
+			}
+			if got := describeZone(z); got != tt.want {
+				t.Errorf("newZone = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGeoJSONZones(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		want  []string // describeZone каждой зоны; nil — ошибка
+		rings []int    // число колец первого полигона каждой зоны
+	}{
+		{
+			name: "feature collection",
+			data: `{"type":"FeatureCollection","features":[
+				{"type":"Feature","properties":{"name":"A","kind":"no_fly","max_alt":120},
+				 "geometry":{"type":"Polygon","coordinates":[[[37,55],[38,55],[38,56],[37,56],[37,55]],
+				  [[37.2,55.2],[37.8,55.2],[37.8,55.8],[37.2,55.8],[37.2,55.2]]]}},
+				{"type":"Feature","properties":{"title":"B","active_from":"08:00","active_to":"20:00"},
+				 "geometry":{"type":"MultiPolygon","coordinates":[[[[30,60],[31,60],[31,61],[30,61],[30,60]]]]}}
+			]}`,
+			want:  []string{"no_fly A -..120 -..- -..-", "restricted B -..- 08:00..20:00 -..-"},
+			rings: []int{2, 1},
+		},
+		{
+			name: "single feature",
+			data: `{"type":"Feature","properties":{"name":"A","valid_to":"2025-06-30"},
+				"geometry":{"type":"Polygon","coordinates":[[[37,55],[38,55],[38,56],[37,55]]]}}`,
+			want:  []string{"restricted A -..- -..- -..2025-06-30T23:59:59Z"},
+			rings: []int{1},
+		},
+		{
+			name: "empty collection",
+			data: `{"type":"FeatureCollection","features":[]}`,
+			want: []string{},
+		},
+		{
+			name: "point feature",
+			data: `{"type":"FeatureCollection","features":[
+				{"type":"Feature","properties":{},"geometry":{"type":"Point","coordinates":[37,55]}}]}`,
+		},
+		{
+			name: "unpaired active window",
+			data: `{"type":"Feature","properties":{"active_to":"06:00"},
+				"geometry":{"type":"Polygon","coordinates":[[[37,55],[38,55],[38,56],[37,55]]]}}`,
+		},
+		{
+			name: "not json",
+			data: `<kml/>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zones, err := parseGeoJSONZones([]byte(tt.data))
+			checkZones(t, zones, err, tt.want, tt.rings)
+		})
+	}
+}
+
+func TestParseKMLZones(t *testing.T) {
+	const square = `37,55,0 38,55,0 38,56,0 37,56,0 37,55,0`
+	tests := []struct {
+		name  string
+		data  string
+		want  []string
+		rings []int
+	}{
+		{
+			name: "nested folders and extended data",
+			data: `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2"><Document><name>Зоны</name>
+ <Folder><name>Москва</name>
+  <Placemark><name>Кремль</name>
+   <ExtendedData>
+    <Data name="kind"><value>no_fly</value></Data>
+    <Data name="max_alt"><value> 150 </value></Data>
+   </ExtendedData>
+   <Polygon><outerBoundaryIs><LinearRing><coordinates>` + square + `</coordinates></LinearRing></outerBoundaryIs></Polygon>
+  </Placemark>
+  <Folder><name>Область</name>
+   <Placemark><name>Аэродром</name>
+    <ExtendedData><SchemaData schemaUrl="#zones">
+     <SimpleData name="active_from">06:00</SimpleData>
+     <SimpleData name="active_to">22:00</SimpleData>
+    </SchemaData></ExtendedData>
+    <Polygon><outerBoundaryIs><LinearRing><coordinates>` + square + `</coordinates></LinearRing></outerBoundaryIs></Polygon>
+   </Placemark>
+  </Folder>
+ </Folder>
+ <Placemark><name>Точка</name><Point><coordinates>37,55</coordinates></Point></Placemark>
+</Document></kml>`,
+			want:  []string{"no_fly Кремль -..150 -..- -..-", "restricted Аэродром -..- 06:00..22:00 -..-"},
+			rings: []int{1, 1},
+		},
+		{
+			name: "inner rings and multigeometry",
+			data: `<kml><Placemark><name>Дырявая</name>
+ <ExtendedData><Data name="valid_to"><value>2025-12-31</value></Data></ExtendedData>
+ <MultiGeometry>
+  <Polygon>
+   <outerBoundaryIs><LinearRing><coordinates>` + square + `</coordinates></LinearRing></outerBoundaryIs>
+   <innerBoundaryIs><LinearRing><coordinates>37.2,55.2 37.4,55.2 37.4,55.4 37.2,55.4 37.2,55.2</coordinates></LinearRing></innerBoundaryIs>
+   <innerBoundaryIs><LinearRing><coordinates>37.6,55.6 37.8,55.6 37.8,55.8 37.6,55.8 37.6,55.6</coordinates></LinearRing></innerBoundaryIs>
+  </Polygon>
+  <Polygon><outerBoundaryIs><LinearRing><coordinates>30,60 31,60 31,61 30,61 30,60</coordinates></LinearRing></outerBoundaryIs></Polygon>
+ </MultiGeometry>
+</Placemark></kml>`,
+			want:  []string{"restricted Дырявая -..- -..- -..2025-12-31T23:59:59Z"},
+			rings: []int{3},
+		},
+		{
+			name: "unclosed ring is closed",
+			data: `<kml><Placemark><name>A</name><Polygon><outerBoundaryIs><LinearRing>
+<coordinates>37,55 38,55 38,56 37,56</coordinates></LinearRing></outerBoundaryIs></Polygon></Placemark></kml>`,
+			want:  []string{"restricted A -..- -..- -..-"},
+			rings: []int{1},
+		},
+		{
+			name: "ring with too few points",
+			data: `<kml><Placemark><name>A</name><Polygon><outerBoundaryIs><LinearRing>
+<coordinates>37,55 38,55 37,55</coordinates></LinearRing></outerBoundaryIs></Polygon></Placemark></kml>`,
+		},
+		{
+			name: "invalid coordinate",
+			data: `<kml><Placemark><name>A</name><Polygon><outerBoundaryIs><LinearRing>
+<coordinates>37,55 38;55 38,56 37,56 37,55</coordinates></LinearRing></outerBoundaryIs></Polygon></Placemark></kml>`,
+		},
+		{
+			name: "invalid inner ring",
+			data: `<kml><Placemark><name>A</name><Polygon>
+<outerBoundaryIs><LinearRing><coordinates>` + square + `</coordinates></LinearRing></outerBoundaryIs>
+<innerBoundaryIs><LinearRing><coordinates>37.2,55.2 37.4,55.2</coordinates></LinearRing></innerBoundaryIs>
+</Polygon></Placemark></kml>`,
+		},
+		{
+			name: "unknown kind",
+			data: `<kml><Placemark><name>A</name><ExtendedData><Data name="kind"><value>danger</value></Data></ExtendedData>
+<Polygon><outerBoundaryIs><LinearRing><coordinates>` + square + `</coordinates></LinearRing></outerBoundaryIs></Polygon></Placemark></kml>`,
+		},
+		{
+			name: "no polygons",
+			data: `<kml><Document><Placemark><name>A</name><Point><coordinates>37,55</coordinates></Point></Placemark></Document></kml>`,
+			want: []string{},
+		},
+		{
+			name: "broken xml",
+			data: `<kml><Placemark><name>A</name>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zones, err := parseKMLZones([]byte(tt.data))
+			checkZones(t, zones, err, tt.want, tt.rings)
+		})
+	}
+}
+
+func checkZones(t *testing.T, zones []model.RestrictedZone, err error, want []string, rings []int) {
+	t.Helper()
+	if want == nil {
+		if err == nil {
+			t.Fatalf("got %d zones, want error", len(zones))
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(zones) != len(want) {
+		t.Fatalf("got %d zones, want %d", len(zones), len(want))
+	}
+	for i, z := range zones {
+		if got := describeZone(z); got != want[i] {
+			t.Errorf("zone %d = %q, want %q", i, got, want[i])
+		}
+		var poly orb.Polygon
+		switch g := z.Geometry.(type) {
+		case orb.Polygon:
+			poly = g
+		case orb.MultiPolygon:
+			poly = g[0]
+		}
+		if len(poly) != rings[i] {
+			t.Errorf("zone %d: %d rings, want %d", i, len(poly), rings[i])
+		}
+		for j, ring := range poly {
+			if !ring.Closed() || len(ring) < 4 {
+				t.Errorf("zone %d ring %d is not a closed ring: %v", i, j, ring)
+			}
+		}
+	}
+}
+
+// describeZone — "kind name min..max from..to validFrom..validTo", "-" — значение не задано
+func describeZone(z model.RestrictedZone) string {
+	alt := func(v *int) string {
+		if v == nil {
+			return "-"
+		}
+		return strconv.Itoa(*v)
+	}
+	str := func(v *string) string {
+		if v == nil {
+			return "-"
+		}
+		return *v
+	}
+	tm := func(v *time.Time) string {
+		if v == nil {
+			return "-"
+		}
+		return v.Format(time.RFC3339)
+	}
+	return strings.Join([]string{
+		z.Kind, z.Name,
+		alt(z.MinAlt) + ".." + alt(z.MaxAlt),
+		str(z.ActiveFrom) + ".." + str(z.ActiveTo),
+		tm(z.ValidFrom) + ".." + tm(z.ValidTo),
+	}, " ")
+}
@@ -0,0 +1,74 @@
+// Package solar вычисляет высоту Солнца по алгоритму NOAA (точность порядка минуты
+// для моментов восхода и захода в широтах России) без обращения к внешним сервисам.
+package solar
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	Day      = "day"      // Солнце над горизонтом
+	Twilight = "twilight" // Гражданские сумерки: центр диска от 0.833° до 6° под горизонтом
+	Night    = "night"    // Солнце ниже 6° под горизонтом
+)
+
+const (
+	// HorizonElevation — высота центра диска в момент восхода/захода с учетом рефракции
+	HorizonElevation = -0.833
+	// CivilTwilightElevation — нижняя граница гражданских сумерек
+	CivilTwilightElevation = -6.0
+)
+
+// Elevation возвращает геометрическую высоту центра Солнца над горизонтом в градусах
+// для момента t в точке lat/lon (градусы, восточная долгота положительна)
+func Elevation(t time.Time, lat, lon float64) float64 {
+	t = t.UTC()
+	jd := float64(t.Unix())/86400 + 2440587.5
+	jc := (jd - 2451545) / 36525
+
+	meanLong := math.Mod(280.46646+jc*(36000.76983+jc*0.0003032), 360)
+	meanAnom := 357.52911 + jc*(35999.05029-0.0001537*jc)
+	ecc := 0.016708634 - jc*(0.000042037+0.0000001267*jc)
+	center := math.Sin(rad(meanAnom))*(1.914602-jc*(0.004817+0.000014*jc)) +
+		math.Sin(rad(2*meanAnom))*(0.019993-0.000101*jc) +
+		math.Sin(rad(3*meanAnom))*0.000289
+	omega := 125.04 - 1934.136*jc
+	appLong := meanLong + center - 0.00569 - 0.00478*math.Sin(rad(omega))
+	meanObliq := 23 + (26+(21.448-jc*(46.815+jc*(0.00059-jc*0.001813)))/60)/60
+	obliq := meanObliq + 0.00256*math.Cos(rad(omega))
+	decl := math.Asin(math.Sin(rad(obliq)) * math.Sin(rad(appLong)))
+
+	y := math.Pow(math.Tan(rad(obliq/2)), 2)
+	eqTime := 4 * deg(y*math.Sin(2*rad(meanLong))-
+		2*ecc*math.Sin(rad(meanAnom))+
+		4*ecc*y*math.Sin(rad(meanAnom))*math.Cos(2*rad(meanLong))-
+		0.5*y*y*math.Sin(4*rad(meanLong))-
+		1.25*ecc*ecc*math.Sin(2*rad(meanAnom)))
+
+	minutes := float64(t.Hour()*60+t.Minute()) + float64(t.Second())/60
+	trueSolar := math.Mod(minutes+eqTime+4*lon, 1440)
+	if trueSolar < 0 {
+		trueSolar += 1440
+	}
+	hourAngle := trueSolar/4 - 180
+
+	cosZenith := math.Sin(rad(lat))*math.Sin(decl) + math.Cos(rad(lat))*math.Cos(decl)*math.Cos(rad(hourAngle))
+	cosZenith = math.Max(-1, math.Min(1, cosZenith))
+	return 90 - deg(math.Acos(cosZenith))
+}
+
+// Classify относит момент t в точке lat/lon к дню, гражданским сумеркам или ночи
+func Classify(t time.Time, lat, lon float64) string {
+	switch e := Elevation(t, lat, lon); {
+	case e > HorizonElevation:
+		return Day
+	case e > CivilTwilightElevation:
+		return Twilight
+	default:
+		return Night
+	}
+}
+
+func rad(d float64) float64 { return d * math.Pi / 180 }
+func deg(r float64) float64 { return r * 180 / math.Pi }
@@ -0,0 +1,70 @@
+package solar
+
+import (
+	"testing"
+	"time"
+)
+
+// Время восхода и захода по опубликованным таблицам, в UTC
+func TestElevationSunriseSunset(t *testing.T) {
+	const tolerance = 3 * time.Minute
+	tests := []struct {
+		name     string
+		lat, lon float64
+		sunrise  string
+		sunset   string
+	}{
+		{"Москва, летнее солнцестояние", 55.7558, 37.6173, "2024-06-21T00:44:00Z", "2024-06-21T18:18:00Z"},
+		{"Москва, весеннее равноденствие", 55.7558, 37.6173, "2024-03-20T03:30:00Z", "2024-03-20T15:43:00Z"},
+		{"Новосибирск, зимнее солнцестояние", 55.0084, 82.9357, "2024-12-21T02:52:00Z", "2024-12-21T10:02:00Z"},
+		{"Владивосток, осеннее равноденствие", 43.1155, 131.8855, "2024-09-21T21:00:00Z", "2024-09-22T09:10:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sunrise, _ := time.Parse(time.RFC3339, tt.sunrise)
+			sunset, _ := time.Parse(time.RFC3339, tt.sunset)
+			checks := []struct {
+				at    time.Time
+				above bool
+			}{
+				{sunrise.Add(-tolerance), false},
+				{sunrise.Add(tolerance), true},
+				{sunset.Add(-tolerance), true},
+				{sunset.Add(tolerance), false},
+			}
+			for _, c := range checks {
+				e := Elevation(c.at, tt.lat, tt.lon)
+				if (e > HorizonElevation) != c.above {
+					t.Errorf("Elevation(%s) = %.3f, want above horizon = %v", c.at.Format(time.RFC3339), e, c.above)
+				}
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		at       string
+		lat, lon float64
+		want     string
+	}{
+		{"Москва, полдень", "2024-06-21T09:30:00Z", 55.7558, 37.6173, Day},
+		{"Москва, после захода зимой", "2024-12-21T13:20:00Z", 55.7558, 37.6173, Twilight},
+		{"Москва, полночь зимой", "2024-12-21T21:00:00Z", 55.7558, 37.6173, Night},
+		// полярный день: Солнце не заходит и в полночь
+		{"Мурманск, полночь в июне", "2024-06-20T21:00:00Z", 68.9585, 33.0827, Day},
+		// полярная ночь: в полдень Солнце под горизонтом, но выше −6°
+		{"Мурманск, полдень в декабре", "2024-12-21T09:45:00Z", 68.9585, 33.0827, Twilight},
+		// полярная ночь севернее 73°: гражданских сумерек нет и в полдень
+		{"Диксон, полдень в декабре", "2024-12-21T06:40:00Z", 73.507, 80.5464, Night},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at, _ := time.Parse(time.RFC3339, tt.at)
+			if got := Classify(at, tt.lat, tt.lon); got != tt.want {
+				t.Errorf("Classify = %q, want %q (elevation %.3f)", got, tt.want, Elevation(at, tt.lat, tt.lon))
+			}
+		})
+	}
+}
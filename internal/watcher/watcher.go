@@ -0,0 +1,223 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	ProcessedDir = "processed"
+	FailedDir    = "failed"
+)
+
+// Handler обрабатывает готовый к загрузке файл. Ошибка переносит файл в failed.
+type Handler func(ctx context.Context, path string) error
+
+type Config struct {
+	Dir       string        // Каталог, в который выгружаются файлы
+	Pattern   string        // Шаблон имени файла, например *.xlsx
+	Interval  time.Duration // Период опроса каталога
+	StableFor int           // Сколько опросов подряд размер и время изменения должны совпадать
+	Poll      bool          // Только опрос, без fsnotify (сетевые каталоги)
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+	stable  int
+}
+
+// fileStat — файл каталога, подходящий под шаблон, на момент опроса
+type fileStat struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Watcher следит за каталогом и передает в обработчик новые или измененные файлы,
+// когда их запись завершена, после чего переносит их в processed или failed
+type Watcher struct {
+	cfg    Config
+	handle Handler
+	files  map[string]fileState
+	// обработанные файлы, которые не удалось перенести: повторно не загружаются,
+	// пока не изменятся
+	stuck map[string]fileState
+	// move переносит файл в подкаталог; заменяется в тестах
+	move func(path, sub string) (string, error)
+}
+
+func New(cfg Config, handle Handler) *Watcher {
+	if cfg.Pattern == "" {
+		cfg.Pattern = "*.xlsx"
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.StableFor < 1 {
+		cfg.StableFor = 2
+	}
+	w := &Watcher{cfg: cfg, handle: handle, files: make(map[string]fileState), stuck: make(map[string]fileState)}
+	w.move = w.moveFile
+	return w
+}
+
+// Run следит за каталогом до отмены контекста. Если fsnotify недоступен
+// (например, на сетевом ресурсе), используется только периодический опрос.
+func (w *Watcher) Run(ctx context.Context) error {
+	for _, sub := range []string{ProcessedDir, FailedDir} {
+		if err := os.MkdirAll(filepath.Join(w.cfg.Dir, sub), 0o755); err != nil {
+			return fmt.Errorf("failed to create %s dir: %w", sub, err)
+		}
+	}
+
+	var events chan fsnotify.Event
+	if !w.cfg.Poll {
+		fw, err := fsnotify.NewWatcher()
+		if err == nil {
+			err = fw.Add(w.cfg.Dir)
+		}
+		if err != nil {
+			slog.Warn("fsnotify unavailable, falling back to polling", "dir", w.cfg.Dir, "error", err)
+		} else {
+			defer fw.Close()
+			events = fw.Events
+		}
+	}
+	slog.Info("watching directory", "dir", w.cfg.Dir, "pattern", w.cfg.Pattern, "interval", w.cfg.Interval, "notify", events != nil)
+
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+	w.scan(ctx, true)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			w.scan(ctx, true)
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			// событие только сбрасывает состояние файла: стабильность считается по опросам
+			if ev.Has(fsnotify.Create) || ev.Has(fsnotify.Write) {
+				w.scan(ctx, false)
+			}
+		}
+	}
+}
+
+// scan сверяет размер и время изменения файлов с предыдущим опросом
+func (w *Watcher) scan(ctx context.Context, tick bool) {
+	files, err := w.list()
+	if err != nil {
+		slog.Error("failed to read watch dir", "dir", w.cfg.Dir, "error", err)
+		return
+	}
+	w.observe(ctx, files, tick)
+}
+
+// list возвращает файлы каталога по шаблону, пропуская временные файлы Excel (~$)
+func (w *Watcher) list() ([]fileStat, error) {
+	entries, err := os.ReadDir(w.cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []fileStat
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), "~$") {
+			continue
+		}
+		if ok, _ := filepath.Match(w.cfg.Pattern, e.Name()); !ok {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, fileStat{path: filepath.Join(w.cfg.Dir, e.Name()), size: info.Size(), modTime: info.ModTime()})
+	}
+	return files, nil
+}
+
+// observe учитывает результат опроса. Файл передается в обработку, когда он
+// не менялся StableFor опросов подряд; событие fsnotify (tick = false) только
+// сбрасывает счетчик измененного файла.
+func (w *Watcher) observe(ctx context.Context, files []fileStat, tick bool) {
+	present := make(map[string]struct{})
+	for _, f := range files {
+		path := f.path
+		present[path] = struct{}{}
+
+		if st, ok := w.stuck[path]; ok {
+			if st.size == f.size && st.modTime.Equal(f.modTime) {
+				continue
+			}
+			delete(w.stuck, path)
+		}
+		st, known := w.files[path]
+		if !known || st.size != f.size || !st.modTime.Equal(f.modTime) {
+			w.files[path] = fileState{size: f.size, modTime: f.modTime}
+			continue
+		}
+		if !tick {
+			continue
+		}
+		st.stable++
+		w.files[path] = st
+		if st.stable < w.cfg.StableFor {
+			continue
+		}
+
+		delete(w.files, path)
+		if !w.process(ctx, path) {
+			w.stuck[path] = fileState{size: f.size, modTime: f.modTime}
+		}
+	}
+	for path := range w.files {
+		if _, ok := present[path]; !ok {
+			delete(w.files, path)
+		}
+	}
+	for path := range w.stuck {
+		if _, ok := present[path]; !ok {
+			delete(w.stuck, path)
+		}
+	}
+}
+
+// process обрабатывает файл и переносит его в processed или failed.
+// Возвращает false, если файл остался в каталоге.
+func (w *Watcher) process(ctx context.Context, path string) bool {
+	target := ProcessedDir
+	if err := w.handle(ctx, path); err != nil {
+		slog.Error("failed to process watched file", "path", path, "error", err)
+		target = FailedDir
+	}
+	dst, err := w.move(path, target)
+	if err != nil {
+		slog.Error("failed to move watched file", "path", path, "target", target, "error", err)
+		return false
+	}
+	slog.Info("watched file moved", "path", path, "to", dst)
+	return true
+}
+
+// moveFile переносит файл в подкаталог; при совпадении имени добавляет метку времени
+func (w *Watcher) moveFile(path, sub string) (string, error) {
+	name := filepath.Base(path)
+	dst := filepath.Join(w.cfg.Dir, sub, name)
+	if _, err := os.Stat(dst); err == nil {
+		ext := filepath.Ext(name)
+		dst = filepath.Join(w.cfg.Dir, sub, fmt.Sprintf("%s_%s%s", strings.TrimSuffix(name, ext), time.Now().Format("20060102T150405"), ext))
+	}
+	return dst, os.Rename(path, dst)
+}
@@ -0,0 +1,221 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func stat(path string, size int64, minute int) fileStat {
+	return fileStat{path: path, size: size, modTime: t0.Add(time.Duration(minute) * time.Minute)}
+}
+
+type step struct {
+	files []fileStat
+	tick  bool     // false — событие fsnotify
+	want  []string // переносы на этом шаге: "processed/a.xlsx"
+}
+
+func TestObserve(t *testing.T) {
+	a, b := stat("a.xlsx", 100, 0), stat("b.xlsx", 200, 0)
+	aGrown := stat("a.xlsx", 150, 0)
+	aTouched := stat("a.xlsx", 100, 1)
+
+	tests := []struct {
+		name       string
+		stableFor  int
+		failHandle string // файл, обработка которого завершается ошибкой
+		failMove   string // файл, который не удается перенести
+		steps      []step
+	}{
+		{
+			name:      "processed after StableFor unchanged ticks",
+			stableFor: 2,
+			steps: []step{
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{a}, tick: true, want: []string{"processed/a.xlsx"}},
+			},
+		},
+		{
+			name:      "size change restarts the count",
+			stableFor: 2,
+			steps: []step{
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{aGrown}, tick: true},
+				{files: []fileStat{aGrown}, tick: true},
+				{files: []fileStat{aGrown}, tick: true, want: []string{"processed/a.xlsx"}},
+			},
+		},
+		{
+			name:      "modification time change restarts the count",
+			stableFor: 1,
+			steps: []step{
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{aTouched}, tick: true},
+				{files: []fileStat{aTouched}, tick: true, want: []string{"processed/a.xlsx"}},
+			},
+		},
+		{
+			name:      "events do not count as stable polls",
+			stableFor: 2,
+			steps: []step{
+				{files: []fileStat{a}, tick: false},
+				{files: []fileStat{a}, tick: false},
+				{files: []fileStat{a}, tick: false},
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{a}, tick: true, want: []string{"processed/a.xlsx"}},
+			},
+		},
+		{
+			name:      "event with a change resets a counting file",
+			stableFor: 2,
+			steps: []step{
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{aGrown}, tick: false},
+				{files: []fileStat{aGrown}, tick: true},
+				{files: []fileStat{aGrown}, tick: true, want: []string{"processed/a.xlsx"}},
+			},
+		},
+		{
+			name:       "handler error moves to failed",
+			stableFor:  1,
+			failHandle: "a.xlsx",
+			steps: []step{
+				{files: []fileStat{a, b}, tick: true},
+				{files: []fileStat{a, b}, tick: true, want: []string{"failed/a.xlsx", "processed/b.xlsx"}},
+			},
+		},
+		{
+			name:      "file that failed to move is skipped until it changes",
+			stableFor: 1,
+			failMove:  "a.xlsx",
+			steps: []step{
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{a}, tick: true, want: []string{"processed/a.xlsx"}},
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{a}, tick: false},
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{aTouched}, tick: true},
+				{files: []fileStat{aTouched}, tick: true, want: []string{"processed/a.xlsx"}},
+				{files: []fileStat{aTouched}, tick: true},
+			},
+		},
+		{
+			name:      "stuck file removed and restored unchanged is processed again",
+			stableFor: 1,
+			failMove:  "a.xlsx",
+			steps: []step{
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{a}, tick: true, want: []string{"processed/a.xlsx"}},
+				{files: nil, tick: true},
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{a}, tick: true, want: []string{"processed/a.xlsx"}},
+			},
+		},
+		{
+			name:      "disappeared file starts over",
+			stableFor: 2,
+			steps: []step{
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{a}, tick: true},
+				{files: []fileStat{b}, tick: true},
+				{files: []fileStat{a, b}, tick: true},
+				{files: []fileStat{a, b}, tick: true, want: []string{"processed/b.xlsx"}},
+				{files: []fileStat{a}, tick: true, want: []string{"processed/a.xlsx"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var moved []string
+			w := New(Config{Dir: ".", StableFor: tt.stableFor}, func(_ context.Context, path string) error {
+				if path == tt.failHandle {
+					return errors.New("parse error")
+				}
+				return nil
+			})
+			w.move = func(path, sub string) (string, error) {
+				moved = append(moved, sub+"/"+path)
+				if path == tt.failMove {
+					return "", errors.New("permission denied")
+				}
+				return filepath.Join(sub, path), nil
+			}
+			for i, s := range tt.steps {
+				moved = nil
+				w.observe(context.Background(), s.files, s.tick)
+				if !slices.Equal(moved, s.want) {
+					t.Fatalf("step %d: moved %v, want %v", i, moved, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.xlsx", "b.XLSX", "~$a.xlsx", "c.csv", "d.xlsx"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "dir.xlsx"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files, err := New(Config{Dir: dir}, nil).list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range files {
+		got = append(got, filepath.Base(f.path))
+		if f.size != int64(len(filepath.Base(f.path))) {
+			t.Errorf("%s: size %d", f.path, f.size)
+		}
+	}
+	if want := []string{"a.xlsx", "d.xlsx"}; !slices.Equal(got, want) {
+		t.Errorf("list = %v, want %v", got, want)
+	}
+
+	if _, err := New(Config{Dir: filepath.Join(dir, "missing")}, nil).list(); err == nil {
+		t.Error("list of missing dir: want error")
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ProcessedDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	w := New(Config{Dir: dir}, nil)
+	for i := range 2 {
+		src := filepath.Join(dir, "a.xlsx")
+		if err := os.WriteFile(src, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		dst, err := w.moveFile(src, ProcessedDir)
+		if err != nil {
+			t.Fatalf("move %d: %v", i, err)
+		}
+		name := filepath.Base(dst)
+		if i == 0 && name != "a.xlsx" {
+			t.Errorf("first move to %s, want a.xlsx", name)
+		}
+		if i == 1 && (name == "a.xlsx" || !strings.HasPrefix(name, "a_") || filepath.Ext(name) != ".xlsx") {
+			t.Errorf("second move to %s, want timestamped name", name)
+		}
+		if _, err := os.Stat(src); !os.IsNotExist(err) {
+			t.Errorf("move %d: source still exists", i)
+		}
+	}
+}